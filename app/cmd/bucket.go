@@ -0,0 +1,107 @@
+/*
+ * @PackageName: cmd
+ * @FileName: bucket.go
+ * @Description: 存储桶管理命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:39:55
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:39:55
+ */
+
+package cmd
+
+import (
+	"easy_dfs/app/services"
+	"easy_dfs/bootstrap"
+	"easy_dfs/model"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func init() {
+	register(&Command{
+		Name:  "bucket",
+		Short: "管理存储桶: create/list/delete/rename",
+		Run:   runBucket,
+	})
+}
+
+// runBucket 管理存储桶,直接读写配置目录
+func runBucket(args []string) error {
+	bootstrap.SetupConfigDir()
+	bs := new(services.BucketService)
+
+	return subcommand("bucket", args, map[string]func(args []string) error{
+		"create": func(args []string) error {
+			fs := flag.NewFlagSet("bucket create", flag.ContinueOnError)
+			policy := fs.String("policy", "private", "访问策略")
+			if err := fs.Parse(args); err != nil {
+				return err
+			}
+			if fs.NArg() != 1 {
+				return errors.New("用法: easy_dfs bucket create [--policy private] <名称>")
+			}
+			name := fs.Arg(0)
+			if err := bs.CreateBucket(model.BucketInfo{Name: name, AccessPolicy: *policy}); err != nil {
+				return err
+			}
+			fmt.Printf("已创建存储桶 %s\n", name)
+			return nil
+		},
+		"list": func(args []string) error {
+			list, err := bs.GetBucketList()
+			if err != nil {
+				return err
+			}
+			for _, b := range list {
+				fmt.Printf("%s\t%s\n", b.Name, b.AccessPolicy)
+			}
+			return nil
+		},
+		"delete": func(args []string) error {
+			fs := flag.NewFlagSet("bucket delete", flag.ContinueOnError)
+			force := fs.Bool("force", false, "删除存储桶中的所有文件")
+			if err := fs.Parse(args); err != nil {
+				return err
+			}
+			if fs.NArg() != 1 {
+				return errors.New("用法: easy_dfs bucket delete [--force] <名称>")
+			}
+			name := fs.Arg(0)
+			if !*force {
+				if err := bs.DeleteBucket(name); err != nil {
+					return err
+				}
+				fmt.Printf("已删除存储桶 %s\n", name)
+				return nil
+			}
+
+			bds := new(services.BucketDeleteService)
+			job, err := bds.ForceDelete(name)
+			if err != nil {
+				return err
+			}
+			for job.Status == services.BucketDeleteRunning {
+				time.Sleep(200 * time.Millisecond)
+				job = bds.Job(job.ID)
+			}
+			if job.Status != services.BucketDeleteCompleted {
+				return fmt.Errorf("删除存储桶 %s 失败: %s", name, job.Error)
+			}
+			fmt.Printf("已删除存储桶 %s 及其中的 %d 个文件\n", name, job.Deleted)
+			return nil
+		},
+		"rename": func(args []string) error {
+			if len(args) != 2 {
+				return errors.New("用法: easy_dfs bucket rename <名称> <新名称>")
+			}
+			if err := bs.RenameBucket(args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("已将存储桶 %s 重命名为 %s\n", args[0], args[1])
+			return nil
+		},
+	}, "create|list|delete|rename")
+}
@@ -0,0 +1,100 @@
+/*
+ * @PackageName: cmd
+ * @FileName: cmd.go
+ * @Description: 服务端子命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:30:44
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:30:44
+ */
+
+package cmd
+
+import (
+	"easy_dfs/bootstrap"
+	"easy_dfs/pkg/config"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Command 子命令
+type Command struct {
+	// 命令名称
+	Name string
+	// 命令说明
+	Short string
+	// 执行命令
+	Run func(args []string) error
+}
+
+// commands 已注册的子命令
+var commands = map[string]*Command{}
+
+// register 注册子命令
+func register(c *Command) {
+	commands[c.Name] = c
+}
+
+// Execute 解析全局参数并执行子命令,未指定子命令时启动服务,返回进程退出码
+func Execute(args []string) int {
+	fs := flag.NewFlagSet("easy_dfs", flag.ContinueOnError)
+	env := fs.String("env", "", "加载 app.yml.{env} 配置文件,如 --env=testing")
+	fs.Usage = usage
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// 加载配置文件及注册的默认配置
+	config.InitConfig(*env)
+	bootstrap.SetupLogger()
+
+	name := "serve"
+	if fs.NArg() > 0 {
+		name = fs.Arg(0)
+		args = fs.Args()[1:]
+	} else {
+		args = nil
+	}
+	c, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+		usage()
+		return 2
+	}
+	if err := c.Run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "easy_dfs:", err)
+		return 1
+	}
+	return 0
+}
+
+// usage 输出命令帮助
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: easy_dfs [--env 环境] <命令> [参数]\n\n命令:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].Short)
+	}
+}
+
+// subcommand 分发二级子命令,如 key create
+func subcommand(name string, args []string, subs map[string]func(args []string) error, help string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: easy_dfs %s %s", name, help)
+	}
+	run, ok := subs[args[0]]
+	if !ok {
+		return fmt.Errorf("未知命令: %s %s\n用法: easy_dfs %s %s", name, args[0], name, help)
+	}
+	return run(args[1:])
+}
@@ -0,0 +1,113 @@
+/*
+ * @PackageName: cmd
+ * @FileName: config.go
+ * @Description: 配置校验命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:47:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:47:12
+ */
+
+package cmd
+
+import (
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/config"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func init() {
+	register(&Command{
+		Name:  "config",
+		Short: "配置管理: validate",
+		Run:   runConfig,
+	})
+}
+
+// runConfig 配置管理
+func runConfig(args []string) error {
+	return subcommand("config", args, map[string]func(args []string) error{
+		"validate": runConfigValidate,
+	}, "validate")
+}
+
+// runConfigValidate 校验已加载的配置项及配置文件,输出所有问题
+func runConfigValidate(args []string) error {
+	problems, warnings := validateConfig()
+	for _, w := range warnings {
+		fmt.Println("[warn]", w)
+	}
+	for _, p := range problems {
+		fmt.Println("[error]", p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("配置校验失败,共 %d 个错误", len(problems))
+	}
+	fmt.Printf("配置校验通过(env=%s)\n", config.Get("app.env"))
+	return nil
+}
+
+// validateConfig 返回错误及警告列表
+func validateConfig() (problems, warnings []string) {
+	if config.Get("app.env") == "" {
+		problems = append(problems, "app.env 不能为空")
+	}
+	if port, err := strconv.Atoi(config.Get("app.port")); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("app.port 有误: %q", config.Get("app.port")))
+	}
+	if u, err := url.Parse(config.Get("app.url")); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("app.url 有误: %q", config.Get("app.url")))
+	}
+	if _, err := time.LoadLocation(config.Get("app.timezone")); err != nil {
+		problems = append(problems, fmt.Sprintf("app.timezone 有误: %v", err))
+	}
+	if config.GetInt("app.drain_timeout", 30) <= 0 {
+		problems = append(problems, "app.drain_timeout 必须大于0")
+	}
+
+	switch config.Get("log.level") {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("log.level 仅支持debug、info、warn、error: %q", config.Get("log.level")))
+	}
+	switch config.Get("log.type") {
+	case "single", "daily", "monthly", "yearly":
+	default:
+		problems = append(problems, fmt.Sprintf("log.type 仅支持single、daily、monthly、yearly: %q", config.Get("log.type")))
+	}
+
+	if config.GetBool("image.enabled", true) {
+		if config.GetInt("image.max_width", 4096) <= 0 || config.GetInt("image.max_height", 4096) <= 0 {
+			problems = append(problems, "image.max_width 和 image.max_height 必须大于0")
+		}
+		if q := config.GetInt("image.default_quality", 80); q < 1 || q > 100 {
+			problems = append(problems, "image.default_quality 取值范围为1-100")
+		}
+		if config.GetInt("image.cache_max_size", 1024) < 0 {
+			problems = append(problems, "image.cache_max_size 不能为负数")
+		}
+	}
+
+	if config.GetInt("webhook.timeout", 10) <= 0 || config.GetInt("webhook.max_attempts", 8) <= 0 {
+		problems = append(problems, "webhook.timeout 和 webhook.max_attempts 必须大于0")
+	}
+	if config.GetInt("webhook.retry_max", 3600) < config.GetInt("webhook.retry_base", 5) {
+		problems = append(problems, "webhook.retry_max 不能小于 webhook.retry_base")
+	}
+
+	if config.GetBool("metrics.enabled", false) && config.Get("metrics.token") == "" {
+		warnings = append(warnings, "metrics.enabled 已开启但未设置 metrics.token,/metrics 可被任意访问")
+	}
+
+	// 配置文件可正常解析
+	if _, err := new(services.BucketService).GetBucketList(); err != nil {
+		problems = append(problems, fmt.Sprintf("存储桶配置文件解析失败: %v", err))
+	}
+	if _, err := new(services.AccessKeyService).GetAccessKeyList(); err != nil {
+		problems = append(problems, fmt.Sprintf("访问密钥配置文件解析失败: %v", err))
+	}
+	return problems, warnings
+}
@@ -0,0 +1,103 @@
+/*
+ * @PackageName: cmd
+ * @FileName: fsck.go
+ * @Description: 数据一致性检查命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:43:30
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:43:30
+ */
+
+package cmd
+
+import (
+	"easy_dfs/app/services"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register(&Command{
+		Name:  "fsck",
+		Short: "检查配置文件与存储目录是否一致,可按指定的方式修复",
+		Run:   runFsck,
+	})
+}
+
+// runFsck 执行一致性检查,发现问题时返回错误以便脚本判断;指定 --fix 时默认试运行,同时指定 --apply 才会修复
+func runFsck(args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	actions := make(map[string]string)
+	var targets []string
+	fs.Func("fix", "修复方式,格式为 问题类型=adopt|quarantine|delete,可指定多次", func(value string) error {
+		issueType, action, ok := strings.Cut(value, "=")
+		if !ok {
+			return errors.New("格式应为 问题类型=修复方式")
+		}
+		actions[issueType] = action
+		return nil
+	})
+	fs.Func("target", "只处理指定的问题对象,可指定多次", func(value string) error {
+		targets = append(targets, value)
+		return nil
+	})
+	apply := fs.Bool("apply", false, "执行修复,未指定时只列出将执行的操作,不做修改")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fcs := new(services.FsckService)
+	if len(actions) > 0 {
+		return reconcile(fcs, services.ReconcileOptions{Actions: actions, Targets: targets, DryRun: !*apply})
+	}
+
+	issues, err := fcs.Check()
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if len(issue.Actions) > 0 {
+			fmt.Printf("[%s] %s: %s (可用修复方式: %s)\n", issue.Type, issue.Target, issue.Message, strings.Join(issue.Actions, ", "))
+			continue
+		}
+		fmt.Printf("[%s] %s: %s\n", issue.Type, issue.Target, issue.Message)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("发现 %d 个问题", len(issues))
+	}
+	fmt.Println("未发现问题")
+	return nil
+}
+
+// reconcile 执行修复并输出每个问题的处理结果,有处理失败时返回错误
+func reconcile(fcs *services.FsckService, opts services.ReconcileOptions) error {
+	results, err := fcs.Reconcile(opts)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			failed++
+			fmt.Printf("[%s] %s: %s 失败: %s\n", result.Type, result.Target, result.Action, result.Error)
+		case !result.Applied:
+			fmt.Printf("[%s] %s: 将执行 %s\n", result.Type, result.Target, result.Action)
+		case result.Destination != "":
+			fmt.Printf("[%s] %s: 已隔离到 %s\n", result.Type, result.Target, result.Destination)
+		default:
+			fmt.Printf("[%s] %s: 已执行 %s\n", result.Type, result.Target, result.Action)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个问题处理失败", failed)
+	}
+	if len(results) == 0 {
+		fmt.Println("没有需要处理的问题")
+	} else if opts.DryRun {
+		fmt.Println("试运行,未做任何修改;确认无误后加上 --apply 执行")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+ * @PackageName: cmd
+ * @FileName: key.go
+ * @Description: 访问密钥管理命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:36:02
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:36:02
+ */
+
+package cmd
+
+import (
+	"easy_dfs/app/services"
+	"easy_dfs/bootstrap"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func init() {
+	register(&Command{
+		Name:  "key",
+		Short: "管理访问密钥: create/list/revoke",
+		Run:   runKey,
+	})
+}
+
+// runKey 管理访问密钥,直接读写配置目录
+func runKey(args []string) error {
+	bootstrap.SetupConfigDir()
+	aks := new(services.AccessKeyService)
+
+	return subcommand("key", args, map[string]func(args []string) error{
+		"create": func(args []string) error {
+			fs := flag.NewFlagSet("key create", flag.ContinueOnError)
+			expire := fs.String("expire", "", "过期时间")
+			owner := fs.String("owner", "", "所属用户名,为空时视为管理员密钥")
+			if err := fs.Parse(args); err != nil {
+				return err
+			}
+			if fs.NArg() != 1 {
+				return errors.New("用法: easy_dfs key create [--expire 过期时间] [--owner 用户名] <名称>")
+			}
+			if *owner != "" {
+				if _, err := new(services.UserService).FindUser(*owner); err != nil {
+					return err
+				}
+			}
+			info, err := aks.CreateAndSaveAccessKey(fs.Arg(0), fs.Arg(0), *expire, *owner)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Name:\t%s\nAccessKey:\t%s\nSecretKey:\t%s\n", info.Name, info.AccessKey, info.SecretKey)
+			return nil
+		},
+		"list": func(args []string) error {
+			list, err := aks.GetAccessKeyList()
+			if err != nil {
+				return err
+			}
+			for _, info := range list {
+				status := "enabled"
+				if info.Status != 1 {
+					status = "revoked"
+				}
+				fmt.Printf("%s\t%s\t%s\t%s\t%s\n", info.Name, info.AccessKey, status, info.ExpireTime, info.Owner)
+			}
+			return nil
+		},
+		"revoke": func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("用法: easy_dfs key revoke <名称>")
+			}
+			if err := aks.RevokeAccessKey(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("已吊销访问密钥 %s\n", args[0])
+			return nil
+		},
+	}, "create|list|revoke")
+}
@@ -0,0 +1,36 @@
+/*
+ * @PackageName: cmd
+ * @FileName: serve.go
+ * @Description: 启动服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:33:17
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:33:17
+ */
+
+package cmd
+
+import (
+	"easy_dfs/bootstrap"
+	"log"
+)
+
+func init() {
+	register(&Command{
+		Name:  "serve",
+		Short: "启动 HTTP 服务(默认命令)",
+		Run:   runServe,
+	})
+}
+
+// runServe 启动 HTTP 服务
+func runServe(args []string) error {
+	// 程序启动时打印
+	log.Println("Server starting...")
+	bootstrap.SetupConfigDir()
+	bootstrap.SetupWebhook()
+	bootstrap.SetupScrub()
+	bootstrap.SetupMetrics()
+	bootstrap.SetupRoute()
+	return nil
+}
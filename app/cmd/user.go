@@ -0,0 +1,103 @@
+/*
+ * @PackageName: cmd
+ * @FileName: user.go
+ * @Description: 用户管理命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:52:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:52:40
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/bootstrap"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func init() {
+	register(&Command{
+		Name:  "user",
+		Short: "管理用户: create/list/revoke/delete",
+		Run:   runUser,
+	})
+}
+
+// runUser 管理用户,直接读写配置目录
+func runUser(args []string) error {
+	bootstrap.SetupConfigDir()
+	us := new(services.UserService)
+
+	return subcommand("user", args, map[string]func(args []string) error{
+		"create": func(args []string) error {
+			fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+			role := fs.String("role", user_role.READ_ONLY, "角色:admin、bucket-owner 或 read-only")
+			password := fs.String("password", "", "密码,为空时从标准输入读取一行")
+			if err := fs.Parse(args); err != nil {
+				return err
+			}
+			if fs.NArg() != 1 {
+				return errors.New("用法: easy_dfs user create [--role 角色] [--password 密码] <用户名>")
+			}
+			if *password == "" {
+				fmt.Fprint(os.Stderr, "密码: ")
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return errors.New("未读取到密码")
+				}
+				*password = strings.TrimRight(line, "\r\n")
+			}
+			user, err := us.CreateUser(fs.Arg(0), *password, *role)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("已创建用户 %s (%s)\n", user.Username, user.Role)
+			return nil
+		},
+		"list": func(args []string) error {
+			users, err := us.GetUserList()
+			if err != nil {
+				return err
+			}
+			for _, user := range users {
+				status := "enabled"
+				if user.Status != 1 {
+					status = "revoked"
+				}
+				provider := user.Provider
+				if provider == "" {
+					provider = "local"
+				}
+				fmt.Printf("%s\t%s\t%s\t%s\t%s\n", user.Username, user.Role, status, provider, user.CreatedAt)
+			}
+			return nil
+		},
+		"revoke": func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("用法: easy_dfs user revoke <用户名>")
+			}
+			if err := us.RevokeUser(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("已禁用用户 %s 并吊销其访问密钥\n", args[0])
+			return nil
+		},
+		"delete": func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("用法: easy_dfs user delete <用户名>")
+			}
+			if err := us.DeleteUser(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("已删除用户 %s 及其访问密钥\n", args[0])
+			return nil
+		},
+	}, "create|list|revoke|delete")
+}
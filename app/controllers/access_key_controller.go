@@ -14,6 +14,8 @@ import (
 	"easy_dfs/app/enum/response_code"
 	"easy_dfs/app/services"
 	"easy_dfs/model"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/auth"
 	"easy_dfs/pkg/http/http_response"
 	"errors"
 	"github.com/gin-gonic/gin"
@@ -22,6 +24,7 @@ import (
 // AccessKeyController 访问密钥控制器
 type AccessKeyController struct {
 	AccessKeyService services.AccessKeyService
+	UserService      services.UserService
 }
 
 // CreateAccessKey 创建访问密钥
@@ -31,11 +34,23 @@ func (akc *AccessKeyController) CreateAccessKey(c *gin.Context) {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
 		return
 	}
+	audit.SetResource(c, accessKeyInfo.Name)
 
 	if accessKeyInfo.Name == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("访问密钥名称不能为空"))
 		return
 	}
+	// 密钥属于当前用户,管理员可以为其他用户创建
+	principal, _ := auth.Principal(c)
+	if !auth.IsAdmin(c) || accessKeyInfo.Owner == "" {
+		accessKeyInfo.Owner = principal.Username
+	}
+	if accessKeyInfo.Owner != "" {
+		if _, err := akc.UserService.FindUser(accessKeyInfo.Owner); err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+	}
 	// if accessKeyInfo.ExpireTime == "" {
 	// 	http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("过期时间不能为空"))
 	// 	return
@@ -66,6 +81,17 @@ func (akc *AccessKeyController) ListAccessKeys(c *gin.Context) {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
 	}
+	// 非管理员只能查看自己的密钥
+	if !auth.IsAdmin(c) {
+		principal, _ := auth.Principal(c)
+		owned := make([]model.AccessKeyInfo, 0, len(accessKeyList))
+		for _, info := range accessKeyList {
+			if info.Owner == principal.Username {
+				owned = append(owned, info)
+			}
+		}
+		accessKeyList = owned
+	}
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", accessKeyList, nil)
 }
 
@@ -76,7 +102,7 @@ func (akc *AccessKeyController) GetAccessKeyInfo(c *gin.Context) {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("访问密钥名称不能为空"))
 		return
 	}
-	accessKeyInfo, err := akc.AccessKeyService.GetAccessKey(name)
+	accessKeyInfo, err := akc.findOwnedAccessKey(c, name)
 	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
@@ -91,6 +117,11 @@ func (akc *AccessKeyController) DeleteAccessKey(c *gin.Context) {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("访问密钥名称不能为空"))
 		return
 	}
+	audit.SetResource(c, name)
+	if _, err := akc.findOwnedAccessKey(c, name); err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
 	err := akc.AccessKeyService.DeleteAccessKey(name)
 	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
@@ -98,3 +129,16 @@ func (akc *AccessKeyController) DeleteAccessKey(c *gin.Context) {
 	}
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "删除成功", nil, nil)
 }
+
+// findOwnedAccessKey 查找访问密钥,非管理员只能找到自己的密钥
+func (akc *AccessKeyController) findOwnedAccessKey(c *gin.Context, name string) (*model.AccessKeyInfo, error) {
+	accessKeyInfo, err := akc.AccessKeyService.GetAccessKey(name)
+	if err != nil {
+		return nil, err
+	}
+	principal, _ := auth.Principal(c)
+	if !auth.IsAdmin(c) && accessKeyInfo.Owner != principal.Username {
+		return nil, errors.New("name 不存在")
+	}
+	return accessKeyInfo, nil
+}
@@ -0,0 +1,106 @@
+/*
+ * @PackageName: controllers
+ * @FileName: audit_controller.go
+ * @Description: 审计日志控制器
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:35:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:35:12
+ */
+
+package controllers
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/http/http_response"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 单次查询返回的最大记录数
+const maxAuditLimit = 1000
+
+// 审计日志控制器
+type AuditController struct {
+	AuditService services.AuditService
+}
+
+// 查询审计日志,按时间倒序
+func (ac *AuditController) ListAuditRecords(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	query.Limit = cast.ToInt(c.DefaultQuery("limit", "100"))
+	if query.Limit <= 0 || query.Limit > maxAuditLimit {
+		query.Limit = maxAuditLimit
+	}
+	records, err := ac.AuditService.List(query)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", records, nil)
+}
+
+// 导出审计日志,按时间顺序输出 JSON Lines
+func (ac *AuditController) ExportAuditRecords(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename=audit.jsonl")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	err = ac.AuditService.Each(query, func(record model.AuditRecord) error {
+		return encoder.Encode(record)
+	})
+	if err != nil {
+		// 响应已开始输出,只能中断
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// parseAuditQuery 解析查询条件,时间支持 RFC3339、日期(2006-01-02)及 Unix 秒
+func parseAuditQuery(c *gin.Context) (services.AuditQuery, error) {
+	query := services.AuditQuery{
+		Key:    c.Query("key"),
+		Bucket: c.Query("bucket"),
+		Action: c.Query("action"),
+	}
+	var err error
+	if query.Start, err = parseAuditTime(c.Query("start")); err != nil {
+		return query, errors.New("start格式有误")
+	}
+	if query.End, err = parseAuditTime(c.Query("end")); err != nil {
+		return query, errors.New("end格式有误")
+	}
+	return query, nil
+}
+
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	// 日期按应用时区解析,与记录时间一致
+	return time.ParseInLocation("2006-01-02", value, app.TimenowInTimezone().Location())
+}
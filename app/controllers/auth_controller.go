@@ -9,3 +9,181 @@
  */
 
 package controllers
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/auth"
+	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/oidc"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 认证控制器
+type AuthController struct {
+	UserService services.UserService
+	OidcService services.OidcService
+}
+
+// 登录请求参数
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// 修改密码请求参数
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// 使用用户名及密码登录,返回会话令牌
+func (ac *AuthController) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	audit.SetResource(c, req.Username)
+	if req.Username == "" || req.Password == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("用户名及密码不能为空"))
+		return
+	}
+
+	result, err := ac.UserService.Login(req.Username, req.Password)
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		http_response.ResponseWithStatus(c, http.StatusUnauthorized, response_code.TOKEN_INVALID, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrUserDisabled):
+		http_response.ResponseWithStatus(c, http.StatusForbidden, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+	case err != nil:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	default:
+		http_response.Response(c, response_code.REQUEST_SUCCESS, true, "登录成功", result, nil)
+	}
+}
+
+// 获取当前请求方,使用升级前创建的访问密钥时用户名为空
+func (ac *AuthController) Me(c *gin.Context) {
+	principal, _ := auth.Principal(c)
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", principal, nil)
+}
+
+// 修改自己的密码,修改后已签发的会话令牌失效,需重新登录
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	principal, _ := auth.Principal(c)
+	if principal.Username == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("当前访问密钥不属于任何用户"))
+		return
+	}
+	audit.SetResource(c, principal.Username)
+
+	if err := services.ValidatePassword(req.NewPassword); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+
+	err := ac.UserService.ChangePassword(principal.Username, req.OldPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("原密码错误"))
+	case errors.Is(err, services.ErrExternalUser):
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+	case err != nil:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	default:
+		http_response.Response(c, response_code.REQUEST_SUCCESS, true, "修改成功", nil, nil)
+	}
+}
+
+// 获取可用的登录方式,供控制台决定是否显示单点登录入口
+func (ac *AuthController) Providers(c *gin.Context) {
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", gin.H{
+		"password": true,
+		"oidc":     ac.OidcService.Enabled(),
+	}, nil)
+}
+
+// 发起 OIDC 单点登录,跳转到身份提供方;redirect 为登录成功后跳转的本站路径,
+// 会话令牌以 #oidc_token=<令牌>&expires_at=<过期时间> 的形式附在其后,不指定时回调接口直接返回登录结果
+func (ac *AuthController) OidcLogin(c *gin.Context) {
+	authURL, err := ac.OidcService.Begin(c.Request.Context(), c.Query("redirect"))
+	switch {
+	case errors.Is(err, services.ErrOidcDisabled):
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrOidcRedirect):
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+	case err != nil:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	default:
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// 身份提供方登录后的回调,校验通过后创建或更新用户并签发会话令牌
+func (ac *AuthController) OidcCallback(c *gin.Context) {
+	// 用户取消登录或身份提供方拒绝授权
+	if idpError := c.Query("error"); idpError != "" {
+		err := errors.New("身份提供方拒绝登录: " + strings.TrimSpace(idpError+" "+c.Query("error_description")))
+		ac.oidcFailed(c, ac.OidcService.PendingRedirect(c.Query("state")), http.StatusUnauthorized, response_code.TOKEN_INVALID, err)
+		return
+	}
+
+	callback, err := ac.OidcService.Complete(c.Request.Context(), c.Query("code"), c.Query("state"))
+	audit.SetResource(c, callback.Username)
+	redirect := callback.Redirect
+	switch {
+	case errors.Is(err, services.ErrOidcDisabled):
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrOidcState):
+		ac.oidcFailed(c, redirect, http.StatusBadRequest, response_code.PARAM_ERROR, err)
+	case errors.Is(err, services.ErrOidcNoRole), errors.Is(err, services.ErrOidcLocalUser), errors.Is(err, services.ErrOidcUsernameTaken), errors.Is(err, services.ErrUserDisabled):
+		ac.oidcFailed(c, redirect, http.StatusForbidden, response_code.REQUEST_DENIED, err)
+	case errors.Is(err, oidc.ErrInvalidIDToken), errors.Is(err, oidc.ErrNonceMismatch), errors.Is(err, services.ErrOidcSubject):
+		ac.oidcFailed(c, redirect, http.StatusUnauthorized, response_code.TOKEN_INVALID, err)
+	case err != nil:
+		ac.oidcFailed(c, redirect, http.StatusBadGateway, response_code.REQUEST_FAILS, err)
+	default:
+		result := callback.Result
+		auth.SetPrincipal(c, model.Principal{Username: result.User.Username, Role: result.User.Role})
+		if redirect == "" {
+			http_response.Response(c, response_code.REQUEST_SUCCESS, true, "登录成功", result, nil)
+			return
+		}
+		c.Set(http_response.CodeKey, response_code.REQUEST_SUCCESS)
+		c.Redirect(http.StatusFound, withFragment(redirect, url.Values{
+			"oidc_token": {result.Token},
+			"expires_at": {result.ExpiresAt},
+		}))
+	}
+}
+
+// oidcFailed 单点登录失败时跳转回发起页面并附上错误信息,未指定跳转地址时直接返回错误
+func (ac *AuthController) oidcFailed(c *gin.Context, redirect string, status int, code string, err error) {
+	if redirect == "" {
+		http_response.ResponseWithStatus(c, status, code, false, "登录失败", nil, err)
+		return
+	}
+	c.Set(http_response.CodeKey, code)
+	c.Set(http_response.ErrorKey, err.Error())
+	c.Redirect(http.StatusFound, withFragment(redirect, url.Values{"oidc_error": {err.Error()}}))
+}
+
+// withFragment 将参数放在跳转地址的片段中,片段不会发送到服务器,避免令牌出现在访问日志中
+func withFragment(redirect string, values url.Values) string {
+	if i := strings.IndexByte(redirect, '#'); i >= 0 {
+		redirect = redirect[:i]
+	}
+	return redirect + "#" + values.Encode()
+}
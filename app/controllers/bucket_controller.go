@@ -11,19 +11,33 @@
 package controllers
 
 import (
+	"easy_dfs/app/enum/access_policy"
+	"easy_dfs/app/enum/event_type"
 	"easy_dfs/app/enum/response_code"
-	"easy_dfs/app/enum/system_default"
+	"easy_dfs/app/enum/user_role"
 	"easy_dfs/app/services"
 	"easy_dfs/model"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/auth"
+	"easy_dfs/pkg/bucketname"
+	"easy_dfs/pkg/cors"
 	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/referer"
+	"easy_dfs/pkg/website"
 	"errors"
 	"github.com/gin-gonic/gin"
-	"strings"
+	"github.com/spf13/cast"
+	"net/http"
+	"net/url"
 )
 
 // 存储桶控制器
 type BucketController struct {
-	BucketService services.BucketService
+	BucketService  services.BucketService
+	WebhookService services.WebhookService
+	FileService    services.FileService
+	DeleteService  services.BucketDeleteService
+	UserService    services.UserService
 }
 
 // CreateBucket 创建存储桶
@@ -34,19 +48,37 @@ func (bc *BucketController) CreateBucket(c *gin.Context) {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
 		return
 	}
+	audit.SetTarget(c, bucketInfo.Name, "")
 
 	if bucketInfo.Name == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
 		return
 	}
-	if strings.Contains(bucketInfo.Name, system_default.STORAGE_PATH) {
-		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, errors.New("存储桶名称非法"))
+	if err := bucketname.Validate(bucketInfo.Name); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
 		return
 	}
-	if bucketInfo.AccessPolicy == "" {
-		bucketInfo.AccessPolicy = "private"
+	if bucketInfo.AccessPolicy != "" && !access_policy.IsValid(bucketInfo.AccessPolicy) {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("访问策略只能为 private 或 public"))
+		return
+	}
+	if err := validateWebhooks(bucketInfo.Webhooks); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	// 存储桶属于创建者,管理员可以指定其他用户
+	if principal, _ := auth.Principal(c); !auth.IsAdmin(c) || bucketInfo.Owner == "" {
+		bucketInfo.Owner = principal.Username
+	}
+	if err := bc.checkOwner(bucketInfo.Owner); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	err := bc.BucketService.CreateBucket(bucketInfo)
+	if errors.Is(err, services.ErrBucketExists) || errors.Is(err, services.ErrBucketDeleting) {
+		http_response.ResponseWithStatus(c, http.StatusConflict, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+		return
 	}
-	err := bc.BucketService.CreateBucket(bucketInfo.Name, bucketInfo.AccessPolicy)
 	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
@@ -61,12 +93,24 @@ func (bc *BucketController) ListBuckets(c *gin.Context) {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
 	}
+	if principal, ok := ownerScope(c); ok {
+		owned := make([]model.BucketInfo, 0, len(bucketList))
+		for _, bucketInfo := range bucketList {
+			if services.OwnsBucket(principal, bucketInfo.Owner, bucketInfo.Name) {
+				owned = append(owned, bucketInfo)
+			}
+		}
+		bucketList = owned
+	}
+	for i := range bucketList {
+		services.MaskWebhookSecrets(&bucketList[i])
+	}
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", bucketList, nil)
 }
 
 // 获取存储桶信息
 func (bc *BucketController) GetBucketInfo(c *gin.Context) {
-	bucketName := c.Query("bucket")
+	bucketName := auth.Bucket(c)
 	if bucketName == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
 		return
@@ -76,22 +120,415 @@ func (bc *BucketController) GetBucketInfo(c *gin.Context) {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
 	}
+	services.MaskWebhookSecrets(bucketInfo)
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", bucketInfo, nil)
 
 }
 
-// 删除存储桶
+// 删除存储桶,默认只能删除空存储桶;force=true 时在后台删除其中的文件,返回删除任务
 func (bc *BucketController) DeleteBucket(c *gin.Context) {
 	// 获取存储桶名称
-	bucketName := c.Query("bucket")
+	bucketName := auth.Bucket(c)
 	if bucketName == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
 		return
 	}
-	err := bc.BucketService.DeleteBucket(bucketName)
+	force := c.Query("force") == "true"
+	if force {
+		audit.SetResource(c, "force")
+	}
+
+	var job *model.BucketDeleteJob
+	var err error
+	if force {
+		job, err = bc.DeleteService.ForceDelete(bucketName)
+	} else {
+		err = bc.BucketService.DeleteBucket(bucketName)
+	}
+	switch {
+	case errors.Is(err, services.ErrBucketNotFound):
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrBucketNotEmpty), errors.Is(err, services.ErrBucketDeleting):
+		http_response.ResponseWithStatus(c, http.StatusConflict, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+	case err != nil:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	case force:
+		http_response.ResponseWithStatus(c, http.StatusAccepted, response_code.REQUEST_SUCCESS, true, "删除任务已开始", job, nil)
+	default:
+		http_response.Response(c, response_code.REQUEST_SUCCESS, true, "删除成功", nil, nil)
+	}
+}
+
+// 获取强制删除存储桶的任务进度,不指定 id 时列出保留的全部任务
+func (bc *BucketController) GetDeleteJob(c *gin.Context) {
+	principal, scoped := ownerScope(c)
+	id := c.Query("id")
+	if id == "" {
+		jobs := bc.DeleteService.Jobs()
+		if scoped {
+			owned := make([]model.BucketDeleteJob, 0, len(jobs))
+			for _, job := range jobs {
+				if services.OwnsBucket(principal, job.Owner, job.Bucket) {
+					owned = append(owned, job)
+				}
+			}
+			jobs = owned
+		}
+		http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", jobs, nil)
+		return
+	}
+	job := bc.DeleteService.Job(id)
+	if job == nil || (scoped && !services.OwnsBucket(principal, job.Owner, job.Bucket)) {
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "删除任务不存在", nil, nil)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", job, nil)
+}
+
+// 修改存储桶请求参数,未传的项不修改
+type BucketUpdateRequest struct {
+	Bucket       string             `json:"bucket"`
+	AccessPolicy *string            `json:"accessPolicy"`
+	StorageType  *string            `json:"storageType"`
+	Description  *string            `json:"description"`
+	Tags         *map[string]string `json:"tags"`
+	Quota        *model.BucketQuota `json:"quota"`
+	Owner        *string            `json:"owner"` // 只有管理员可以修改
+}
+
+// 修改存储桶的访问策略、存储类型、描述、标签、配额及所有者
+func (bc *BucketController) UpdateBucket(c *gin.Context) {
+	var req BucketUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	update := services.BucketUpdate{
+		AccessPolicy: req.AccessPolicy,
+		StorageType:  req.StorageType,
+		Description:  req.Description,
+		Tags:         req.Tags,
+		Quota:        req.Quota,
+		Owner:        req.Owner,
+	}
+	if err := update.Validate(); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	if req.Owner != nil {
+		if !auth.IsAdmin(c) {
+			http_response.ResponseWithStatus(c, http.StatusForbidden, response_code.REQUEST_DENIED, false, "操作失败", nil, errors.New("只有管理员可以修改存储桶所有者"))
+			return
+		}
+		if err := bc.checkOwner(*req.Owner); err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+	}
+	bucketInfo, err := bc.BucketService.UpdateBucket(req.Bucket, update)
+	if errors.Is(err, services.ErrBucketNotFound) {
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+		return
+	}
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	services.MaskWebhookSecrets(bucketInfo)
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", bucketInfo, nil)
+}
+
+// 重命名存储桶请求参数
+type BucketRenameRequest struct {
+	Bucket  string `json:"bucket"`
+	NewName string `json:"newName"`
+}
+
+// 重命名存储桶,存储目录及元数据随之移动
+func (bc *BucketController) RenameBucket(c *gin.Context) {
+	var req BucketRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	audit.SetResource(c, req.NewName)
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	if err := bucketname.Validate(req.NewName); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	err := bc.BucketService.RenameBucket(req.Bucket, req.NewName)
+	switch {
+	case errors.Is(err, services.ErrBucketNotFound):
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrBucketExists), errors.Is(err, services.ErrBucketDeleting):
+		http_response.ResponseWithStatus(c, http.StatusConflict, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+	case err != nil:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	default:
+		http_response.Response(c, response_code.REQUEST_SUCCESS, true, "重命名成功", nil, nil)
+	}
+}
+
+// 事件通知配置请求参数
+type BucketWebhookRequest struct {
+	Bucket   string                `json:"bucket"`
+	Webhooks []model.WebhookConfig `json:"webhooks"`
+}
+
+// 设置存储桶事件通知
+func (bc *BucketController) SetBucketWebhooks(c *gin.Context) {
+	var req BucketWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	if err := validateWebhooks(req.Webhooks); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	err := bc.BucketService.UpdateBucketWebhooks(req.Bucket, req.Webhooks)
 	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
 	}
-	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "删除成功", nil, nil)
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", nil, nil)
+}
+
+// 访问策略请求参数
+type BucketPolicyRequest struct {
+	Bucket       string `json:"bucket"`
+	AccessPolicy string `json:"accessPolicy"`
+}
+
+// 设置存储桶访问策略
+func (bc *BucketController) SetBucketPolicy(c *gin.Context) {
+	var req BucketPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	if !access_policy.IsValid(req.AccessPolicy) {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("访问策略只能为 private 或 public"))
+		return
+	}
+	err := bc.BucketService.UpdateBucketPolicy(req.Bucket, req.AccessPolicy)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", nil, nil)
+}
+
+// 跨域规则请求参数
+type BucketCorsRequest struct {
+	Bucket string      `json:"bucket"`
+	Cors   []cors.Rule `json:"cors"`
+}
+
+// 设置存储桶跨域规则
+func (bc *BucketController) SetBucketCors(c *gin.Context) {
+	var req BucketCorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	for _, rule := range req.Cors {
+		if err := rule.Validate(); err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+	}
+	err := bc.BucketService.UpdateBucketCors(req.Bucket, req.Cors)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", nil, nil)
+}
+
+// 防盗链规则请求参数
+type BucketRefererRequest struct {
+	Bucket  string        `json:"bucket"`
+	Referer *referer.Rule `json:"referer"`
+}
+
+// 设置存储桶防盗链规则
+func (bc *BucketController) SetBucketReferer(c *gin.Context) {
+	var req BucketRefererRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	if req.Referer != nil {
+		if err := req.Referer.Validate(); err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+	}
+	err := bc.BucketService.UpdateBucketReferer(req.Bucket, req.Referer)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", nil, nil)
+}
+
+// 自定义域名请求参数
+type BucketDomainRequest struct {
+	Bucket  string   `json:"bucket"`
+	Domains []string `json:"domains"`
+}
+
+// 设置存储桶自定义域名
+func (bc *BucketController) SetBucketDomains(c *gin.Context) {
+	var req BucketDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	err := bc.BucketService.UpdateBucketDomains(req.Bucket, req.Domains)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", nil, nil)
+}
+
+// 静态网站托管请求参数
+type BucketWebsiteRequest struct {
+	Bucket  string          `json:"bucket"`
+	Website *website.Config `json:"website"`
+}
+
+// 设置存储桶静态网站托管
+func (bc *BucketController) SetBucketWebsite(c *gin.Context) {
+	var req BucketWebsiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, "")
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("存储桶名称不能为空"))
+		return
+	}
+	if req.Website != nil {
+		if err := req.Website.Validate(); err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+	}
+	err := bc.BucketService.UpdateBucketWebsite(req.Bucket, req.Website)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "设置成功", nil, nil)
+}
+
+// 获取各存储桶占用空间(字节)
+func (bc *BucketController) GetBucketUsage(c *gin.Context) {
+	usage, err := bc.FileService.BucketUsage()
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	if principal, ok := ownerScope(c); ok {
+		bucketList, err := bc.BucketService.GetBucketList()
+		if err != nil {
+			http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+			return
+		}
+		for _, bucketInfo := range bucketList {
+			if !services.OwnsBucket(principal, bucketInfo.Owner, bucketInfo.Name) {
+				delete(usage, bucketInfo.Name)
+			}
+		}
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", usage, nil)
+}
+
+// 获取事件通知投递记录
+func (bc *BucketController) ListWebhookDeliveries(c *gin.Context) {
+	limit := cast.ToInt(c.DefaultQuery("limit", "100"))
+	deliveries, err := bc.WebhookService.ListDeliveries(auth.Bucket(c), c.Query("status"), limit)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", deliveries, nil)
+}
+
+// ownerScope 请求方为存储桶所有者时返回 true,列表类接口只返回其拥有或被授权的存储桶
+func ownerScope(c *gin.Context) (model.Principal, bool) {
+	principal, _ := auth.Principal(c)
+	return principal, principal.Role == user_role.BUCKET_OWNER
+}
+
+// checkOwner 校验存储桶所有者是否为已有用户,为空时只有管理员可以管理
+func (bc *BucketController) checkOwner(owner string) error {
+	if owner == "" {
+		return nil
+	}
+	_, err := bc.UserService.FindUser(owner)
+	return err
+}
+
+// validateWebhooks 校验事件通知配置
+func validateWebhooks(webhooks []model.WebhookConfig) error {
+	for _, hook := range webhooks {
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("通知地址非法: " + hook.URL)
+		}
+		for _, e := range hook.Events {
+			if !event_type.IsValid(e) {
+				return errors.New("事件类型非法: " + e)
+			}
+		}
+	}
+	return nil
 }
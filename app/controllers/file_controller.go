@@ -13,21 +13,31 @@ import (
 	"easy_dfs/app/enum/response_code"
 	"easy_dfs/app/enum/system_default"
 	"easy_dfs/app/services"
-	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/auth"
+	"easy_dfs/pkg/checksum"
+	"easy_dfs/pkg/filesystem"
 	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/metrics"
 	"easy_dfs/pkg/utils/str_util"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"io"
 	"net/http"
+	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 // 文件控制器
 type FileController struct {
-	FileService services.FileService
+	FileService      services.FileService
+	BandwidthService services.BandwidthService
 }
 
 // 文件上传返回数据结构体
@@ -42,7 +52,7 @@ type UploadResponse struct {
 
 // 上传文件
 func (fc *FileController) UploadFile(c *gin.Context) {
-	bucket := c.PostForm("bucket")
+	bucket := auth.Bucket(c)
 	if bucket == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
 		return
@@ -68,17 +78,33 @@ func (fc *FileController) UploadFile(c *gin.Context) {
 	if saveName != "" {
 		filename = savePath + saveName + ext
 	}
+	audit.SetTarget(c, bucket, filename)
 	if strings.Contains(filename, system_default.STORAGE_PATH) {
 		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, errors.New("文件保存名称非法"))
 		return
 	}
 
-	if err := fc.FileService.SaveFile(bucket, filename, file); err != nil {
+	// 按配置限制写入速度;Content-MD5 请求头是整个 multipart 请求体的摘要,文件部分的摘要
+	// 由表单字段 contentMD5(Base64)及 sha256(十六进制)提供,不为空时校验
+	throttle := fc.BandwidthService.Throttle(c.Request.Context(), services.DirectionUpload, bucket, c.GetHeader("X-Access-Key"))
+	_, err = fc.FileService.PutObject(bucket, filename, throttle.Reader(file), services.PutObjectOptions{
+		ContentMD5: c.PostForm("contentMD5"),
+		SHA256:     c.PostForm("sha256"),
+	})
+	if checksum.IsMismatch(err) {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+		return
+	}
+	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
 	}
 
-	fileURL := fmt.Sprintf("%s/storage/%s/%s?bucket=%s", config.Get("app.url"), bucket, filename, bucket)
+	fileURL := fc.FileService.PublicURL(bucket, filename)
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "上传成功", UploadResponse{
 		Bucket:       bucket,
 		OriginalName: header.Filename,
@@ -89,6 +115,98 @@ func (fc *FileController) UploadFile(c *gin.Context) {
 	}, nil)
 }
 
+// 自定义元数据请求头前缀及总长度上限
+const (
+	metaHeaderPrefix = "X-Meta-"
+	maxMetadataSize  = 2048
+)
+
+// 原始请求体上传返回数据结构体
+type PutObjectResponse struct {
+	Bucket      string `json:"bucket"`
+	FileName    string `json:"fileName"`
+	FileUrl     string `json:"fileUrl"`
+	FileSize    int64  `json:"fileSize"`
+	ContentType string `json:"contentType,omitempty"`
+	MD5         string `json:"md5"`
+	SHA256      string `json:"sha256"`
+}
+
+// 以原始请求体上传文件,请求体直接流式写入存储,不经过 multipart 解析及临时文件。
+// 支持 Content-MD5(Base64)及 X-Content-SHA256(十六进制)校验,X-Meta-* 请求头作为自定义元数据保存
+func (fc *FileController) PutObject(c *gin.Context) {
+	bucket := auth.Bucket(c)
+	filename := strings.TrimPrefix(c.Param("key"), "/")
+	audit.SetTarget(c, bucket, filename)
+	if filename == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("文件名不能为空"))
+		return
+	}
+	if strings.Contains(filename, system_default.STORAGE_PATH) {
+		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, errors.New("文件保存名称非法"))
+		return
+	}
+
+	metadata, err := parseMetadata(c.Request.Header)
+	if err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+
+	// 按配置限制写入速度
+	throttle := fc.BandwidthService.Throttle(c.Request.Context(), services.DirectionUpload, bucket, c.GetHeader("X-Access-Key"))
+	meta, err := fc.FileService.PutObject(bucket, filename, throttle.Reader(c.Request.Body), services.PutObjectOptions{
+		ContentType: c.ContentType(),
+		ContentMD5:  c.GetHeader("Content-MD5"),
+		SHA256:      c.GetHeader("X-Content-SHA256"),
+		Metadata:    metadata,
+	})
+	if checksum.IsMismatch(err) {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+		return
+	}
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "上传成功", PutObjectResponse{
+		Bucket:      bucket,
+		FileName:    filename,
+		FileUrl:     fc.FileService.PublicURL(bucket, filename),
+		FileSize:    meta.Size,
+		ContentType: meta.ContentType,
+		MD5:         meta.MD5,
+		SHA256:      meta.SHA256,
+	}, nil)
+}
+
+// parseMetadata 从 X-Meta-* 请求头解析自定义元数据,键统一为小写
+func parseMetadata(header http.Header) (map[string]string, error) {
+	metadata := map[string]string{}
+	size := 0
+	for name, values := range header {
+		if !strings.HasPrefix(name, metaHeaderPrefix) || len(name) == len(metaHeaderPrefix) {
+			continue
+		}
+		key := strings.ToLower(name[len(metaHeaderPrefix):])
+		value := strings.Join(values, ",")
+		size += len(key) + len(value)
+		metadata[key] = value
+	}
+	if size > maxMetadataSize {
+		return nil, fmt.Errorf("自定义元数据不能超过 %d 字节", maxMetadataSize)
+	}
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
 // 所有文件列表
 func (fc *FileController) ListAllFiles(c *gin.Context) {
 	files, err := fc.FileService.ListAllFiles()
@@ -101,7 +219,7 @@ func (fc *FileController) ListAllFiles(c *gin.Context) {
 
 // 文件列表
 func (fc *FileController) ListFiles(c *gin.Context) {
-	bucket := c.Query("bucket")
+	bucket := auth.Bucket(c)
 	if bucket == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
 		return
@@ -117,7 +235,7 @@ func (fc *FileController) ListFiles(c *gin.Context) {
 
 // 文件信息
 func (fc *FileController) GetFileInfo(c *gin.Context) {
-	bucket := c.Query("bucket")
+	bucket := auth.Bucket(c)
 	if bucket == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
 		return
@@ -129,7 +247,7 @@ func (fc *FileController) GetFileInfo(c *gin.Context) {
 	}
 	fileSize, err := fc.FileService.GetFileInfo(bucket, filename)
 	if err != nil {
-		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		objectError(c, err)
 		return
 	}
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", fileSize, nil)
@@ -138,33 +256,162 @@ func (fc *FileController) GetFileInfo(c *gin.Context) {
 
 // 下载文件
 func (fc *FileController) DownloadFile(c *gin.Context) {
-	bucket := c.PostForm("bucket")
+	bucket := auth.Bucket(c)
 	if bucket == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
 		return
 	}
-	filename := c.Param("filename")
-	reader, err := fc.FileService.LoadFile(bucket, filename)
-	if err != nil {
+	filename := c.Query("filename")
+	if filename == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("filename不能为空"))
+		return
+	}
+	file, fileInfo, err := fc.FileService.OpenFile(bucket, filename)
+	if errors.Is(err, os.ErrNotExist) {
 		c.String(http.StatusNotFound, "File not found")
 		return
 	}
-	c.Header("Content-Disposition", "attachment; filename="+filename)
+	if err != nil {
+		objectError(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Disposition", "attachment; filename="+path.Base(filename))
 	c.Header("Content-Type", "application/octet-stream")
-	io.Copy(c.Writer, reader)
+	c.Header("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
+	throttle := fc.BandwidthService.Throttle(c.Request.Context(), services.DirectionDownload, bucket, c.GetHeader("X-Access-Key"))
+	n, _ := io.Copy(throttle.Writer(c.Writer), file)
+	metrics.AddDownloadedBytes(bucket, n)
+}
+
+// 打包下载请求参数
+type ArchiveRequest struct {
+	Bucket string   `form:"bucket" json:"bucket"`
+	Prefix string   `form:"prefix" json:"prefix"`
+	Keys   []string `form:"keys" json:"keys"`
+	Format string   `form:"format" json:"format"`
+}
+
+// 打包下载文件夹或多个文件(zip/tar.gz),边读取边写入响应
+func (fc *FileController) DownloadArchive(c *gin.Context) {
+	var req ArchiveRequest
+	if err := c.ShouldBind(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
+		return
+	}
+	if req.Prefix == "" && len(req.Keys) == 0 {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("prefix和keys不能同时为空"))
+		return
+	}
+	if req.Format == "" {
+		req.Format = filesystem.ArchiveFormatZip
+	}
+	if req.Format != filesystem.ArchiveFormatZip && req.Format != filesystem.ArchiveFormatTarGz {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("format仅支持zip或tar.gz"))
+		return
+	}
+
+	keys, err := fc.FileService.ResolveArchiveKeys(req.Bucket, req.Prefix, req.Keys)
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	if len(keys) == 0 {
+		http_response.Response(c, response_code.QUERY_EMPTY, false, "操作失败", nil, errors.New("没有可打包的文件"))
+		return
+	}
+
+	// 压缩包名称:优先使用前缀的最后一级目录,否则使用bucket名称
+	archiveName := req.Bucket
+	if base := path.Base(strings.TrimSuffix(req.Prefix, "/")); req.Prefix != "" && base != "." && base != "/" {
+		archiveName = base
+	}
+	c.Header("Content-Disposition", "attachment; filename="+archiveName+filesystem.ArchiveExt(req.Format))
+	c.Header("Content-Type", filesystem.ArchiveContentType(req.Format))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送,出错时只能记录日志并中断连接
+	err = fc.FileService.WriteArchive(c.Writer, req.Format, req.Bucket, keys)
+	metrics.AddDownloadedBytes(req.Bucket, int64(c.Writer.Size()))
+	if err != nil {
+		logger.Error("DownloadArchive", zap.String("bucket", req.Bucket), zap.Error(err))
+		c.Abort()
+	}
+}
+
+// 重命名请求参数
+type RenameRequest struct {
+	Bucket string `json:"bucket"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// 重命名文件
+func (fc *FileController) RenameFile(c *gin.Context) {
+	var req RenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	req.Bucket = auth.Bucket(c)
+	audit.SetTarget(c, req.Bucket, req.From)
+	audit.SetResource(c, req.To)
+	if req.Bucket == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
+		return
+	}
+	if req.From == "" || req.To == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("from和to不能为空"))
+		return
+	}
+	if strings.Contains(req.To, system_default.STORAGE_PATH) {
+		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, errors.New("文件保存名称非法"))
+		return
+	}
+	err := fc.FileService.RenameFile(req.Bucket, req.From, req.To)
+	if errors.Is(err, os.ErrNotExist) {
+		http_response.Response(c, response_code.QUERY_EMPTY, false, "操作失败", nil, errors.New("文件不存在"))
+		return
+	}
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "重命名成功", nil, nil)
 }
 
 // 删除文件
 func (fc *FileController) DeleteFile(c *gin.Context) {
-	bucket := c.Query("bucket")
+	bucket := auth.Bucket(c)
 	if bucket == "" {
 		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
 		return
 	}
 	filename := c.Query("filename")
+	audit.SetTarget(c, bucket, filename)
 	if err := fc.FileService.DeleteFile(bucket, filename); err != nil {
-		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		objectError(c, err)
 		return
 	}
 	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "删除成功", nil, nil)
 }
+
+// objectError 按文件操作的错误类型返回响应:文件名非法为参数错误,存储桶或文件不存在为查询为空
+func objectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidObjectKey):
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrBucketNotFound):
+		http_response.Response(c, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+	case errors.Is(err, os.ErrNotExist):
+		http_response.Response(c, response_code.QUERY_EMPTY, false, "操作失败", nil, errors.New("文件不存在"))
+	default:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * @PackageName: controllers
+ * @FileName: fsck_controller.go
+ * @Description: 数据一致性检查控制器
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 03:12:48
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 03:12:48
+ */
+
+package controllers
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/http/http_response"
+	"errors"
+	"github.com/gin-gonic/gin"
+)
+
+// 数据一致性检查控制器
+type FsckController struct {
+	FsckService services.FsckService
+}
+
+// 修复请求
+type FsckReconcileRequest struct {
+	Actions map[string]string `json:"actions"` // 问题类型到修复方式的映射
+	Targets []string          `json:"targets"` // 只处理这些问题对象,为空时处理全部
+	DryRun  *bool             `json:"dryRun"`  // 是否试运行,未指定时为试运行
+}
+
+// 检查配置文件与存储目录是否一致
+func (fc *FsckController) Check(c *gin.Context) {
+	issues, err := fc.FsckService.Check()
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", issues, nil)
+}
+
+// 按指定的方式修复问题,默认试运行,需明确指定 dryRun 为 false 才会修改
+func (fc *FsckController) Reconcile(c *gin.Context) {
+	var req FsckReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+	if dryRun {
+		audit.SetResource(c, "dry-run")
+	}
+	if err := services.ValidateFsckActions(req.Actions); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+		return
+	}
+
+	results, err := fc.FsckService.Reconcile(services.ReconcileOptions{Actions: req.Actions, Targets: req.Targets, DryRun: dryRun})
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "操作成功", results, nil)
+}
@@ -0,0 +1,39 @@
+/*
+ * @PackageName: controllers
+ * @FileName: health_controller.go
+ * @Description: 健康检查控制器
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 16:48:05
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 16:48:05
+ */
+
+package controllers
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/http/http_response"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// 健康检查控制器
+type HealthController struct {
+	HealthService services.HealthService
+}
+
+// Healthz 存活检查,进程能处理请求即返回成功
+func (hc *HealthController) Healthz(c *gin.Context) {
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "ok", nil, nil)
+}
+
+// Readyz 就绪检查,未就绪时返回 503
+func (hc *HealthController) Readyz(c *gin.Context) {
+	checks, err := hc.HealthService.CheckReady()
+	if err != nil {
+		http_response.ResponseWithStatus(c, http.StatusServiceUnavailable, response_code.REQUEST_FAILS, false, "未就绪", checks, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "ok", checks, nil)
+}
@@ -0,0 +1,29 @@
+/*
+ * @PackageName: controllers
+ * @FileName: metrics_controller.go
+ * @Description: 监控指标控制器
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 16:01:44
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 16:01:44
+ */
+
+package controllers
+
+import (
+	"easy_dfs/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 监控指标控制器
+type MetricsController struct {
+}
+
+// metricsHandler Prometheus 指标输出
+var metricsHandler = promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+
+// Metrics 输出 Prometheus 格式的监控指标
+func (mc *MetricsController) Metrics(c *gin.Context) {
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,52 @@
+/*
+ * @PackageName: controllers
+ * @FileName: scrub_controller.go
+ * @Description: 数据完整性巡检控制器
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 02:46:15
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 02:46:15
+ */
+
+package controllers
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/http/http_response"
+	"errors"
+	"github.com/gin-gonic/gin"
+)
+
+// 数据完整性巡检控制器
+type ScrubController struct {
+	ScrubService services.ScrubService
+}
+
+// 手动触发巡检,在后台执行,通过巡检报告接口查询进度及结果
+func (sc *ScrubController) RunScrub(c *gin.Context) {
+	report, err := sc.ScrubService.Trigger()
+	if errors.Is(err, services.ErrScrubRunning) {
+		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+		return
+	}
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "巡检已开始", report, nil)
+}
+
+// 获取巡检报告,有进行中的巡检时返回其进度,否则返回最近一次的报告
+func (sc *ScrubController) GetScrubReport(c *gin.Context) {
+	report, err := sc.ScrubService.Report()
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	if report == nil {
+		http_response.Response(c, response_code.QUERY_EMPTY, false, "尚未进行过巡检", nil, nil)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", report, nil)
+}
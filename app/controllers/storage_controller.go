@@ -13,18 +13,34 @@ package controllers
 import (
 	"easy_dfs/app/enum/response_code"
 	"easy_dfs/app/services"
+	"easy_dfs/pkg/bandwidth"
+	"easy_dfs/pkg/config"
 	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/imageproc"
+	"easy_dfs/pkg/metrics"
+	"easy_dfs/pkg/presign"
+	"easy_dfs/pkg/website"
+	"errors"
+	"fmt"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/gin-gonic/gin"
 	"io"
+	"mime"
+	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 // 存储控制器
 type StorageController struct {
-	FileService services.FileService
+	FileService      services.FileService
+	ImageService     services.ImageService
+	AccessKeyService services.AccessKeyService
+	UserService      services.UserService
+	BandwidthService services.BandwidthService
 }
 
 // // 获取文件
@@ -83,51 +99,133 @@ func (sc *StorageController) GetFile(c *gin.Context) {
 		return
 	}
 
-	// 获取bucket
-	bucket := queryParams.Get("bucket")
-	if bucket == "" {
-		http_response.Response(c, response_code.PARAM_ERROR, false, "缺少bucket参数", nil, nil)
+	// 从路径解析存储桶及文件名,虚拟主机及自定义域名的请求已在路由前转换为该形式
+	bucket, path, err := sc.FileService.ResolveStoragePath(c.Request.URL.Path, queryParams.Get("bucket"))
+	if err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
 		return
 	}
 
-	// 获取URL路径
-	path := c.Request.URL.Path
-
-	// 移除 /storage/{bucket} 前缀
-	if !strings.HasPrefix(path, "/storage/"+bucket) {
-		http_response.Response(c, response_code.REQUEST_FAILS, false, "路径不匹配", nil, nil)
+	// 先校验访问权限及防盗链,再按网站配置解析,避免通过重定向或错误页探测私有存储桶中的文件
+	// 校验签名URL,私有存储桶按配置要求必须使用签名URL访问
+	if err := sc.checkAccess(c, bucket, path, queryParams); err != nil {
+		http_response.Response(c, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
 		return
 	}
-	path = path[len("/storage/"+bucket):]
 
-	// 确保路径安全
-	path = filepath.Clean(path)
-	if filepath.IsAbs(path) {
-		http_response.Response(c, response_code.REQUEST_FAILS, false, "路径不能是绝对路径", nil, nil)
+	// 防盗链:签名URL已单独授权,不校验来源;被拒绝时返回占位文件或 403
+	placeholder := ""
+	if !presign.HasSignature(queryParams) {
+		placeholder, err = sc.checkReferer(c, bucket)
+		if err != nil {
+			http_response.ResponseWithStatus(c, http.StatusForbidden, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+			return
+		}
+	}
+
+	// 开启静态网站托管的存储桶按网站配置解析首页、目录、重定向及错误页
+	var site *website.Config
+	status := http.StatusOK
+	if placeholder != "" {
+		path = placeholder
+	} else if bucketInfo, err := sc.FileService.BucketService.FindBucketInfo(bucket); err == nil && bucketInfo.Website != nil {
+		site = bucketInfo.Website
+		key := path
+		if key != "" && strings.HasSuffix(c.Request.URL.Path, "/") {
+			key += "/"
+		}
+		result := site.Resolve(key, func(k string) bool {
+			_, err := sc.FileService.GetFileInfo(bucket, k)
+			return err == nil
+		})
+		// 目录重定向使用相对地址,由浏览器按实际访问的地址解析,虚拟主机下同样有效
+		if result.Location != "" {
+			c.Header("Location", result.Location)
+			c.Status(result.Status)
+			return
+		}
+		if result.Key == "" {
+			http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "资源不存在", nil, nil)
+			return
+		}
+		path, status = result.Key, result.Status
+	}
+
+	if path == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "文件路径不能为空", nil, nil)
 		return
 	}
 
-	// 加载文件
-	reader, err := sc.FileService.LoadFileByPath(bucket, path)
+	// 打开文件
+	file, fileInfo, err := sc.FileService.OpenFile(bucket, path)
 	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
 		return
 	}
+	defer file.Close()
 
 	// 读取文件内容来检测MIME类型
-	mtype, err := mimetype.DetectReader(reader)
+	mtype, err := mimetype.DetectReader(file)
 	if err != nil {
 		http_response.Response(c, response_code.REQUEST_FAILS, false, "无法检测文件类型", nil, err)
 		return
 	}
 
 	// 重置reader的读取位置
-	if seeker, ok := reader.(io.Seeker); ok {
-		seeker.Seek(0, io.SeekStart)
-	}
+	file.Seek(0, io.SeekStart)
 
 	// 判断MIME类型，设置Content-Type和Content-Disposition
 	contentType := mtype.String()
+
+	// 图片按请求参数实时处理(缩放、裁剪、旋转、格式转换)
+	if imageproc.IsSupported(contentType) && sc.ImageService.Enabled() {
+		opts, ok, err := imageproc.ParseOptions(queryParams, sc.ImageService.Limits())
+		if err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+		if ok {
+			if opts.Format == "" {
+				opts.Format = imageproc.DefaultFormat(contentType)
+			}
+			cachePath, err := sc.ImageService.ProcessImage(bucket, path, file, fileInfo, opts)
+			if err != nil {
+				http_response.Response(c, response_code.REQUEST_FAILS, false, "图片处理失败", nil, err)
+				return
+			}
+			c.Header("Content-Disposition", "inline; filename="+filepath.Base(path))
+			c.Header("Content-Type", imageproc.ContentType(opts.Format))
+			c.File(cachePath)
+			metrics.AddDownloadedBytes(bucket, int64(c.Writer.Size()))
+			return
+		}
+	}
+
+	// 以原始请求体上传的文件按上传时的内容类型返回,并返回 ETag 及自定义元数据
+	if meta, err := sc.FileService.MetaService.Get(bucket, path); err == nil && meta != nil {
+		if meta.ContentType != "" {
+			contentType = meta.ContentType
+			c.Header("Content-Type", contentType)
+		}
+		c.Header("ETag", `"`+meta.MD5+`"`)
+		for key, value := range meta.Metadata {
+			c.Header("X-Meta-"+key, value)
+		}
+	}
+
+	// 签名URL按签名的访问密钥限速
+	accessKey := ""
+	if presign.HasSignature(queryParams) {
+		accessKey = queryParams.Get(presign.QueryAccessKey)
+	}
+	throttle := sc.BandwidthService.Throttle(c.Request.Context(), services.DirectionDownload, bucket, accessKey)
+
+	if site != nil {
+		sc.serveWebsiteFile(c, site, path, status, file, fileInfo, throttle)
+		metrics.AddDownloadedBytes(bucket, int64(c.Writer.Size()))
+		return
+	}
+
 	if strings.HasPrefix(contentType, "image/") || contentType == "application/pdf" {
 		c.Header("Content-Disposition", "inline; filename="+filepath.Base(path))
 	} else {
@@ -136,5 +234,97 @@ func (sc *StorageController) GetFile(c *gin.Context) {
 	c.Header("Content-Type", contentType)
 
 	// 将文件内容写入响应
-	io.Copy(c.Writer, reader)
+	n, _ := io.Copy(throttle.Writer(c.Writer), file)
+	metrics.AddDownloadedBytes(bucket, n)
+}
+
+// serveWebsiteFile 以网站方式返回文件:按扩展名设置类型、直接在浏览器中打开、设置缓存头,并支持条件请求及断点续传
+func (sc *StorageController) serveWebsiteFile(c *gin.Context, site *website.Config, key string, status int, file *os.File, fileInfo os.FileInfo, throttle *bandwidth.Throttle) {
+	// HTML 文件需向服务端确认是否更新,保证发布后立即生效;其他资源按配置缓存
+	if website.IsHTML(key) || status != http.StatusOK {
+		c.Header("Cache-Control", "no-cache")
+	} else {
+		maxAge := site.CacheMaxAge
+		if maxAge == 0 {
+			maxAge = website.DefaultCacheMaxAge
+		}
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	}
+
+	w := &throttledResponseWriter{ResponseWriter: c.Writer, w: throttle.Writer(c.Writer)}
+
+	// 错误页以 404 返回,不支持条件请求
+	if status != http.StatusOK {
+		contentType := mime.TypeByExtension(filepath.Ext(key))
+		if contentType == "" {
+			contentType = "text/html; charset=utf-8"
+		}
+		c.Header("Content-Type", contentType)
+		c.Status(status)
+		io.Copy(w, file)
+		return
+	}
+
+	if c.Writer.Header().Get("ETag") == "" {
+		c.Header("ETag", fmt.Sprintf(`"%x-%x"`, fileInfo.ModTime().UnixNano(), fileInfo.Size()))
+	}
+	http.ServeContent(w, c.Request, key, fileInfo.ModTime(), file)
+}
+
+// throttledResponseWriter 经过限速的响应
+type throttledResponseWriter struct {
+	http.ResponseWriter
+	w io.Writer
+}
+
+func (tw *throttledResponseWriter) Write(p []byte) (int, error) {
+	return tw.w.Write(p)
+}
+
+// checkReferer 按存储桶的防盗链规则校验请求来源,被拒绝且占位文件存在时返回占位文件路径
+func (sc *StorageController) checkReferer(c *gin.Context, bucket string) (string, error) {
+	bucketInfo, err := sc.FileService.BucketService.FindBucketInfo(bucket)
+	if err != nil || bucketInfo.Referer == nil {
+		return "", nil
+	}
+	// 同一地址按来源返回不同内容,避免缓存混用
+	c.Header("Vary", "Referer")
+	if bucketInfo.Referer.Allowed(c.GetHeader("Referer")) {
+		return "", nil
+	}
+	if placeholder := bucketInfo.Referer.Placeholder; placeholder != "" {
+		if _, err := sc.FileService.GetFileInfo(bucket, placeholder); err == nil {
+			return placeholder, nil
+		}
+	}
+	return "", errors.New("禁止盗链访问")
+}
+
+// checkAccess 携带签名时校验签名及签名密钥所属用户的权限,未携带签名时仅在开启 storage.enforce_private 后拒绝访问私有存储桶
+func (sc *StorageController) checkAccess(c *gin.Context, bucket, path string, queryParams url.Values) error {
+	if presign.HasSignature(queryParams) {
+		if err := sc.AccessKeyService.VerifyPresignedURL(c.Request.Method, bucket, path, queryParams); err != nil {
+			return err
+		}
+		principal, err := sc.UserService.AccessKeyPrincipal(queryParams.Get(presign.QueryAccessKey))
+		if err != nil {
+			return err
+		}
+		bucketInfo, err := sc.FileService.BucketService.FindBucketInfo(bucket)
+		if err != nil {
+			return err
+		}
+		return services.AuthorizeBucket(*principal, bucketInfo, false)
+	}
+	if !config.GetBool("storage.enforce_private", false) {
+		return nil
+	}
+	bucketInfo, err := sc.FileService.BucketService.FindBucketInfo(bucket)
+	if err != nil {
+		return err
+	}
+	if bucketInfo.AccessPolicy == "private" {
+		return errors.New("私有存储桶需使用签名URL访问")
+	}
+	return nil
 }
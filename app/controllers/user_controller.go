@@ -0,0 +1,160 @@
+/*
+ * @PackageName: controllers
+ * @FileName: user_controller.go
+ * @Description: 用户管理控制器
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:40:22
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:40:22
+ */
+
+package controllers
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/http/http_response"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// 用户管理控制器
+type UserController struct {
+	UserService services.UserService
+}
+
+// 创建用户请求参数
+type UserCreateRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// 修改用户请求参数,未传的项不修改
+type UserUpdateRequest struct {
+	Username string  `json:"username"`
+	Role     *string `json:"role"`
+	Password *string `json:"password"`
+}
+
+// 创建用户
+func (uc *UserController) CreateUser(c *gin.Context) {
+	var req UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	audit.SetResource(c, req.Username)
+
+	user, err := uc.UserService.CreateUser(req.Username, req.Password, req.Role)
+	switch {
+	case errors.Is(err, services.ErrUserExists):
+		http_response.ResponseWithStatus(c, http.StatusConflict, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+	case err != nil:
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+	default:
+		http_response.Response(c, response_code.REQUEST_SUCCESS, true, "新增成功", user, nil)
+	}
+}
+
+// 获取用户列表
+func (uc *UserController) ListUsers(c *gin.Context) {
+	users, err := uc.UserService.GetUserList()
+	if err != nil {
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", users, nil)
+}
+
+// 获取用户信息
+func (uc *UserController) GetUserInfo(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("用户名不能为空"))
+		return
+	}
+	user, err := uc.UserService.FindUser(username)
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "获取成功", user, nil)
+}
+
+// 修改用户角色或重置密码
+func (uc *UserController) UpdateUser(c *gin.Context) {
+	var req UserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("参数有误"))
+		return
+	}
+	audit.SetResource(c, req.Username)
+	if req.Username == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("用户名不能为空"))
+		return
+	}
+
+	if req.Role != nil && !user_role.IsValid(*req.Role) {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("角色只能为 admin、bucket-owner 或 read-only"))
+		return
+	}
+	if req.Password != nil {
+		if err := services.ValidatePassword(*req.Password); err != nil {
+			http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, err)
+			return
+		}
+	}
+
+	user, err := uc.UserService.UpdateUser(req.Username, services.UserUpdate{Role: req.Role, Password: req.Password})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "修改成功", user, nil)
+}
+
+// 禁用用户,同时吊销其全部访问密钥
+func (uc *UserController) RevokeUser(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("用户名不能为空"))
+		return
+	}
+	audit.SetResource(c, username)
+	if err := uc.UserService.RevokeUser(username); err != nil {
+		userError(c, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "已禁用", nil, nil)
+}
+
+// 删除用户及其全部访问密钥
+func (uc *UserController) DeleteUser(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		http_response.Response(c, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("用户名不能为空"))
+		return
+	}
+	audit.SetResource(c, username)
+	if err := uc.UserService.DeleteUser(username); err != nil {
+		userError(c, err)
+		return
+	}
+	http_response.Response(c, response_code.REQUEST_SUCCESS, true, "删除成功", nil, nil)
+}
+
+// userError 按错误类型返回用户操作失败的结果
+func userError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		http_response.ResponseWithStatus(c, http.StatusNotFound, response_code.QUERY_EMPTY, false, "操作失败", nil, err)
+	case errors.Is(err, services.ErrLastAdmin):
+		http_response.ResponseWithStatus(c, http.StatusConflict, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+	default:
+		http_response.Response(c, response_code.REQUEST_FAILS, false, "操作失败", nil, err)
+	}
+}
@@ -0,0 +1,27 @@
+/*
+ * @PackageName: access_policy
+ * @FileName: access_policy.go
+ * @Description: 存储桶访问策略
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 04:24:51
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 04:24:51
+ */
+
+package access_policy
+
+const (
+	// 私有,访问文件需签名或访问密钥
+	PRIVATE = "private"
+	// 公开,任何人可访问文件
+	PUBLIC = "public"
+)
+
+// IsValid 判断访问策略是否合法
+func IsValid(policy string) bool {
+	switch policy {
+	case PRIVATE, PUBLIC:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+/*
+ * @PackageName: audit_action
+ * @FileName: audit_action.go
+ * @Description: 审计操作类型枚举
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:04:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:04:40
+ */
+
+package audit_action
+
+const (
+	// 创建存储桶
+	BUCKET_CREATE = "bucket.create"
+	// 删除存储桶
+	BUCKET_DELETE = "bucket.delete"
+	// 设置存储桶访问策略
+	BUCKET_POLICY = "bucket.policy"
+	// 设置存储桶事件通知
+	BUCKET_WEBHOOK = "bucket.webhook"
+	// 设置存储桶跨域规则
+	BUCKET_CORS = "bucket.cors"
+	// 设置存储桶防盗链规则
+	BUCKET_REFERER = "bucket.referer"
+	// 设置存储桶自定义域名
+	BUCKET_DOMAIN = "bucket.domain"
+	// 设置静态网站托管
+	BUCKET_WEBSITE = "bucket.website"
+	// 修改存储桶配置
+	BUCKET_UPDATE = "bucket.update"
+	// 重命名存储桶
+	BUCKET_RENAME = "bucket.rename"
+	// 创建访问密钥
+	KEY_CREATE = "key.create"
+	// 删除访问密钥
+	KEY_DELETE = "key.delete"
+	// 上传文件
+	FILE_UPLOAD = "file.upload"
+	// 重命名文件
+	FILE_RENAME = "file.rename"
+	// 删除文件
+	FILE_DELETE = "file.delete"
+	// 手动触发数据完整性巡检
+	SCRUB_RUN = "scrub.run"
+	// 修复配置文件与存储目录的不一致
+	FSCK_RECONCILE = "fsck.reconcile"
+	// 用户登录
+	AUTH_LOGIN = "auth.login"
+	// 修改自己的密码
+	AUTH_PASSWORD = "auth.password"
+	// 通过 OIDC 单点登录
+	AUTH_OIDC = "auth.oidc"
+	// 创建用户
+	USER_CREATE = "user.create"
+	// 修改用户角色或密码
+	USER_UPDATE = "user.update"
+	// 禁用用户
+	USER_REVOKE = "user.revoke"
+	// 删除用户
+	USER_DELETE = "user.delete"
+)
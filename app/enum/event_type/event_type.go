@@ -0,0 +1,31 @@
+/*
+ * @PackageName: event_type
+ * @FileName: event_type.go
+ * @Description: 事件类型枚举
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 14:20:05
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 14:20:05
+ */
+
+package event_type
+
+const (
+	// 文件已创建(上传或覆盖)
+	OBJECT_CREATED = "ObjectCreated"
+	// 文件已删除
+	OBJECT_REMOVED = "ObjectRemoved"
+	// 存储桶已创建
+	BUCKET_CREATED = "BucketCreated"
+	// 存储桶已删除
+	BUCKET_REMOVED = "BucketRemoved"
+)
+
+// IsValid 判断事件类型是否合法
+func IsValid(eventType string) bool {
+	switch eventType {
+	case OBJECT_CREATED, OBJECT_REMOVED, BUCKET_CREATED, BUCKET_REMOVED:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+/*
+ * @PackageName: storage_type
+ * @FileName: storage_type.go
+ * @Description: 存储桶存储类型
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 04:25:37
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 04:25:37
+ */
+
+package storage_type
+
+const (
+	// 本地文件系统,未指定存储类型时使用
+	LOCAL = "local"
+)
+
+// IsValid 判断存储类型是否合法,空值表示使用默认的本地文件系统
+func IsValid(storageType string) bool {
+	switch storageType {
+	case "", LOCAL:
+		return true
+	}
+	return false
+}
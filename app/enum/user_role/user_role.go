@@ -0,0 +1,29 @@
+/*
+ * @PackageName: user_role
+ * @FileName: user_role.go
+ * @Description: 用户角色枚举
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:06:03
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:06:03
+ */
+
+package user_role
+
+const (
+	// 管理员,可执行所有操作
+	ADMIN = "admin"
+	// 存储桶所有者,可创建存储桶并管理自己的存储桶及其中的文件
+	BUCKET_OWNER = "bucket-owner"
+	// 只读用户,只能查看存储桶及下载文件
+	READ_ONLY = "read-only"
+)
+
+// IsValid 判断角色是否合法
+func IsValid(role string) bool {
+	switch role {
+	case ADMIN, BUCKET_OWNER, READ_ONLY:
+		return true
+	}
+	return false
+}
@@ -12,32 +12,68 @@ package middlewares
 
 import (
 	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
 	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/auth"
 	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/metrics"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"strings"
 )
 
-// 校验访问密钥
+// 上下文中保存已通过校验的访问密钥的键
+const AccessKeyContextKey = "accessKey"
+
+// 尚未创建任何用户及访问密钥时,允许不经校验访问的路由,用于创建第一个用户或访问密钥
+var bootstrapRoutes = map[string]bool{
+	"POST /access_key/create": true,
+	"POST /user/create":       true,
+}
+
+// 校验访问密钥或会话令牌,通过后在上下文中记录请求方
 func AccessKeyCheck() gin.HandlerFunc {
+	userService := new(services.UserService)
 	return func(ctx *gin.Context) {
+		// 使用登录后签发的会话令牌
+		if token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer "); ok {
+			principal, err := userService.Authenticate(token)
+			if err != nil {
+				metrics.AuthFailure("invalid")
+				http_response.Response(ctx, response_code.TOKEN_INVALID, false, "操作失败", nil, err)
+				return
+			}
+			auth.SetPrincipal(ctx, *principal)
+			ctx.Next()
+			return
+		}
+
 		// 获取访问密钥
 		accessKey := ctx.GetHeader("X-Access-Key")
 		secretKey := ctx.GetHeader("X-Secret-Key")
 		if accessKey == "" || secretKey == "" {
+			if bootstrapRoutes[ctx.Request.Method+" "+ctx.FullPath()] {
+				if bootstrap, _ := userService.NeedsBootstrap(); bootstrap {
+					auth.SetPrincipal(ctx, model.Principal{Role: user_role.ADMIN})
+					ctx.Next()
+					return
+				}
+			}
+			metrics.AuthFailure("missing")
 			http_response.Response(ctx, response_code.TOKEN_INVALID, false, "操作失败", nil, errors.New("密钥不能为空"))
 			return
 		}
 
-		// 校验访问密钥
-		accessKeyService := new(services.AccessKeyService)
-		checkKey := accessKeyService.CheckAccessKey(accessKey, secretKey)
-		if !checkKey {
-			http_response.Response(ctx, response_code.TOKEN_INVALID, false, "操作失败", nil, errors.New("密钥无效"))
+		// 校验访问密钥,所属用户被禁用时同样视为无效
+		principal, err := userService.AuthenticateAccessKey(accessKey, secretKey)
+		if err != nil {
+			metrics.AuthFailure("invalid")
+			http_response.Response(ctx, response_code.TOKEN_INVALID, false, "操作失败", nil, err)
 			return
-		} else {
-			ctx.Next()
 		}
-
+		ctx.Set(AccessKeyContextKey, accessKey)
+		auth.SetPrincipal(ctx, *principal)
+		ctx.Next()
 	}
 }
@@ -0,0 +1,99 @@
+/*
+ * @PackageName: middlewares
+ * @FileName: audit.go
+ * @Description: 审计日志中间件
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:20:47
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:20:47
+ */
+
+package middlewares
+
+import (
+	"easy_dfs/app/enum/audit_action"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/auth"
+	"easy_dfs/pkg/http/http_response"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// 需要记录审计日志的路由及对应的操作类型
+var auditActions = map[string]string{
+	"POST /bucket/create":           audit_action.BUCKET_CREATE,
+	"DELETE /bucket/delete":         audit_action.BUCKET_DELETE,
+	"PUT /bucket/policy":            audit_action.BUCKET_POLICY,
+	"PUT /bucket/webhook":           audit_action.BUCKET_WEBHOOK,
+	"PUT /bucket/cors":              audit_action.BUCKET_CORS,
+	"PUT /bucket/referer":           audit_action.BUCKET_REFERER,
+	"PUT /bucket/domain":            audit_action.BUCKET_DOMAIN,
+	"PUT /bucket/website":           audit_action.BUCKET_WEBSITE,
+	"PUT /bucket/update":            audit_action.BUCKET_UPDATE,
+	"PUT /bucket/rename":            audit_action.BUCKET_RENAME,
+	"POST /access_key/create":       audit_action.KEY_CREATE,
+	"DELETE /access_key/delete":     audit_action.KEY_DELETE,
+	"POST /file/upload":             audit_action.FILE_UPLOAD,
+	"PUT /file/object/:bucket/*key": audit_action.FILE_UPLOAD,
+	"POST /file/rename":             audit_action.FILE_RENAME,
+	"DELETE /file/delete":           audit_action.FILE_DELETE,
+	"POST /scrub/run":               audit_action.SCRUB_RUN,
+	"POST /fsck/reconcile":          audit_action.FSCK_RECONCILE,
+	"POST /auth/login":              audit_action.AUTH_LOGIN,
+	"POST /auth/password":           audit_action.AUTH_PASSWORD,
+	"GET /auth/oidc/callback":       audit_action.AUTH_OIDC,
+	"POST /user/create":             audit_action.USER_CREATE,
+	"PUT /user/update":              audit_action.USER_UPDATE,
+	"POST /user/revoke":             audit_action.USER_REVOKE,
+	"DELETE /user/delete":           audit_action.USER_DELETE,
+}
+
+// Audit 记录变更操作的审计日志,需放在密钥校验之前,以便记录校验失败的请求
+func Audit() gin.HandlerFunc {
+	as := new(services.AuditService)
+	aks := new(services.AccessKeyService)
+	return func(ctx *gin.Context) {
+		action, ok := auditActions[ctx.Request.Method+" "+ctx.FullPath()]
+		if !ok || !as.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		ctx.Next()
+
+		record := model.AuditRecord{
+			Action:    action,
+			AccessKey: ctx.GetHeader("X-Access-Key"),
+			IP:        ctx.ClientIP(),
+			UserAgent: ctx.Request.UserAgent(),
+			Code:      ctx.GetString(http_response.CodeKey),
+			Error:     ctx.GetString(http_response.ErrorKey),
+		}
+		// 仅记录通过校验的密钥名称或用户名,避免把伪造的密钥当作操作人
+		if principal, ok := auth.Principal(ctx); ok {
+			record.User = principal.Username
+			record.Actor = principal.Username
+		}
+		if accessKey := ctx.GetString(AccessKeyContextKey); accessKey != "" {
+			if info, err := aks.FindByAccessKey(accessKey); err == nil {
+				record.Actor = info.Name
+			}
+		}
+		record.Bucket, record.Object, record.Version = audit.Target(ctx)
+		record.Resource = audit.Resource(ctx)
+		if record.Bucket == "" {
+			record.Bucket = ctx.Query("bucket")
+		}
+		if record.Bucket == "" {
+			record.Bucket = ctx.Param("bucket")
+		}
+		if record.Code == "" {
+			record.Code = strconv.Itoa(ctx.Writer.Status())
+		}
+		record.Success = record.Code == response_code.REQUEST_SUCCESS
+		as.RecordQuietly(record)
+	}
+}
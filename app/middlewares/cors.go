@@ -11,32 +11,73 @@
 package middlewares
 
 import (
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/cors"
 	"github.com/gin-gonic/gin"
-	"log"
 	"net/http"
 )
 
-// 跨域处理函数
+// Cors 管理接口的跨域处理,使用全局配置的规则
 func Cors() gin.HandlerFunc {
+	rules := globalCorsRules()
 	return func(ctx *gin.Context) {
-		method := ctx.Request.Method
-		origin := ctx.Request.Header.Get("Origin") // 请求头部
-		if origin != "" {
-			// 可将将 * 替换为指定的域名
-			ctx.Header("Access-Control-Allow-Origin", "*")
-			ctx.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-			ctx.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization")
-			ctx.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type")
-			ctx.Header("Access-Control-Allow-Credentials", "true")
+		handleCors(ctx, rules)
+	}
+}
+
+// BucketCors 文件相关接口的跨域处理,使用文件地址、路由参数或请求参数 bucket 对应存储桶的规则,未指定存储桶时使用全局配置的规则
+func BucketCors() gin.HandlerFunc {
+	global := globalCorsRules()
+	fs := new(services.FileService)
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Origin") == "" {
+			ctx.Next()
+			return
 		}
-		if method == "OPTIONS" {
-			ctx.AbortWithStatus(http.StatusNoContent)
+		rules := global
+		bucket := ctx.Query("bucket")
+		if pathBucket, _, err := fs.ResolveStoragePath(ctx.Request.URL.Path, ""); err == nil {
+			bucket = pathBucket
+		} else if paramBucket := ctx.Param("bucket"); paramBucket != "" {
+			bucket = paramBucket
 		}
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("Panic info is: %v", err)
+		if bucket != "" {
+			rules = nil
+			bs := new(services.BucketService)
+			if bucketInfo, err := bs.FindBucketInfo(bucket); err == nil {
+				rules = bucketInfo.Cors
 			}
-		}()
+		}
+		handleCors(ctx, rules)
+	}
+}
+
+// handleCors 设置跨域响应头,预检请求在此直接返回,不再经过密钥校验等后续中间件
+func handleCors(ctx *gin.Context, rules []cors.Rule) {
+	preflight, allowed := cors.Apply(ctx.Writer.Header(), ctx.Request, rules)
+	if !preflight {
 		ctx.Next()
+		return
+	}
+	if allowed {
+		ctx.AbortWithStatus(http.StatusNoContent)
+	} else {
+		ctx.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// globalCorsRules 根据配置生成全局跨域规则,未配置允许的来源时不允许跨域
+func globalCorsRules() []cors.Rule {
+	origins := cors.ParseList(config.GetString("cors.allowed_origins"))
+	if len(origins) == 0 {
+		return nil
 	}
+	return []cors.Rule{{
+		AllowedOrigins: origins,
+		AllowedMethods: cors.ParseList(config.GetString("cors.allowed_methods")),
+		AllowedHeaders: cors.ParseList(config.GetString("cors.allowed_headers")),
+		ExposeHeaders:  cors.ParseList(config.GetString("cors.expose_headers")),
+		MaxAgeSeconds:  config.GetInt("cors.max_age", 600),
+	}}
 }
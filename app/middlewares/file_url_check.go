@@ -15,7 +15,6 @@ import (
 	"easy_dfs/app/enum/system_default"
 	"easy_dfs/app/services"
 	"easy_dfs/pkg/http/http_response"
-	"errors"
 	"github.com/gin-gonic/gin"
 	"net/url"
 	"strings"
@@ -24,21 +23,17 @@ import (
 // 文件URL检查
 func FileUrlCheck() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// 获取URL
-		fileUrl := ctx.Request.URL
-		// 如果访问URL包含storage则校验bucket
-		if strings.Contains(fileUrl.String(), system_default.STORAGE_PATH) {
-			// fmt.Println("fileUrl:", fileUrl)
-			// 获取bucket
+		// 文件访问地址需校验存储桶,存储桶从路径 /storage/{bucket}/ 中获取,兼容旧地址的 bucket 参数
+		if strings.HasPrefix(ctx.Request.URL.Path, "/"+system_default.STORAGE_PATH+"/") {
 			queryParams, bcerr := url.ParseQuery(ctx.Request.URL.RawQuery)
 			if bcerr != nil {
 				http_response.Response(ctx, response_code.PARAM_ERROR, false, "操作失败", nil, bcerr)
 				return
 			}
-			// 获取bucket
-			bucket := queryParams.Get("bucket")
-			if bucket == "" {
-				http_response.Response(ctx, response_code.PARAM_ERROR, false, "操作失败", nil, errors.New("bucket不能为空"))
+			fs := new(services.FileService)
+			bucket, _, err := fs.ResolveStoragePath(ctx.Request.URL.Path, queryParams.Get("bucket"))
+			if err != nil {
+				http_response.Response(ctx, response_code.PARAM_ERROR, false, "操作失败", nil, err)
 				return
 			}
 			bs := new(services.BucketService)
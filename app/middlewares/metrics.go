@@ -0,0 +1,59 @@
+/*
+ * @PackageName: middlewares
+ * @FileName: metrics.go
+ * @Description: 监控指标中间件
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 15:55:02
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 15:55:02
+ */
+
+package middlewares
+
+import (
+	"crypto/subtle"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/metrics"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"strings"
+	"time"
+)
+
+// Metrics 记录请求次数及耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// 使用路由模板作为标签,避免文件路径等参数导致标签数量无限增长
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, cast.ToString(c.Writer.Status()), time.Since(start))
+	}
+}
+
+// MetricsAuth 校验监控指标接口的访问权限
+func MetricsAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.GetBool("metrics.enabled", false) {
+			http_response.Response(c, response_code.QUERY_EMPTY, false, "资源不存在", nil, nil)
+			return
+		}
+
+		token := config.GetString("metrics.token")
+		if token != "" {
+			given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				http_response.Response(c, response_code.TOKEN_INVALID, false, "操作失败", nil, errors.New("令牌无效"))
+				return
+			}
+		}
+		c.Next()
+	}
+}
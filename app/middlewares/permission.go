@@ -0,0 +1,156 @@
+/*
+ * @PackageName: middlewares
+ * @FileName: permission.go
+ * @Description: 按用户角色校验操作权限的中间件
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:31:08
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:31:08
+ */
+
+package middlewares
+
+import (
+	"bytes"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/audit"
+	"easy_dfs/pkg/auth"
+	"easy_dfs/pkg/http/http_response"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 读取 JSON 请求体中存储桶名称时最多读取的字节数
+const maxPeekBodySize = 1 << 20
+
+// 只读用户可以访问的非 GET 路由
+var readOnlyRoutes = map[string]bool{
+	"POST /file/archive": true,
+}
+
+// 存储桶所有者可以访问的、不指定存储桶的路由,其中列表类接口由控制器按所有者过滤
+var ownerBucketlessRoutes = map[string]bool{
+	"POST /bucket/create":    true,
+	"GET /bucket/list":       true,
+	"GET /bucket/usage":      true,
+	"GET /bucket/delete/job": true,
+}
+
+// RequireAdmin 只允许管理员访问,需放在密钥校验之后
+func RequireAdmin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !auth.IsAdmin(ctx) {
+			deny(ctx, errors.New("需要管理员权限"))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// BucketPermission 按角色校验存储桶及文件操作的权限,需放在密钥校验之后:
+// 管理员不受限制;只读用户只能查看及下载;存储桶所有者只能操作自己的存储桶
+func BucketPermission() gin.HandlerFunc {
+	bucketService := new(services.BucketService)
+	return func(ctx *gin.Context) {
+		principal, _ := auth.Principal(ctx)
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		write := ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead && !readOnlyRoutes[route]
+
+		// 控制器只使用此处解析出的存储桶,与校验权限的存储桶保持一致
+		bucket, err := requestBucket(ctx)
+		if err != nil {
+			deny(ctx, err)
+			return
+		}
+		auth.SetBucket(ctx, bucket)
+
+		switch principal.Role {
+		case user_role.ADMIN:
+			ctx.Next()
+			return
+		case user_role.READ_ONLY:
+			// 只读用户可以查看全部存储桶
+			if err := services.AuthorizeBucket(principal, nil, write); err != nil {
+				deny(ctx, err)
+				return
+			}
+			ctx.Next()
+			return
+		case user_role.BUCKET_OWNER:
+		default:
+			deny(ctx, services.ErrPermissionDenied)
+			return
+		}
+
+		if bucket == "" {
+			if ownerBucketlessRoutes[route] {
+				ctx.Next()
+				return
+			}
+			deny(ctx, services.ErrNotBucketOwner)
+			return
+		}
+		// 被拒绝的请求同样记录操作的存储桶
+		audit.SetTarget(ctx, bucket, "")
+		// 存储桶不存在时交由控制器返回
+		if bucketInfo, err := bucketService.FindBucketInfo(bucket); err == nil {
+			if err := services.AuthorizeBucket(principal, bucketInfo, write); err != nil {
+				deny(ctx, err)
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// deny 拒绝没有权限的请求
+func deny(ctx *gin.Context, err error) {
+	http_response.ResponseWithStatus(ctx, http.StatusForbidden, response_code.REQUEST_DENIED, false, "操作失败", nil, err)
+}
+
+// requestBucket 从路径参数、查询参数、表单及 JSON 请求体中获取存储桶名称,
+// 多处指定了不同的存储桶时返回错误,避免以一个存储桶通过校验而操作另一个存储桶
+func requestBucket(ctx *gin.Context) (string, error) {
+	var bucket string
+	for _, name := range []string{ctx.Param("bucket"), ctx.Query("bucket"), bodyBucket(ctx)} {
+		if name == "" {
+			continue
+		}
+		if bucket != "" && name != bucket {
+			return "", errors.New("请求中指定了不同的存储桶")
+		}
+		bucket = name
+	}
+	return bucket, nil
+}
+
+// bodyBucket 从表单或 JSON 请求体中获取存储桶名称
+func bodyBucket(ctx *gin.Context) string {
+	contentType := ctx.ContentType()
+	if contentType == gin.MIMEPOSTForm || contentType == gin.MIMEMultipartPOSTForm {
+		return ctx.PostForm("bucket")
+	}
+	if !strings.HasSuffix(contentType, "json") || ctx.Request.Body == nil {
+		return ""
+	}
+
+	// 读取后还原请求体,供控制器再次解析
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPeekBodySize))
+	ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), ctx.Request.Body))
+	if err != nil {
+		return ""
+	}
+	var req struct {
+		Bucket string `json:"bucket"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return ""
+	}
+	return req.Bucket
+}
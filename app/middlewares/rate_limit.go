@@ -0,0 +1,102 @@
+/*
+ * @PackageName: middlewares
+ * @FileName: rate_limit.go
+ * @Description: 限流中间件
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:50:04
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:50:04
+ */
+
+package middlewares
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/pkg/auth"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/metrics"
+	"easy_dfs/pkg/ratelimit"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 限流范围
+const (
+	// RateLimitAPI 接口请求
+	RateLimitAPI = "api"
+	// RateLimitStorage 公开文件下载
+	RateLimitStorage = "storage"
+)
+
+// RateLimit 按全局及客户端IP限流,放在密钥校验之前;同一 scope 的路由组需共用返回的中间件以共享额度
+func RateLimit(scope string) gin.HandlerFunc {
+	if !config.GetBool("rate_limit.enabled", false) {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	ipLimiter := newRateLimiter(scope, "ip")
+	globalLimiter := newRateLimiter(scope, "global")
+
+	return func(ctx *gin.Context) {
+		if ok, wait := ipLimiter.Allow(ctx.ClientIP()); !ok {
+			rejectRateLimited(ctx, scope, "ip", wait)
+			return
+		}
+		if ok, wait := globalLimiter.Allow(""); !ok {
+			rejectRateLimited(ctx, scope, "global", wait)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// KeyRateLimit 按通过校验的访问密钥限流,使用会话令牌时按用户名限流;需放在密钥校验之后,
+// 避免他人在请求头中填写别人的访问密钥耗尽其额度。各路由组需共用返回的中间件以共享额度
+func KeyRateLimit() gin.HandlerFunc {
+	if !config.GetBool("rate_limit.enabled", false) {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	keyLimiter := newRateLimiter(RateLimitAPI, "key")
+	return func(ctx *gin.Context) {
+		key := ctx.GetString(AccessKeyContextKey)
+		if key == "" {
+			if principal, ok := auth.Principal(ctx); ok && principal.Username != "" {
+				key = "user:" + principal.Username
+			}
+		}
+		if key != "" {
+			if ok, wait := keyLimiter.Allow(key); !ok {
+				rejectRateLimited(ctx, RateLimitAPI, "key", wait)
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// newRateLimiter 按 rate_limit.{scope}_{dimension}_rate/burst 配置创建限流器
+func newRateLimiter(scope, dimension string) *ratelimit.Limiter {
+	prefix := "rate_limit." + scope + "_" + dimension
+	return ratelimit.New(config.GetFloat64(prefix+"_rate", 0), config.GetInt(prefix+"_burst", 0))
+}
+
+// rejectRateLimited 返回 429 及 Retry-After(秒,向上取整)
+func rejectRateLimited(ctx *gin.Context, scope, dimension string, wait time.Duration) {
+	metrics.RateLimited(scope, dimension)
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+	http_response.ResponseWithStatus(ctx, http.StatusTooManyRequests, response_code.REQUEST_FREQUENT, false, "请求过于频繁", nil, errors.New("请求过于频繁，请稍后重试"))
+}
@@ -0,0 +1,34 @@
+/*
+ * @PackageName: middlewares
+ * @FileName: virtual_host.go
+ * @Description: 虚拟主机及自定义域名访问
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 23:05:29
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 23:05:29
+ */
+
+package middlewares
+
+import (
+	"easy_dfs/app/enum/system_default"
+	"easy_dfs/app/services"
+	"net/http"
+	"net/url"
+)
+
+// VirtualHost 将 <bucket>.{storage.domain} 及自定义域名的请求转换为 /storage/{bucket}/{key},
+// 路由匹配发生在 gin 内部,因此以 http.Handler 的形式包装在路由之外
+func VirtualHost(next http.Handler) http.Handler {
+	bs := new(services.BucketService)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bucket, ok := bs.ResolveHostBucket(r.Host); ok {
+			prefix := "/" + system_default.STORAGE_PATH + "/" + bucket
+			r.URL.Path = prefix + r.URL.Path
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = "/" + system_default.STORAGE_PATH + "/" + url.PathEscape(bucket) + r.URL.RawPath
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
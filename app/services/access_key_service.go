@@ -15,11 +15,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"net/url"
 	"os"
 	"sync"
+	"time"
 
 	"easy_dfs/model"
 	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/presign"
 )
 
 // AccessKeyService 访问密钥服务
@@ -135,6 +138,22 @@ func (aks *AccessKeyService) GetAccessKey(userID string) (*model.AccessKeyInfo,
 	return nil, errors.New("name 不存在")
 }
 
+// FindByAccessKey 根据访问密钥查找密钥信息
+func (aks *AccessKeyService) FindByAccessKey(accessKey string) (*model.AccessKeyInfo, error) {
+	accessKeyConfig, err := aks.readAccessKeyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range accessKeyConfig {
+		if v.AccessKey == accessKey {
+			return &v, nil
+		}
+	}
+
+	return nil, errors.New("accessKey 不存在")
+}
+
 // 校验访问密钥
 func (aks *AccessKeyService) CheckAccessKey(accessKey string, secretKey string) bool {
 	accessKeyConfig, err := aks.readAccessKeyConfig()
@@ -144,13 +163,30 @@ func (aks *AccessKeyService) CheckAccessKey(accessKey string, secretKey string)
 
 	for _, v := range accessKeyConfig {
 		if v.AccessKey == accessKey && v.SecretKey == secretKey {
-			return true
+			// 已禁用的密钥不可使用
+			return v.Status == 1
 		}
 	}
 
 	return false
 }
 
+// VerifyPresignedURL 校验签名URL,签名使用的访问密钥需存在且处于启用状态
+func (aks *AccessKeyService) VerifyPresignedURL(method, bucket, key string, query url.Values) error {
+	accessKeyConfig, err := aks.readAccessKeyConfig()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range accessKeyConfig {
+		if v.AccessKey == query.Get(presign.QueryAccessKey) && v.Status == 1 {
+			return presign.Verify(v.SecretKey, method, bucket, key, query, time.Now())
+		}
+	}
+
+	return errors.New("访问密钥无效")
+}
+
 // DeleteAccessKey 删除访问密钥
 func (aks *AccessKeyService) DeleteAccessKey(userID string) error {
 	accessKeyConfig, err := aks.readAccessKeyConfig()
@@ -168,8 +204,58 @@ func (aks *AccessKeyService) DeleteAccessKey(userID string) error {
 	return aks.writeAccessKeyConfig(accessKeyConfig)
 }
 
-// CreateAndSaveAccessKey 创建并保存访问密钥
-func (aks *AccessKeyService) CreateAndSaveAccessKey(userID string, name string, expireTime string) (model.AccessKeyInfo, error) {
+// RevokeAccessKey 吊销(禁用)访问密钥,保留记录便于追溯
+func (aks *AccessKeyService) RevokeAccessKey(userID string) error {
+	accessKeyConfig, err := aks.readAccessKeyConfig()
+	if err != nil {
+		return err
+	}
+
+	for k, v := range accessKeyConfig {
+		if v.Name == userID {
+			accessKeyConfig[k].Status = -1
+			return aks.writeAccessKeyConfig(accessKeyConfig)
+		}
+	}
+
+	return errors.New("name 不存在")
+}
+
+// RevokeOwnerKeys 吊销用户的全部访问密钥
+func (aks *AccessKeyService) RevokeOwnerKeys(owner string) error {
+	accessKeyConfig, err := aks.readAccessKeyConfig()
+	if err != nil {
+		return err
+	}
+
+	for k, v := range accessKeyConfig {
+		if v.Owner == owner {
+			accessKeyConfig[k].Status = -1
+		}
+	}
+
+	return aks.writeAccessKeyConfig(accessKeyConfig)
+}
+
+// DeleteOwnerKeys 删除用户的全部访问密钥
+func (aks *AccessKeyService) DeleteOwnerKeys(owner string) error {
+	accessKeyConfig, err := aks.readAccessKeyConfig()
+	if err != nil {
+		return err
+	}
+
+	kept := accessKeyConfig[:0]
+	for _, v := range accessKeyConfig {
+		if v.Owner != owner {
+			kept = append(kept, v)
+		}
+	}
+
+	return aks.writeAccessKeyConfig(kept)
+}
+
+// CreateAndSaveAccessKey 创建并保存访问密钥,owner 为所属用户名
+func (aks *AccessKeyService) CreateAndSaveAccessKey(userID string, name string, expireTime string, owner string) (model.AccessKeyInfo, error) {
 	accessKey, secretKey, err := aks.GenerateAccessKey()
 	if err != nil {
 		return model.AccessKeyInfo{}, err
@@ -181,6 +267,7 @@ func (aks *AccessKeyService) CreateAndSaveAccessKey(userID string, name string,
 		SecretKey:  secretKey,
 		ExpireTime: expireTime,
 		Status:     1,
+		Owner:      owner,
 	}
 
 	err = aks.SaveAccessKey(userID, accessKeyInfo)
@@ -0,0 +1,183 @@
+/*
+ * @PackageName: services
+ * @FileName: audit_service.go
+ * @Description: 审计日志服务,以 JSON Lines 追加写入
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:12:31
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:12:31
+ */
+
+package services
+
+import (
+	"bufio"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/utils/str_util"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 审计日志写入锁,保证每条记录完整写入一行
+var auditMu sync.Mutex
+
+// 单条审计记录的最大长度
+const maxAuditLineSize = 1024 * 1024
+
+// AuditService 审计日志服务
+type AuditService struct {
+}
+
+// AuditQuery 审计日志查询条件,为空的条件不过滤
+type AuditQuery struct {
+	Start  time.Time
+	End    time.Time
+	Key    string // 访问密钥或其名称
+	Bucket string
+	Action string
+	Limit  int
+}
+
+// getLogPath 根据应用环境返回审计日志文件的路径
+func (as *AuditService) getLogPath() string {
+	auditLogPath := "config/audit.jsonl"
+	if config.Get("app.env") != "prod" {
+		auditLogPath = "tmp/config/audit.jsonl"
+	}
+	return auditLogPath
+}
+
+// Enabled 是否开启审计日志
+func (as *AuditService) Enabled() bool {
+	return config.GetBool("audit.enabled", true)
+}
+
+// Record 追加一条审计记录,只追加不修改
+func (as *AuditService) Record(record model.AuditRecord) error {
+	if record.ID == "" {
+		record.ID = str_util.SimpleUUID()
+	}
+	if record.Time == "" {
+		record.Time = app.TimenowInTimezone().Format(time.RFC3339)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	path := as.getLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// RecordQuietly 追加审计记录,失败时只记录日志,不影响调用方的主流程
+func (as *AuditService) RecordQuietly(record model.AuditRecord) {
+	if err := as.Record(record); err != nil {
+		logger.Error("Audit", zap.String("action", record.Action), zap.Error(err))
+	}
+}
+
+// Each 按时间顺序遍历符合条件的审计记录
+func (as *AuditService) Each(query AuditQuery, fn func(model.AuditRecord) error) error {
+	file, err := os.Open(as.getLogPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditLineSize)
+	for scanner.Scan() {
+		var record model.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// 跳过写入中断产生的不完整记录
+			continue
+		}
+		if !query.match(record) {
+			continue
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// List 查询审计记录,按时间倒序,最多返回 Limit 条
+func (as *AuditService) List(query AuditQuery) ([]model.AuditRecord, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	// 环形缓冲区,只保留最新的 limit 条
+	ring := make([]model.AuditRecord, 0, limit)
+	next := 0
+	err := as.Each(query, func(record model.AuditRecord) error {
+		if len(ring) < limit {
+			ring = append(ring, record)
+		} else {
+			ring[next] = record
+		}
+		next = (next + 1) % limit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]model.AuditRecord, 0, len(ring))
+	for i := 0; i < len(ring); i++ {
+		records = append(records, ring[(next-1-i+2*len(ring))%len(ring)])
+	}
+	return records, nil
+}
+
+// match 判断审计记录是否符合查询条件
+func (q AuditQuery) match(record model.AuditRecord) bool {
+	if q.Key != "" && q.Key != record.AccessKey && q.Key != record.Actor {
+		return false
+	}
+	if q.Bucket != "" && q.Bucket != record.Bucket {
+		return false
+	}
+	if q.Action != "" && q.Action != record.Action {
+		return false
+	}
+	if !q.Start.IsZero() || !q.End.IsZero() {
+		t, err := time.Parse(time.RFC3339, record.Time)
+		if err != nil {
+			return false
+		}
+		if !q.Start.IsZero() && t.Before(q.Start) {
+			return false
+		}
+		if !q.End.IsZero() && !t.Before(q.End) {
+			return false
+		}
+	}
+	return true
+}
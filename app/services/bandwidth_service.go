@@ -0,0 +1,87 @@
+/*
+ * @PackageName: services
+ * @FileName: bandwidth_service.go
+ * @Description: 带宽限速服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 20:24:38
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 20:24:38
+ */
+
+package services
+
+import (
+	"context"
+	"easy_dfs/pkg/bandwidth"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// 传输方向
+const (
+	DirectionUpload   = "upload"
+	DirectionDownload = "download"
+)
+
+// 按访问密钥、存储桶共享的限速器集合,首次使用时根据配置创建
+var (
+	bandwidthOnce   sync.Once
+	bandwidthGroups map[string]*bandwidthGroup
+)
+
+type bandwidthGroup struct {
+	perConnection int64
+	perKey        *bandwidth.Group
+	perBucket     *bandwidth.Group
+}
+
+// BandwidthService 带宽限速服务
+type BandwidthService struct {
+}
+
+// Enabled 是否开启带宽限速
+func (bs *BandwidthService) Enabled() bool {
+	return config.GetBool("bandwidth.enabled", false)
+}
+
+// groups 根据配置创建各传输方向的限速器集合
+func (bs *BandwidthService) groups() map[string]*bandwidthGroup {
+	bandwidthOnce.Do(func() {
+		bandwidthGroups = make(map[string]*bandwidthGroup)
+		for _, direction := range []string{DirectionUpload, DirectionDownload} {
+			prefix := "bandwidth." + direction + "_per_"
+			bandwidthGroups[direction] = &bandwidthGroup{
+				perConnection: config.GetInt64(prefix+"connection", 0) * 1024,
+				perKey:        bandwidth.NewGroup(config.GetInt64(prefix+"key", 0) * 1024),
+				perBucket:     bandwidth.NewGroup(config.GetInt64(prefix+"bucket", 0) * 1024),
+			}
+		}
+	})
+	return bandwidthGroups
+}
+
+// Throttle 返回一次传输的限速,同时受单连接、访问密钥、存储桶的限制,未开启或均不限速时返回 nil
+func (bs *BandwidthService) Throttle(ctx context.Context, direction, bucket, accessKey string) *bandwidth.Throttle {
+	if !bs.Enabled() {
+		return nil
+	}
+	group := bs.groups()[direction]
+	throttle := bandwidth.NewThrottle(ctx,
+		bandwidth.NewLimiter(group.perConnection),
+		group.perKey.Get(accessKey),
+		group.perBucket.Get(bucket),
+	)
+	if throttle == nil {
+		return nil
+	}
+
+	// 统计触发限速的传输次数及等待时间
+	var once sync.Once
+	throttle.OnWait = func(wait time.Duration) {
+		once.Do(func() { metrics.ThrottledTransfer(direction) })
+		metrics.ThrottleWait(direction, wait)
+	}
+	return throttle
+}
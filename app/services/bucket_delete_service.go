@@ -0,0 +1,186 @@
+/*
+ * @PackageName: services
+ * @FileName: bucket_delete_service.go
+ * @Description: 强制删除存储桶服务,先删除配置使存储桶立即不可用,再在后台逐个删除文件并记录进度
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 03:52:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 03:52:40
+ */
+
+package services
+
+import (
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/utils/str_util"
+	"errors"
+	"go.uber.org/zap"
+	"os"
+	"sync"
+	"time"
+)
+
+// 删除任务状态
+const (
+	BucketDeleteRunning   = "running"
+	BucketDeleteCompleted = "completed"
+	BucketDeleteFailed    = "failed"
+)
+
+// bucketDeleteJobLimit 最多保留的任务数,超出时丢弃最早结束的任务
+const bucketDeleteJobLimit = 100
+
+var (
+	bucketDeleteMu   sync.Mutex
+	bucketDeleteJobs []*model.BucketDeleteJob // 按开始时间排序
+)
+
+// BucketDeleteService 强制删除存储桶服务。任务只保存在内存中,服务重启时中断的任务
+// 留下的存储桶目录没有对应的配置,可通过 fsck 的 orphan_bucket_dir=delete 清理
+type BucketDeleteService struct {
+	FileService FileService
+}
+
+// ForceDelete 删除存储桶配置,并在后台删除存储桶中的所有文件,返回删除任务
+func (bds *BucketDeleteService) ForceDelete(bucket string) (*model.BucketDeleteJob, error) {
+	fs := &bds.FileService
+	bucketInfo, err := fs.BucketService.FindBucketInfo(bucket)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := fs.Storage.ListFilesUnder(fs.getFilePath(bucket, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	bucketDeleteMu.Lock()
+	if findDeletingJob(bucket) != nil {
+		bucketDeleteMu.Unlock()
+		return nil, ErrBucketDeleting
+	}
+	job := &model.BucketDeleteJob{
+		ID:        str_util.SimpleUUID(),
+		Bucket:    bucket,
+		Owner:     bucketInfo.Owner,
+		Status:    BucketDeleteRunning,
+		Total:     int64(len(keys)),
+		StartedAt: app.TimenowInTimezone().Format(time.RFC3339),
+	}
+	bucketDeleteJobs = append(bucketDeleteJobs, job)
+	pruneBucketDeleteJobs()
+	bucketDeleteMu.Unlock()
+
+	// 先删除配置,新的上传及下载立即失败;任务登记在前,删除期间无法创建同名存储桶
+	if err := fs.BucketService.removeBucketConfig(bucket); err != nil {
+		bucketDeleteMu.Lock()
+		for i, j := range bucketDeleteJobs {
+			if j == job {
+				bucketDeleteJobs = append(bucketDeleteJobs[:i], bucketDeleteJobs[i+1:]...)
+				break
+			}
+		}
+		bucketDeleteMu.Unlock()
+		return nil, err
+	}
+
+	snapshot := *job
+	go bds.run(job, keys)
+	return &snapshot, nil
+}
+
+// Job 获取删除任务,不存在时返回 nil
+func (bds *BucketDeleteService) Job(id string) *model.BucketDeleteJob {
+	bucketDeleteMu.Lock()
+	defer bucketDeleteMu.Unlock()
+	for _, job := range bucketDeleteJobs {
+		if job.ID == id {
+			snapshot := *job
+			return &snapshot
+		}
+	}
+	return nil
+}
+
+// Jobs 列出保留的删除任务,最近开始的在前
+func (bds *BucketDeleteService) Jobs() []model.BucketDeleteJob {
+	bucketDeleteMu.Lock()
+	defer bucketDeleteMu.Unlock()
+	jobs := make([]model.BucketDeleteJob, 0, len(bucketDeleteJobs))
+	for i := len(bucketDeleteJobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, *bucketDeleteJobs[i])
+	}
+	return jobs
+}
+
+// run 逐个删除文件并更新进度,最后删除存储桶目录及元数据目录
+func (bds *BucketDeleteService) run(job *model.BucketDeleteJob, keys []string) {
+	fs := &bds.FileService
+	var err error
+	for _, key := range keys {
+		info, statErr := fs.Storage.GetFileInfo(fs.getFilePath(job.Bucket, key))
+		if err = fs.deleteObject(job.Bucket, key); err != nil && !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		err = nil
+		bucketDeleteMu.Lock()
+		job.Deleted++
+		if statErr == nil {
+			job.Bytes += info.FileSize
+		}
+		bucketDeleteMu.Unlock()
+	}
+	// 列出文件之后上传的文件随目录一同删除
+	if err == nil {
+		err = os.RemoveAll(fs.getFilePath(job.Bucket, ""))
+	}
+	if err == nil {
+		err = os.RemoveAll(fs.MetaService.getMetaDir(job.Bucket))
+	}
+
+	bucketDeleteMu.Lock()
+	job.FinishedAt = app.TimenowInTimezone().Format(time.RFC3339)
+	job.Status = BucketDeleteCompleted
+	if err != nil {
+		job.Status = BucketDeleteFailed
+		job.Error = err.Error()
+	}
+	bucketDeleteMu.Unlock()
+
+	logger.Info("BucketDelete",
+		zap.String("id", job.ID),
+		zap.String("bucket", job.Bucket),
+		zap.String("status", job.Status),
+		zap.Int64("deleted", job.Deleted),
+		zap.Int64("bytes", job.Bytes),
+		zap.Error(err))
+}
+
+// bucketDeleting 存储桶是否有进行中的删除任务
+func bucketDeleting(bucket string) bool {
+	bucketDeleteMu.Lock()
+	defer bucketDeleteMu.Unlock()
+	return findDeletingJob(bucket) != nil
+}
+
+// findDeletingJob 查找存储桶进行中的删除任务,调用方需持有 bucketDeleteMu
+func findDeletingJob(bucket string) *model.BucketDeleteJob {
+	for _, job := range bucketDeleteJobs {
+		if job.Bucket == bucket && job.Status == BucketDeleteRunning {
+			return job
+		}
+	}
+	return nil
+}
+
+// pruneBucketDeleteJobs 丢弃超出上限的已结束任务,调用方需持有 bucketDeleteMu
+func pruneBucketDeleteJobs() {
+	for i := 0; len(bucketDeleteJobs) > bucketDeleteJobLimit && i < len(bucketDeleteJobs); {
+		if bucketDeleteJobs[i].Status == BucketDeleteRunning {
+			i++
+			continue
+		}
+		bucketDeleteJobs = append(bucketDeleteJobs[:i], bucketDeleteJobs[i+1:]...)
+	}
+}
@@ -11,17 +11,68 @@
 package services
 
 import (
+	"easy_dfs/app/enum/access_policy"
+	"easy_dfs/app/enum/event_type"
+	"easy_dfs/app/enum/storage_type"
 	"easy_dfs/model"
+	"easy_dfs/pkg/bucketname"
 	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/cors"
+	"easy_dfs/pkg/filesystem"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/referer"
+	"easy_dfs/pkg/website"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
+)
+
+// 域名格式
+var domainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`)
+
+// 存储桶操作的错误
+var (
+	ErrBucketNotFound = errors.New("bucket 不存在")
+	ErrBucketExists   = errors.New("bucket 已存在")
+	ErrBucketNotEmpty = errors.New("存储桶不为空,请先删除其中的文件或使用强制删除")
+	ErrBucketDeleting = errors.New("存储桶正在删除中")
+	ErrQuotaExceeded  = errors.New("超出存储桶配额")
+)
+
+// 存储桶标签限制
+const (
+	maxBucketTags        = 50
+	maxBucketTagKeyLen   = 128
+	maxBucketTagValueLen = 256
+)
+
+// 创建、删除、重命名及修改存储桶时持有,保证读取及写回配置之间不会被其他修改覆盖
+var bucketLifecycleMu sync.Mutex
+
+// 自定义域名与存储桶的对应关系,每个请求都会查询,缓存以免每次解析配置文件;
+// 按配置文件的修改时间及大小判断是否失效,命令行等其他进程修改配置后同样生效
+var (
+	domainCacheMu      sync.Mutex
+	domainCache        map[string]string
+	domainCachePath    string
+	domainCacheModTime time.Time
+	domainCacheSize    int64
 )
 
 // BucketService 存储桶服务
 type BucketService struct {
-	mu sync.Mutex
+	mu             sync.Mutex
+	WebhookService WebhookService    // 事件通知服务，用于发布存储桶事件
+	MetaService    ObjectMetaService // 文件元数据服务，删除存储桶时一并删除元数据
 }
 
 // getConfigPath 根据应用环境返回存储桶配置文件的路径
@@ -68,30 +119,62 @@ func (bs *BucketService) writeBucketConfig(bucketConfig []model.BucketInfo) erro
 		return err
 	}
 
-	err = os.WriteFile(bucketConfigPath, bucketConfigJson, 0644)
-	if err != nil {
+	// 原子地替换配置文件,写入中断时保留原配置
+	err = filesystem.WriteFileAtomic(bucketConfigPath, bucketConfigJson, 0644)
+	invalidateDomainCache()
+	return err
+}
+
+// CreateBucket 创建新的存储桶，校验名称及配置，如果已存在则返回错误
+func (bs *BucketService) CreateBucket(bucketInfo model.BucketInfo) error {
+	if err := bucketname.Validate(bucketInfo.Name); err != nil {
 		return err
 	}
+	if bucketInfo.AccessPolicy == "" {
+		bucketInfo.AccessPolicy = access_policy.PRIVATE
+	}
+	if !access_policy.IsValid(bucketInfo.AccessPolicy) {
+		return fmt.Errorf("访问策略只能为 %s 或 %s", access_policy.PRIVATE, access_policy.PUBLIC)
+	}
+	if !storage_type.IsValid(bucketInfo.StorageType) {
+		return fmt.Errorf("不支持的存储类型 %s", bucketInfo.StorageType)
+	}
+	if err := validateBucketTags(bucketInfo.Tags); err != nil {
+		return err
+	}
+	for i := range bucketInfo.Webhooks {
+		bucketInfo.Webhooks[i].HasSecret = false
+	}
 
-	return nil
-}
+	bucketLifecycleMu.Lock()
+	defer bucketLifecycleMu.Unlock()
 
-// CreateBucket 创建新的存储桶，如果已存在则返回错误
-func (bs *BucketService) CreateBucket(bucketName string, accessPolicy string) error {
 	bucketConfig, err := bs.readBucketConfig()
 	if err != nil {
 		return err
 	}
 
 	for _, v := range bucketConfig {
-		if v.Name == bucketName {
-			return errors.New("bucket 已存在")
+		if v.Name == bucketInfo.Name {
+			return ErrBucketExists
 		}
 	}
+	// 同名存储桶的文件仍在删除中,此时创建会被删除任务一并删除
+	if bucketDeleting(bucketInfo.Name) {
+		return ErrBucketDeleting
+	}
 
-	bucketConfig = append(bucketConfig, model.BucketInfo{Name: bucketName, AccessPolicy: accessPolicy})
+	bucketConfig = append(bucketConfig, bucketInfo)
 
-	return bs.writeBucketConfig(bucketConfig)
+	if err := bs.writeBucketConfig(bucketConfig); err != nil {
+		return err
+	}
+	// 同时创建存储桶目录,一致性检查据此判断存储桶目录是否缺失
+	if err := os.MkdirAll(storagePath(bucketInfo.Name, ""), os.ModePerm); err != nil {
+		return err
+	}
+	bs.WebhookService.PublishQuietly(event_type.BUCKET_CREATED, bucketInfo, "", 0)
+	return nil
 }
 
 // GetBucketList 返回所有存储桶配置的列表
@@ -112,22 +195,489 @@ func (bs *BucketService) FindBucketInfo(bucketName string) (*model.BucketInfo, e
 		}
 	}
 
-	return nil, errors.New("bucket 不存在")
+	return nil, ErrBucketNotFound
 }
 
-// DeleteBucket 根据存储桶名称删除存储桶配置
+// DeleteBucket 删除存储桶,存储桶中仍有文件时返回 ErrBucketNotEmpty,需要删除文件时使用 BucketDeleteService.ForceDelete。
+// 检查及删除期间持有存储桶锁,进行中的写入完成后才开始检查,之后的写入会因存储桶不存在而失败
 func (bs *BucketService) DeleteBucket(bucketName string) error {
+	bucketLifecycleMu.Lock()
+	defer bucketLifecycleMu.Unlock()
+	unlock := bucketLocks.Lock(bucketName)
+	defer unlock()
+
+	if _, err := bs.FindBucketInfo(bucketName); err != nil {
+		return err
+	}
+	hasFiles, err := bucketHasFiles(bucketName)
+	if err != nil {
+		return err
+	}
+	if hasFiles {
+		return ErrBucketNotEmpty
+	}
+	if err := bs.deleteBucketConfig(bucketName); err != nil {
+		return err
+	}
+	// 只剩空目录、没有对应文件的元数据及写入中的临时文件,一并删除。
+	// 配置已删除,删除目录失败时只记录日志:进行中的上传可能同时创建临时文件,
+	// 这些上传会因存储桶不存在而放弃,并删除其创建的目录
+	for _, dir := range []string{storagePath(bucketName, ""), bs.MetaService.getMetaDir(bucketName)} {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Warn("Bucket", zap.String("bucket", bucketName), zap.String("dir", dir), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// removeBucketConfig 删除存储桶配置并发布事件,存储桶不存在时返回 ErrBucketNotFound;
+// 持有存储桶锁,等待进行中的写入完成,之后的写入会因存储桶不存在而失败
+func (bs *BucketService) removeBucketConfig(bucketName string) error {
+	bucketLifecycleMu.Lock()
+	defer bucketLifecycleMu.Unlock()
+	unlock := bucketLocks.Lock(bucketName)
+	defer unlock()
+	return bs.deleteBucketConfig(bucketName)
+}
+
+// deleteBucketConfig 删除存储桶配置并发布事件,调用方需持有 bucketLifecycleMu 及存储桶锁
+func (bs *BucketService) deleteBucketConfig(bucketName string) error {
 	bucketConfig, err := bs.readBucketConfig()
 	if err != nil {
 		return err
 	}
 
+	var deleted *model.BucketInfo
 	for k, v := range bucketConfig {
 		if v.Name == bucketName {
+			deleted = &v
 			bucketConfig = append(bucketConfig[:k], bucketConfig[k+1:]...)
 			break
 		}
 	}
+	if deleted == nil {
+		return ErrBucketNotFound
+	}
 
-	return bs.writeBucketConfig(bucketConfig)
+	if err := bs.writeBucketConfig(bucketConfig); err != nil {
+		return err
+	}
+	invalidateUsage(bucketName)
+	removeBucketImageCache(bucketName)
+	// 配置已删除,使用删除前的通知配置投递事件
+	bs.WebhookService.PublishQuietly(event_type.BUCKET_REMOVED, *deleted, "", 0)
+	return nil
+}
+
+// bucketHasFiles 存储桶目录下是否有文件,写入中的临时文件不计
+func bucketHasFiles(bucketName string) (bool, error) {
+	root := storagePath(bucketName, "")
+	found := errors.New("found")
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && !filesystem.IsTempFile(path) {
+			return found
+		}
+		return nil
+	})
+	if errors.Is(err, found) {
+		return true, nil
+	}
+	return false, err
+}
+
+// BucketUpdate 修改存储桶的内容,为 nil 的项不修改
+type BucketUpdate struct {
+	AccessPolicy *string
+	StorageType  *string
+	Description  *string
+	Tags         *map[string]string // 为空映射时清除全部标签
+	Quota        *model.BucketQuota // 各项均为 0 时取消配额
+	Owner        *string            // 为空字符串时只有管理员可以管理
+}
+
+// Validate 校验修改内容
+func (update BucketUpdate) Validate() error {
+	if update.AccessPolicy != nil && !access_policy.IsValid(*update.AccessPolicy) {
+		return fmt.Errorf("访问策略只能为 %s 或 %s", access_policy.PRIVATE, access_policy.PUBLIC)
+	}
+	if update.StorageType != nil && !storage_type.IsValid(*update.StorageType) {
+		return fmt.Errorf("不支持的存储类型 %s", *update.StorageType)
+	}
+	if update.Tags != nil {
+		if err := validateBucketTags(*update.Tags); err != nil {
+			return err
+		}
+	}
+	if update.Quota != nil && (update.Quota.MaxSize < 0 || update.Quota.MaxObjects < 0) {
+		return errors.New("配额不能为负数")
+	}
+	return nil
+}
+
+// UpdateBucket 修改存储桶的访问策略、存储类型、描述、标签、配额及所有者,返回修改后的存储桶配置
+func (bs *BucketService) UpdateBucket(bucketName string, update BucketUpdate) (*model.BucketInfo, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	var updated model.BucketInfo
+	err := bs.modifyBucket(bucketName, func(info *model.BucketInfo, _ []model.BucketInfo) error {
+		if update.AccessPolicy != nil {
+			info.AccessPolicy = *update.AccessPolicy
+		}
+		if update.StorageType != nil {
+			info.StorageType = *update.StorageType
+		}
+		if update.Description != nil {
+			info.Description = *update.Description
+		}
+		if update.Tags != nil {
+			info.Tags = *update.Tags
+			if len(info.Tags) == 0 {
+				info.Tags = nil
+			}
+		}
+		if update.Quota != nil {
+			info.Quota = update.Quota
+			if update.Quota.MaxSize == 0 && update.Quota.MaxObjects == 0 {
+				info.Quota = nil
+			}
+		}
+		if update.Owner != nil {
+			info.Owner = *update.Owner
+		}
+		updated = *info
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// modifyBucket 持有存储桶生命周期锁读取配置,由 modify 修改指定存储桶后写回,
+// 避免并发修改同一配置文件时互相覆盖;modify 返回错误时不写入
+func (bs *BucketService) modifyBucket(bucketName string, modify func(info *model.BucketInfo, bucketConfig []model.BucketInfo) error) error {
+	bucketLifecycleMu.Lock()
+	defer bucketLifecycleMu.Unlock()
+
+	bucketConfig, err := bs.readBucketConfig()
+	if err != nil {
+		return err
+	}
+	for k := range bucketConfig {
+		if bucketConfig[k].Name != bucketName {
+			continue
+		}
+		if err := modify(&bucketConfig[k], bucketConfig); err != nil {
+			return err
+		}
+		return bs.writeBucketConfig(bucketConfig)
+	}
+	return ErrBucketNotFound
+}
+
+// validateBucketTags 校验存储桶标签
+func validateBucketTags(tags map[string]string) error {
+	if len(tags) > maxBucketTags {
+		return fmt.Errorf("标签不能超过 %d 个", maxBucketTags)
+	}
+	for k, v := range tags {
+		if k == "" || len(k) > maxBucketTagKeyLen {
+			return fmt.Errorf("标签名长度应为 1 到 %d 个字符", maxBucketTagKeyLen)
+		}
+		if len(v) > maxBucketTagValueLen {
+			return fmt.Errorf("标签 %s 的值不能超过 %d 个字符", k, maxBucketTagValueLen)
+		}
+	}
+	return nil
+}
+
+// RenameBucket 重命名存储桶:依次移动存储目录、元数据目录并更新配置,任一步失败时撤销已完成的步骤。
+// 重命名期间进行中的上传会失败,存储桶的访问地址随名称改变
+func (bs *BucketService) RenameBucket(from, to string) error {
+	if err := bucketname.Validate(to); err != nil {
+		return err
+	}
+
+	bucketLifecycleMu.Lock()
+	defer bucketLifecycleMu.Unlock()
+
+	bucketConfig, err := bs.readBucketConfig()
+	if err != nil {
+		return err
+	}
+	index := -1
+	for k, v := range bucketConfig {
+		if v.Name == to {
+			return ErrBucketExists
+		}
+		if v.Name == from {
+			index = k
+		}
+	}
+	if index < 0 {
+		return ErrBucketNotFound
+	}
+	if bucketDeleting(to) {
+		return ErrBucketDeleting
+	}
+	// 目标目录已存在时不覆盖,需先通过 fsck 处理
+	for _, dir := range []string{storagePath(to, ""), bs.MetaService.getMetaDir(to)} {
+		if _, err := os.Lstat(dir); err == nil {
+			return fmt.Errorf("%s 已存在,请先通过 fsck 处理", dir)
+		}
+	}
+
+	moved, err := renameDirs([][2]string{
+		{storagePath(from, ""), storagePath(to, "")},
+		{bs.MetaService.getMetaDir(from), bs.MetaService.getMetaDir(to)},
+	})
+	if err != nil {
+		return err
+	}
+	old := bucketConfig[index]
+	bucketConfig[index].Name = to
+	if err := bs.writeBucketConfig(bucketConfig); err != nil {
+		return errors.Join(err, undoRenameDirs(moved))
+	}
+	invalidateUsage(from)
+	invalidateUsage(to)
+	removeBucketImageCache(from)
+
+	bs.WebhookService.PublishQuietly(event_type.BUCKET_REMOVED, old, "", 0)
+	bs.WebhookService.PublishQuietly(event_type.BUCKET_CREATED, bucketConfig[index], "", 0)
+	return nil
+}
+
+// renameDirs 依次重命名目录,源目录不存在时跳过;失败时撤销已完成的重命名。返回已完成的重命名
+func renameDirs(pairs [][2]string) ([][2]string, error) {
+	var moved [][2]string
+	for _, pair := range pairs {
+		err := os.Rename(pair[0], pair[1])
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Join(err, undoRenameDirs(moved))
+		}
+		moved = append(moved, pair)
+	}
+	return moved, nil
+}
+
+// undoRenameDirs 按相反的顺序撤销目录重命名
+func undoRenameDirs(moved [][2]string) error {
+	var errs []error
+	for i := len(moved) - 1; i >= 0; i-- {
+		errs = append(errs, os.Rename(moved[i][1], moved[i][0]))
+	}
+	return errors.Join(errs...)
+}
+
+// UpdateBucketPolicy 更新存储桶的访问策略
+func (bs *BucketService) UpdateBucketPolicy(bucketName, accessPolicy string) error {
+	return bs.modifyBucket(bucketName, func(info *model.BucketInfo, _ []model.BucketInfo) error {
+		info.AccessPolicy = accessPolicy
+		return nil
+	})
+}
+
+// UpdateBucketWebhooks 更新存储桶的事件通知配置,未传签名密钥且 HasSecret 为 true 的地址沿用原有的密钥
+func (bs *BucketService) UpdateBucketWebhooks(bucketName string, webhooks []model.WebhookConfig) error {
+	return bs.modifyBucket(bucketName, func(info *model.BucketInfo, _ []model.BucketInfo) error {
+		for i := range webhooks {
+			if webhooks[i].Secret == "" && webhooks[i].HasSecret {
+				webhooks[i].Secret = webhookSecret(info.Webhooks, webhooks[i].URL)
+			}
+			webhooks[i].HasSecret = false
+		}
+		info.Webhooks = webhooks
+		return nil
+	})
+}
+
+// webhookSecret 返回同一通知地址原有的签名密钥
+func webhookSecret(webhooks []model.WebhookConfig, url string) string {
+	for _, hook := range webhooks {
+		if hook.URL == url {
+			return hook.Secret
+		}
+	}
+	return ""
+}
+
+// MaskWebhookSecrets 清空存储桶配置中的签名密钥,用于接口返回,避免有查看权限的用户读取密钥伪造通知
+func MaskWebhookSecrets(bucketInfo *model.BucketInfo) {
+	if len(bucketInfo.Webhooks) == 0 {
+		return
+	}
+	webhooks := make([]model.WebhookConfig, len(bucketInfo.Webhooks))
+	for i, hook := range bucketInfo.Webhooks {
+		hook.HasSecret = hook.Secret != ""
+		hook.Secret = ""
+		webhooks[i] = hook
+	}
+	bucketInfo.Webhooks = webhooks
+}
+
+// UpdateBucketCors 更新存储桶的跨域规则
+func (bs *BucketService) UpdateBucketCors(bucketName string, rules []cors.Rule) error {
+	return bs.modifyBucket(bucketName, func(info *model.BucketInfo, _ []model.BucketInfo) error {
+		info.Cors = rules
+		return nil
+	})
+}
+
+// UpdateBucketReferer 更新存储桶的防盗链规则,rule 为 nil 时关闭防盗链
+func (bs *BucketService) UpdateBucketReferer(bucketName string, rule *referer.Rule) error {
+	if rule != nil && rule.Placeholder != "" {
+		placeholder, err := cleanObjectKey(rule.Placeholder)
+		if err != nil {
+			return err
+		}
+		rule.Placeholder = placeholder
+	}
+
+	return bs.modifyBucket(bucketName, func(info *model.BucketInfo, _ []model.BucketInfo) error {
+		info.Referer = rule
+		return nil
+	})
+}
+
+// UpdateBucketDomains 更新存储桶的自定义域名,同一域名只能绑定一个存储桶
+func (bs *BucketService) UpdateBucketDomains(bucketName string, domains []string) error {
+	appHost := appHostname()
+	storageHost := storageDomain()
+	for i, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if !domainPattern.MatchString(domain) {
+			return errors.New("域名格式非法: " + domain)
+		}
+		if domain == appHost {
+			return errors.New("域名不能与服务地址相同: " + domain)
+		}
+		// 文件域名及其子域名保留给 <bucket>.{storage.domain},避免占用其他存储桶的地址
+		if storageHost != "" && (domain == storageHost || strings.HasSuffix(domain, "."+storageHost)) {
+			return errors.New("域名不能使用文件域名 " + storageHost + ": " + domain)
+		}
+		domains[i] = domain
+	}
+
+	return bs.modifyBucket(bucketName, func(info *model.BucketInfo, bucketConfig []model.BucketInfo) error {
+		for _, v := range bucketConfig {
+			if v.Name == bucketName {
+				continue
+			}
+			for _, used := range v.Domains {
+				for _, domain := range domains {
+					if used == domain {
+						return errors.New("域名已被存储桶 " + v.Name + " 使用: " + domain)
+					}
+				}
+			}
+		}
+		info.Domains = domains
+		return nil
+	})
+}
+
+// UpdateBucketWebsite 更新存储桶的静态网站配置,site 为 nil 时关闭
+func (bs *BucketService) UpdateBucketWebsite(bucketName string, site *website.Config) error {
+	if site != nil && site.ErrorDocument != "" {
+		errorDocument, err := cleanObjectKey(site.ErrorDocument)
+		if err != nil {
+			return err
+		}
+		site.ErrorDocument = errorDocument
+	}
+
+	return bs.modifyBucket(bucketName, func(info *model.BucketInfo, _ []model.BucketInfo) error {
+		info.Website = site
+		return nil
+	})
+}
+
+// ResolveHostBucket 根据请求的 Host 解析存储桶:先匹配自定义域名,再匹配 <bucket>.{storage.domain}
+func (bs *BucketService) ResolveHostBucket(host string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	// 服务地址、IP 及 localhost 不是文件域名,无需读取存储桶配置
+	if host == "" || host == "localhost" || host == appHostname() || net.ParseIP(host) != nil {
+		return "", false
+	}
+
+	// 文件域名及其子域名只按 <bucket>.{storage.domain} 解析,不匹配自定义域名,
+	// 修改文件域名前已绑定的自定义域名同样不能占用其他存储桶的地址。
+	// 存储桶名称可以包含点,如 my.photos.{storage.domain}
+	if domain := storageDomain(); domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+		bucket := strings.TrimSuffix(host, "."+domain)
+		if host != domain && bucketname.Validate(bucket) == nil {
+			return bucket, true
+		}
+		return "", false
+	}
+
+	if bucket, ok := bs.domainBuckets()[host]; ok {
+		return bucket, true
+	}
+	return "", false
+}
+
+// domainBuckets 返回自定义域名对应的存储桶,配置文件的修改时间或大小变化后重新读取
+func (bs *BucketService) domainBuckets() map[string]string {
+	bucketConfigPath := bs.getConfigPath()
+	stat, err := os.Stat(bucketConfigPath)
+	if err != nil {
+		return nil
+	}
+
+	domainCacheMu.Lock()
+	defer domainCacheMu.Unlock()
+	if domainCache != nil && domainCachePath == bucketConfigPath && domainCacheModTime.Equal(stat.ModTime()) && domainCacheSize == stat.Size() {
+		return domainCache
+	}
+
+	bucketConfig, err := bs.readBucketConfig()
+	if err != nil {
+		return nil
+	}
+	domains := make(map[string]string)
+	for _, v := range bucketConfig {
+		for _, domain := range v.Domains {
+			domains[domain] = v.Name
+		}
+	}
+	domainCache, domainCachePath = domains, bucketConfigPath
+	domainCacheModTime, domainCacheSize = stat.ModTime(), stat.Size()
+	return domainCache
+}
+
+// invalidateDomainCache 存储桶配置写入后作废自定义域名缓存
+func invalidateDomainCache() {
+	domainCacheMu.Lock()
+	defer domainCacheMu.Unlock()
+	domainCache = nil
+}
+
+// storageDomain 返回文件域名 storage.domain,未配置时为空
+func storageDomain() string {
+	return strings.ToLower(config.GetString("storage.domain"))
+}
+
+// appHostname 返回服务地址 app.url 的主机名
+func appHostname() string {
+	u, err := url.Parse(config.GetString("app.url"))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
 }
@@ -11,48 +11,259 @@
 package services
 
 import (
+	"easy_dfs/app/enum/event_type"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/checksum"
 	"easy_dfs/pkg/config"
 	"easy_dfs/pkg/filesystem"
+	"easy_dfs/pkg/keylock"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/metrics"
 	"errors"
+	"fmt"
+	"go.uber.org/zap"
 	"io"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
 // FileService 文件服务
 type FileService struct {
-	Storage       filesystem.FileSystemStorage // 文件系统存储接口，用于具体的文件操作
-	BucketService BucketService                // 存储桶服务，用于获取存储桶信息
-	mu            sync.Mutex                   // 互斥锁，用于保护文件操作的并发访问
+	Storage        filesystem.FileSystemStorage // 文件系统存储接口，用于具体的文件操作
+	BucketService  BucketService                // 存储桶服务，用于获取存储桶信息
+	WebhookService WebhookService               // 事件通知服务，用于发布文件事件
+	MetaService    ObjectMetaService            // 文件元数据服务，用于保存原始请求体上传的元数据
+}
+
+// 文件锁,所有 FileService 共享,按存储桶及文件名加读写锁,不同文件的操作互不阻塞。
+// 写入先落到临时文件,仅在原子重命名及更新元数据时持有写锁,列出文件无需加锁
+var objectLocks = keylock.New()
+
+// 存储桶锁,按存储桶名称加读写锁:写入文件时加读锁并确认存储桶仍存在,删除存储桶时加写锁,
+// 保证删除前的检查与删除目录之间不会有新文件写入
+var bucketLocks = keylock.New()
+
+// objectLockKey 文件锁的键。key 须已由 cleanObjectKey 规范化(对外的方法经 resolveObject,
+// 一致性检查及巡检使用遍历得到的文件名),保证同一文件只对应一个锁
+func objectLockKey(bucket, key string) string {
+	return bucket + "/" + key
 }
 
 const BasePath = "storage/" // 保存文件的基础路径
 
+// ErrInvalidObjectKey 文件名为空或使用了保留的前缀
+var ErrInvalidObjectKey = errors.New("文件名非法")
+
+// PutObjectOptions 保存文件时的选项
+type PutObjectOptions struct {
+	ContentType string            // 内容类型,为空时下载时按文件内容检测
+	ContentMD5  string            // Base64 编码的 MD5,不为空时校验
+	SHA256      string            // 十六进制编码的 SHA-256,不为空时校验
+	Metadata    map[string]string // 自定义元数据
+}
+
 // SaveFile 将数据保存到指定的存储桶和文件名中
 func (fs *FileService) SaveFile(bucket, filename string, data io.Reader) error {
+	_, err := fs.PutObject(bucket, filename, data, PutObjectOptions{})
+	return err
+}
+
+// PutObject 保存文件:写入临时文件的同时计算摘要,摘要与客户端提供的不一致时放弃写入;
+// 校验通过后保存元数据(含摘要,供完整性校验使用)并原子地替换目标文件。失败时不留下任何文件
+func (fs *FileService) PutObject(bucket, key string, data io.Reader, opts PutObjectOptions) (*model.ObjectMeta, error) {
 	// 判断bucket是否合法
 	if bucket == "" {
-		return errors.New("bucket不能为空")
+		return nil, errors.New("bucket不能为空")
+	}
+	key, err := cleanObjectKey(key)
+	if err != nil {
+		return nil, err
 	}
 
 	// bucket需在配置文件中存在
-	_, err := fs.BucketService.FindBucketInfo(bucket)
+	bucketInfo, err := fs.BucketService.FindBucketInfo(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := checksum.NewReader(data, opts.ContentMD5, opts.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	// 统计上传字节数及正在进行的上传数
+	done := metrics.UploadStarted()
+	defer done()
+
+	filePath := fs.getFilePath(bucket, key)               // 获取文件保存路径
+	tmpPath, err := fs.Storage.SaveTemp(filePath, reader) // 写入临时文件并校验摘要,不持有锁
+	metrics.AddUploadedBytes(bucket, reader.N())
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.checkQuota(bucketInfo, key, reader.N()); err != nil {
+		fs.Storage.Abort(tmpPath)
+		return nil, err
+	}
+
+	meta := &model.ObjectMeta{
+		ContentType:  opts.ContentType,
+		Size:         reader.N(),
+		MD5:          reader.MD5(),
+		SHA256:       reader.SHA256(),
+		Metadata:     opts.Metadata,
+		LastModified: app.TimenowInTimezone().Format(time.RFC3339),
+	}
+
+	unlockBucket := bucketLocks.RLock(bucket)
+	defer unlockBucket()
+	// 写入临时文件期间存储桶可能已被删除,持有存储桶锁后再次确认
+	if _, err := fs.BucketService.FindBucketInfo(bucket); err != nil {
+		fs.Storage.Abort(tmpPath)
+		return nil, err
+	}
+
+	unlock := objectLocks.Lock(objectLockKey(bucket, key))
+	defer unlock()
+
+	// 先保存元数据再替换文件,替换失败时恢复原元数据,保证两者一致
+	oldMeta, err := fs.MetaService.Get(bucket, key)
+	if err != nil {
+		fs.Storage.Abort(tmpPath)
+		return nil, err
+	}
+	if err := fs.MetaService.Save(bucket, key, *meta); err != nil {
+		fs.Storage.Abort(tmpPath)
+		return nil, err
+	}
+	var oldSize, oldObjects int64
+	if info, err := os.Stat(filePath); err == nil {
+		oldSize, oldObjects = info.Size(), 1
+	}
+	if err := fs.Storage.Commit(tmpPath, filePath); err != nil {
+		if oldMeta != nil {
+			err = errors.Join(err, fs.MetaService.Save(bucket, key, *oldMeta))
+		} else {
+			err = errors.Join(err, fs.MetaService.Delete(bucket, key))
+		}
+		return nil, err
+	}
+	adjustUsage(bucket, meta.Size-oldSize, 1-oldObjects)
+	invalidateImageCache(bucket, key)
+
+	// 发布文件创建事件
+	fs.WebhookService.PublishQuietly(event_type.OBJECT_CREATED, *bucketInfo, key, meta.Size)
+	return meta, nil
+}
+
+// checkQuota 校验写入后是否超出存储桶配额,覆盖已有文件时不计原文件。
+// 不与其他上传互斥,并发上传时可能略微超出配额
+func (fs *FileService) checkQuota(bucketInfo *model.BucketInfo, key string, size int64) error {
+	quota := bucketInfo.Quota
+	if quota == nil || (quota.MaxSize == 0 && quota.MaxObjects == 0) {
+		return nil
+	}
+	usedSize, objects, err := fs.bucketUsage(bucketInfo.Name)
 	if err != nil {
 		return err
 	}
+	if info, err := os.Stat(fs.getFilePath(bucketInfo.Name, key)); err == nil {
+		usedSize -= info.Size()
+		objects--
+	}
+	if quota.MaxSize > 0 && usedSize+size > quota.MaxSize {
+		return fmt.Errorf("%w: 占用空间上限为 %d 字节,已使用 %d 字节", ErrQuotaExceeded, quota.MaxSize, usedSize)
+	}
+	if quota.MaxObjects > 0 && objects+1 > quota.MaxObjects {
+		return fmt.Errorf("%w: 文件数上限为 %d", ErrQuotaExceeded, quota.MaxObjects)
+	}
+	return nil
+}
+
+// 存储桶用量缓存,仅在校验配额时统计,之后随写入及删除增量调整;
+// 超过有效期后重新统计,以纠正绕过服务对存储目录的修改
+var (
+	usageMu    sync.Mutex
+	usageCache = make(map[string]*bucketUsage)
+)
+
+const usageCacheTTL = 5 * time.Minute // 用量缓存有效期
+
+// bucketUsage 存储桶已使用的空间及文件数
+type bucketUsage struct {
+	size     int64
+	objects  int64
+	loadedAt time.Time
+}
+
+// bucketUsage 返回存储桶已使用的空间及文件数,缓存失效时遍历存储目录统计,不计临时文件
+func (fs *FileService) bucketUsage(bucket string) (int64, int64, error) {
+	usageMu.Lock()
+	if usage, ok := usageCache[bucket]; ok && time.Since(usage.loadedAt) < usageCacheTTL {
+		usageMu.Unlock()
+		return usage.size, usage.objects, nil
+	}
+	usageMu.Unlock()
+
+	root := fs.getFilePath(bucket, "")
+	usage := &bucketUsage{loadedAt: time.Now()}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filesystem.IsTempFile(path) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			usage.size += info.Size()
+			usage.objects++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
 
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	usageMu.Lock()
+	usageCache[bucket] = usage
+	usageMu.Unlock()
+	return usage.size, usage.objects, nil
+}
+
+// adjustUsage 按写入或删除调整已缓存的存储桶用量,未缓存时不做处理
+func adjustUsage(bucket string, size, objects int64) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	if usage, ok := usageCache[bucket]; ok {
+		usage.size += size
+		usage.objects += objects
+	}
+}
 
-	filePath := fs.getFilePath(bucket, filename) // 获取文件保存路径
-	return fs.Storage.Save(filePath, data)       // 调用存储接口保存文件
+// invalidateUsage 清除存储桶的用量缓存,删除或重命名存储桶时调用
+func invalidateUsage(bucket string) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	delete(usageCache, bucket)
 }
 
 // LoadFile 加载指定存储桶和文件名的文件内容
 func (fs *FileService) LoadFile(bucket, filename string) (io.Reader, error) {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	filename, err := fs.resolveObject(bucket, filename)
+	if err != nil {
+		return nil, err
+	}
+	unlock := objectLocks.RLock(objectLockKey(bucket, filename))
+	defer unlock()
 
 	filePath := fs.getFilePath(bucket, filename) // 获取文件加载路径
 	return fs.Storage.Load(filePath)             // 调用存储接口加载文件内容
@@ -67,8 +278,12 @@ func (fs *FileService) LoadFile(bucket, filename string) (io.Reader, error) {
 // }
 
 func (fs *FileService) LoadFileByPath(bucket, filePath string) (io.Reader, error) {
-	fs.mu.Lock()                                     // 加锁以保护文件操作
-	defer fs.mu.Unlock()                             // 延迟释放锁
+	filePath, err := fs.resolveObject(bucket, filePath)
+	if err != nil {
+		return nil, err
+	}
+	unlock := objectLocks.RLock(objectLockKey(bucket, filePath))
+	defer unlock()
 	realFilePath := fs.getFilePath(bucket, filePath) // 获取文件加载路径
 
 	// // 打印调试信息
@@ -77,18 +292,36 @@ func (fs *FileService) LoadFileByPath(bucket, filePath string) (io.Reader, error
 	return fs.Storage.Load(realFilePath) // 调用存储接口加载文件内容
 }
 
+// OpenFile 打开指定存储桶和文件名的文件,返回文件及其状态,调用方负责关闭文件
+func (fs *FileService) OpenFile(bucket, filename string) (*os.File, os.FileInfo, error) {
+	filename, err := fs.resolveObject(bucket, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlock := objectLocks.RLock(objectLockKey(bucket, filename))
+	defer unlock()
+
+	filePath := fs.getFilePath(bucket, filename) // 获取文件加载路径
+	return fs.Storage.Open(filePath)             // 调用存储接口打开文件
+}
+
 // 获取指定存储桶下的所有文件
 func (fs *FileService) ListFiles(bucket string) ([]string, error) {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	// bucket需在配置文件中存在
+	if _, err := fs.BucketService.FindBucketInfo(bucket); err != nil {
+		return nil, err
+	}
 
-	return fs.Storage.ListFiles(bucket) // 调用存储接口列出文件
+	files, err := fs.Storage.ListFiles(bucket) // 调用存储接口列出文件
+	// 尚未上传过文件的存储桶没有目录,视为空
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	return files, err
 }
 
 // 获取所有存储桶下的所有文件
 func (fs *FileService) ListAllFiles() ([]filesystem.ResponseFileList, error) {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
 
 	return fs.Storage.ListAllFiles() // 调用存储接口列出所有文件
 
@@ -96,8 +329,12 @@ func (fs *FileService) ListAllFiles() ([]filesystem.ResponseFileList, error) {
 
 // 获取文件信息
 func (fs *FileService) GetFileInfo(bucket, filename string) (filesystem.FileInfo, error) {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	filename, err := fs.resolveObject(bucket, filename)
+	if err != nil {
+		return filesystem.FileInfo{}, err
+	}
+	unlock := objectLocks.RLock(objectLockKey(bucket, filename))
+	defer unlock()
 
 	filePath := fs.getFilePath(bucket, filename) // 获取文件信息路径
 	return fs.Storage.GetFileInfo(filePath)      // 调用存储接口获取文件信息
@@ -105,20 +342,98 @@ func (fs *FileService) GetFileInfo(bucket, filename string) (filesystem.FileInfo
 
 // DeleteFile 删除指定存储桶和文件名的文件
 func (fs *FileService) DeleteFile(bucket, filename string) error {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	bucketInfo, err := fs.BucketService.FindBucketInfo(bucket)
+	if err != nil {
+		return err
+	}
+	if filename, err = cleanObjectKey(filename); err != nil {
+		return err
+	}
+	if err := fs.deleteObject(bucket, filename); err != nil {
+		return err
+	}
 
-	filePath := fs.getFilePath(bucket, filename) // 获取文件删除路径
-	return fs.Storage.Delete(filePath)           // 调用存储接口删除文件
+	// 发布文件删除事件
+	fs.WebhookService.PublishQuietly(event_type.OBJECT_REMOVED, *bucketInfo, filename, 0)
+	return nil
+}
+
+// deleteObject 删除文件及其元数据并调整用量,key 须已规范化。
+// 强制删除存储桶时配置已先删除,由删除任务直接调用,不发布事件
+func (fs *FileService) deleteObject(bucket, key string) error {
+	unlock := objectLocks.Lock(objectLockKey(bucket, key))
+	defer unlock()
+
+	filePath := fs.getFilePath(bucket, key) // 获取文件删除路径
+	info, statErr := os.Stat(filePath)
+	if err := fs.Storage.Delete(filePath); err != nil { // 调用存储接口删除文件
+		return err
+	}
+	if statErr == nil {
+		adjustUsage(bucket, -info.Size(), -1)
+	}
+	invalidateImageCache(bucket, key)
+	if err := fs.MetaService.Delete(bucket, key); err != nil {
+		logger.Warn("ObjectMeta", zap.String("bucket", bucket), zap.String("key", key), zap.Error(err))
+	}
+	return nil
+}
+
+// RenameFile 重命名存储桶内的文件,发布原文件删除及新文件创建事件
+func (fs *FileService) RenameFile(bucket, from, to string) error {
+	unlockBucket := bucketLocks.RLock(bucket)
+	defer unlockBucket()
+
+	// bucket需在配置文件中存在
+	bucketInfo, err := fs.BucketService.FindBucketInfo(bucket)
+	if err != nil {
+		return err
+	}
+	if from, err = cleanObjectKey(from); err != nil {
+		return err
+	}
+	if to, err = cleanObjectKey(to); err != nil {
+		return err
+	}
+	if from == to {
+		return errors.New("新文件名不能与原文件名相同")
+	}
+
+	// 同时锁定原文件及新文件,按键排序加锁避免死锁
+	unlock := objectLocks.LockAll(objectLockKey(bucket, from), objectLockKey(bucket, to))
+	defer unlock()
+
+	if err := fs.Storage.Rename(fs.getFilePath(bucket, from), fs.getFilePath(bucket, to)); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("文件已存在: %s", to)
+		}
+		return err
+	}
+	if err := fs.MetaService.Rename(bucket, from, to); err != nil {
+		logger.Warn("ObjectMeta", zap.String("bucket", bucket), zap.String("key", to), zap.Error(err))
+	}
+	invalidateImageCache(bucket, from)
+
+	var size int64
+	if info, err := fs.Storage.GetFileInfo(fs.getFilePath(bucket, to)); err == nil {
+		size = info.FileSize
+	}
+	fs.WebhookService.PublishQuietly(event_type.OBJECT_REMOVED, *bucketInfo, from, 0)
+	fs.WebhookService.PublishQuietly(event_type.OBJECT_CREATED, *bucketInfo, to, size)
+	return nil
 }
 
 // FileExists 检查指定存储桶和文件名的文件是否存在
 func (fs *FileService) FileExists(bucket, filename string) (bool, error) {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	filename, err := fs.resolveObject(bucket, filename)
+	if err != nil {
+		return false, err
+	}
+	unlock := objectLocks.RLock(objectLockKey(bucket, filename))
+	defer unlock()
 
 	filePath := fs.getFilePath(bucket, filename) // 获取文件路径
-	_, err := fs.Storage.Load(filePath)          // 尝试加载文件内容
+	_, err = fs.Storage.GetFileInfo(filePath)    // 获取文件信息,不打开文件
 
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -132,8 +447,12 @@ func (fs *FileService) FileExists(bucket, filename string) (bool, error) {
 
 // GetFileSize 获取指定存储桶和文件名的文件大小
 func (fs *FileService) GetFileSize(bucket, filename string) (int64, error) {
-	fs.mu.Lock()         // 加锁以保护文件操作
-	defer fs.mu.Unlock() // 延迟释放锁
+	filename, err := fs.resolveObject(bucket, filename)
+	if err != nil {
+		return 0, err
+	}
+	unlock := objectLocks.RLock(objectLockKey(bucket, filename))
+	defer unlock()
 
 	filePath := fs.getFilePath(bucket, filename)  // 获取文件路径
 	info, err := fs.Storage.GetFileInfo(filePath) // 获取文件信息
@@ -145,6 +464,186 @@ func (fs *FileService) GetFileSize(bucket, filename string) (int64, error) {
 	return info.FileSize, nil // 返回文件大小
 }
 
+// ResolveArchiveKeys 解析需要打包的文件列表,prefix 与 keys 可同时指定,结果去重
+func (fs *FileService) ResolveArchiveKeys(bucket, prefix string, keys []string) ([]string, error) {
+	// bucket需在配置文件中存在
+	_, err := fs.BucketService.FindBucketInfo(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+
+	if prefix != "" {
+		prefix = strings.TrimPrefix(prefix, "/")
+		// 只遍历前缀所在的目录,减少扫描范围
+		dir := ""
+		if i := strings.LastIndex(prefix, "/"); i >= 0 {
+			if dir, err = cleanObjectKey(prefix[:i]); err != nil {
+				return nil, err
+			}
+			prefix = dir + prefix[i:]
+		}
+		files, err := fs.Storage.ListFilesUnder(fs.getFilePath(bucket, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			key := path.Join(dir, file)
+			if strings.HasPrefix(key, prefix) && !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		key, err := cleanObjectKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			continue
+		}
+		file, _, err := fs.Storage.Open(fs.getFilePath(bucket, key))
+		if err != nil {
+			return nil, fmt.Errorf("文件不存在: %s", key)
+		}
+		file.Close()
+		seen[key] = true
+		result = append(result, key)
+	}
+
+	return result, nil
+}
+
+// WriteArchive 将存储桶中的文件逐个打包写入 w,不在内存中缓存整个压缩包
+func (fs *FileService) WriteArchive(w io.Writer, format, bucket string, keys []string) error {
+	aw, err := filesystem.NewArchiveWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := fs.addArchiveEntry(aw, bucket, key); err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// addArchiveEntry 写入单个文件,仅在打开文件时持有锁,避免长时间阻塞其他文件操作
+func (fs *FileService) addArchiveEntry(aw filesystem.ArchiveWriter, bucket, key string) error {
+	unlock := objectLocks.RLock(objectLockKey(bucket, key))
+	file, info, err := fs.Storage.Open(fs.getFilePath(bucket, key))
+	unlock()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return aw.AddFile(key, info.Size(), info.ModTime(), file)
+}
+
+// BucketUsage 统计各存储桶占用的磁盘空间(字节)
+func (fs *FileService) BucketUsage() (map[string]int64, error) {
+	buckets, err := fs.BucketService.GetBucketList()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]int64)
+	for _, bucket := range buckets {
+		var size int64
+		root := filepath.Join(fs.Storage.BaseDir, fs.getFilePath(bucket.Name, ""))
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				// 存储桶目录尚未创建时视为空
+				if os.IsNotExist(err) && path == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if !d.IsDir() {
+				if info, err := d.Info(); err == nil {
+					size += info.Size()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage[bucket.Name] = size
+	}
+	return usage, nil
+}
+
+// countingReader 统计已读取字节数的 Reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// PublicURL 返回文件的公开访问地址,形如 {app.url}/storage/{bucket}/{key}
+func (fs *FileService) PublicURL(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(config.Get("app.url"), "/") + "/storage/" + url.PathEscape(bucket) + "/" + strings.Join(segments, "/")
+}
+
+// ResolveStoragePath 从 /storage/{bucket}/{key} 形式的路径解析存储桶及文件名
+// 兼容旧地址携带的 bucket 参数,参数与路径中的存储桶不一致时返回错误;文件名为空时返回空字符串
+func (fs *FileService) ResolveStoragePath(urlPath, queryBucket string) (bucket, key string, err error) {
+	rest := strings.TrimPrefix(urlPath, "/storage/")
+	if rest == urlPath {
+		return "", "", errors.New("路径不匹配")
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", errors.New("bucket不能为空")
+	}
+	if queryBucket != "" && queryBucket != bucket {
+		return "", "", errors.New("bucket参数与路径不一致")
+	}
+	// 以 / 为根清理路径,去除 .. 后转为存储桶内的相对路径,与其他接口使用相同的规范化规则
+	if strings.TrimPrefix(path.Clean("/"+key), "/") == "" {
+		return bucket, "", nil
+	}
+	key, err = cleanObjectKey(key)
+	return bucket, key, err
+}
+
+// resolveObject 确认存储桶存在并规范化对象键,按文件名访问文件的方法均先经此处,
+// 避免不存在的存储桶名称或未规范化的文件名拼接出存储桶目录以外的路径
+func (fs *FileService) resolveObject(bucket, key string) (string, error) {
+	if _, err := fs.BucketService.FindBucketInfo(bucket); err != nil {
+		return "", err
+	}
+	return cleanObjectKey(key)
+}
+
+// cleanObjectKey 规范化对象键,去除开头的 / 及 ..,保证路径不会越出存储桶目录
+func cleanObjectKey(key string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("%w: 文件名不能为空", ErrInvalidObjectKey)
+	}
+	// 该前缀保留给写入中的临时文件,否则文件不会出现在列表中,并会被一致性检查当作残留文件清理
+	if filesystem.IsTempFile(cleaned) {
+		return "", fmt.Errorf("%w: 文件名不能以 %s 开头", ErrInvalidObjectKey, filesystem.TempFilePrefix)
+	}
+	return cleaned, nil
+}
+
 // // getFilePath 根据环境和存储桶名称生成文件路径
 // func (fs *FileService) getFilePath(bucket, filename string) string {
 // 	basePath := BasePath // 默认使用基础路径
@@ -158,6 +657,11 @@ func (fs *FileService) GetFileSize(bucket, filename string) (int64, error) {
 
 // getFilePath 根据环境和存储桶名称生成文件路径
 func (fs *FileService) getFilePath(bucket, filename string) string {
+	return storagePath(bucket, filename)
+}
+
+// storagePath 根据环境和存储桶名称生成文件路径,供不持有 FileService 的服务使用
+func storagePath(bucket, filename string) string {
 	basePath := BasePath // 默认使用基础路径
 
 	// 如果不是生产环境，使用临时路径
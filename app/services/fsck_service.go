@@ -0,0 +1,366 @@
+/*
+ * @PackageName: services
+ * @FileName: fsck_service.go
+ * @Description: 数据一致性检查服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:24:09
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:24:09
+ */
+
+package services
+
+import (
+	"easy_dfs/app/enum/access_policy"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/bucketname"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/filesystem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 可修复的问题类型
+const (
+	FsckOrphanBucketDir  = "orphan_bucket_dir"  // 存储目录没有对应的存储桶配置
+	FsckMissingBucketDir = "missing_bucket_dir" // 存储桶配置没有对应的存储目录
+	FsckOrphanMetadata   = "orphan_metadata"    // 元数据没有对应的存储桶或文件
+	FsckStaleTempFile    = "stale_temp_file"    // 中断的写入遗留的临时文件
+	FsckStrayFile        = "stray_file"         // 存储目录下不属于任何存储桶的文件
+)
+
+// 修复方式
+const (
+	FsckActionAdopt      = "adopt"      // 补全缺失的一方:为目录创建存储桶配置,或为存储桶创建目录
+	FsckActionQuarantine = "quarantine" // 移动到隔离目录,确认无用后再手动删除
+	FsckActionDelete     = "delete"     // 直接删除
+)
+
+// fsckActions 各问题类型可用的修复方式
+var fsckActions = map[string][]string{
+	FsckOrphanBucketDir:  {FsckActionAdopt, FsckActionQuarantine, FsckActionDelete},
+	FsckMissingBucketDir: {FsckActionAdopt, FsckActionDelete},
+	FsckOrphanMetadata:   {FsckActionQuarantine, FsckActionDelete},
+	FsckStaleTempFile:    {FsckActionDelete},
+	FsckStrayFile:        {FsckActionQuarantine, FsckActionDelete},
+}
+
+// fsckTempFileAge 临时文件超过该时长未修改才视为遗留,避免误删写入中的文件
+const fsckTempFileAge = time.Hour
+
+// ReconcileOptions 修复选项
+type ReconcileOptions struct {
+	Actions map[string]string // 问题类型到修复方式的映射,未指定的问题类型不处理
+	Targets []string          // 只处理这些问题对象,为空时处理全部
+	DryRun  bool              // 只列出将执行的操作,不做修改
+}
+
+// FsckService 检查配置文件与存储目录是否一致,并按指定的方式修复
+type FsckService struct {
+	BucketService    BucketService
+	AccessKeyService AccessKeyService
+	WebhookService   WebhookService
+	MetaService      ObjectMetaService
+}
+
+// getStorageDir 根据应用环境返回文件存储目录
+func (fcs *FsckService) getStorageDir() string {
+	storageDir := "storage"
+	if config.Get("app.env") != "prod" {
+		storageDir = "tmp/storage"
+	}
+	return storageDir
+}
+
+// getQuarantineDir 根据应用环境返回隔离目录
+func (fcs *FsckService) getQuarantineDir() string {
+	quarantineDir := "quarantine"
+	if config.Get("app.env") != "prod" {
+		quarantineDir = "tmp/quarantine"
+	}
+	return quarantineDir
+}
+
+// Check 执行检查,返回发现的问题列表
+func (fcs *FsckService) Check() ([]model.FsckIssue, error) {
+	issues := make([]model.FsckIssue, 0)
+
+	// 配置文件可正常解析
+	buckets, err := fcs.BucketService.GetBucketList()
+	if err != nil {
+		issues = append(issues, model.FsckIssue{Type: "config", Target: fcs.BucketService.getConfigPath(), Message: err.Error()})
+	}
+	accessKeys, err := fcs.AccessKeyService.GetAccessKeyList()
+	if err != nil {
+		issues = append(issues, model.FsckIssue{Type: "config", Target: fcs.AccessKeyService.getConfigPath(), Message: err.Error()})
+	}
+	if _, err := fcs.WebhookService.ListDeliveries("", "", 1); err != nil {
+		issues = append(issues, model.FsckIssue{Type: "config", Target: fcs.WebhookService.getConfigPath(), Message: err.Error()})
+	}
+
+	// 存储桶及访问密钥不能重复
+	bucketNames := make(map[string]bool)
+	var bucketOrder []string // 按配置顺序检查存储桶,结果顺序稳定
+	for _, b := range buckets {
+		if bucketNames[b.Name] {
+			issues = append(issues, model.FsckIssue{Type: "duplicate_bucket", Target: b.Name, Message: "存储桶配置重复"})
+			continue
+		}
+		bucketNames[b.Name] = true
+		bucketOrder = append(bucketOrder, b.Name)
+	}
+	keys := make(map[string]bool)
+	for _, k := range accessKeys {
+		if keys[k.AccessKey] {
+			issues = append(issues, model.FsckIssue{Type: "duplicate_access_key", Target: k.Name, Message: "访问密钥重复"})
+		}
+		keys[k.AccessKey] = true
+	}
+
+	// 存储目录下的每个目录都应有对应的存储桶配置
+	entries, err := os.ReadDir(fcs.getStorageDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	bucketDirs := make(map[string]bool)
+	for _, entry := range entries {
+		target := filepath.Join(fcs.getStorageDir(), entry.Name())
+		if !entry.IsDir() {
+			issues = append(issues, fcs.issue(model.FsckIssue{Type: FsckStrayFile, Target: target, Message: "存储目录下存在不属于任何存储桶的文件"}))
+			continue
+		}
+		bucketDirs[entry.Name()] = true
+		// 强制删除中的存储桶配置已删除,文件由删除任务处理
+		if !bucketNames[entry.Name()] && !bucketDeleting(entry.Name()) {
+			issues = append(issues, fcs.issue(model.FsckIssue{Type: FsckOrphanBucketDir, Target: target, Bucket: entry.Name(), Message: "存储桶目录没有对应的存储桶配置"}))
+		}
+	}
+
+	// 每个存储桶都应有存储目录
+	for _, name := range bucketOrder {
+		if !bucketDirs[name] {
+			issues = append(issues, fcs.issue(model.FsckIssue{Type: FsckMissingBucketDir, Target: storagePath(name, ""), Bucket: name, Message: "存储桶没有对应的存储目录"}))
+		}
+	}
+
+	// 元数据目录应属于某个存储桶,随存储桶目录一同处理的除外
+	metaEntries, err := os.ReadDir(fcs.MetaService.getMetaDir(""))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range metaEntries {
+		if !entry.IsDir() || bucketNames[entry.Name()] || bucketDirs[entry.Name()] || bucketDeleting(entry.Name()) {
+			continue
+		}
+		issues = append(issues, fcs.issue(model.FsckIssue{Type: FsckOrphanMetadata, Target: fcs.MetaService.getMetaDir(entry.Name()), Bucket: entry.Name(), Message: "元数据目录没有对应的存储桶"}))
+	}
+
+	// 存储桶内的元数据应有对应的文件,且不应有遗留的临时文件
+	for _, name := range bucketOrder {
+		bucketIssues, err := fcs.checkBucket(name)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, bucketIssues...)
+	}
+	return issues, nil
+}
+
+// checkBucket 检查存储桶内没有对应文件的元数据及遗留的临时文件
+func (fcs *FsckService) checkBucket(bucket string) ([]model.FsckIssue, error) {
+	var issues []model.FsckIssue
+	metaKeys, err := fcs.MetaService.List(bucket)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range metaKeys {
+		if _, err := os.Stat(storagePath(bucket, key)); errors.Is(err, os.ErrNotExist) {
+			issues = append(issues, fcs.issue(model.FsckIssue{Type: FsckOrphanMetadata, Target: fcs.MetaService.getMetaPath(bucket, key), Bucket: bucket, Key: key, Message: "元数据没有对应的文件"}))
+		}
+	}
+
+	deadline := time.Now().Add(-fsckTempFileAge)
+	for _, root := range []string{storagePath(bucket, ""), fcs.MetaService.getMetaDir(bucket)} {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() || !filesystem.IsTempFile(path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.ModTime().After(deadline) {
+				return nil
+			}
+			issues = append(issues, fcs.issue(model.FsckIssue{Type: FsckStaleTempFile, Target: path, Bucket: bucket, Message: "中断的写入遗留的临时文件"}))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// issue 填充问题可用的修复方式
+func (fcs *FsckService) issue(issue model.FsckIssue) model.FsckIssue {
+	issue.Actions = fsckActions[issue.Type]
+	return issue
+}
+
+// ValidateFsckActions 校验修复方式,每种问题类型只能使用其支持的修复方式
+func ValidateFsckActions(actions map[string]string) error {
+	if len(actions) == 0 {
+		return errors.New("未指定任何问题类型的修复方式")
+	}
+	for issueType, action := range actions {
+		allowed, ok := fsckActions[issueType]
+		if !ok {
+			return fmt.Errorf("问题类型 %s 不支持自动修复", issueType)
+		}
+		if !containsString(allowed, action) {
+			return fmt.Errorf("问题类型 %s 不支持 %s,可用的修复方式:%s", issueType, action, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// Reconcile 重新检查并按指定的方式修复问题,每个问题独立执行,单个失败不影响其余问题
+func (fcs *FsckService) Reconcile(opts ReconcileOptions) ([]model.FsckResult, error) {
+	if err := ValidateFsckActions(opts.Actions); err != nil {
+		return nil, err
+	}
+
+	issues, err := fcs.Check()
+	if err != nil {
+		return nil, err
+	}
+	quarantineDir := filepath.Join(fcs.getQuarantineDir(), app.TimenowInTimezone().Format("20060102-150405"))
+	results := make([]model.FsckResult, 0)
+	for _, issue := range issues {
+		action, ok := opts.Actions[issue.Type]
+		if !ok || (len(opts.Targets) > 0 && !containsString(opts.Targets, issue.Target)) {
+			continue
+		}
+		result := model.FsckResult{FsckIssue: issue, Action: action}
+		if !opts.DryRun {
+			destination, err := fcs.apply(issue, action, quarantineDir)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Applied = true
+				result.Destination = destination
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// apply 执行单个问题的修复,执行前再次确认问题仍然存在,隔离时返回移动到的位置
+func (fcs *FsckService) apply(issue model.FsckIssue, action, quarantineDir string) (string, error) {
+	switch issue.Type {
+	case FsckOrphanBucketDir:
+		if _, err := fcs.BucketService.FindBucketInfo(issue.Bucket); err == nil {
+			return "", errors.New("存储桶配置已存在,无需处理")
+		}
+		switch action {
+		case FsckActionAdopt:
+			if err := bucketname.Validate(issue.Bucket); err != nil {
+				return "", fmt.Errorf("目录名称不能用作存储桶名称,请先重命名目录: %w", err)
+			}
+			return "", fcs.BucketService.CreateBucket(model.BucketInfo{Name: issue.Bucket, AccessPolicy: access_policy.PRIVATE})
+		case FsckActionQuarantine:
+			// 元数据随存储桶目录一同隔离
+			if err := fcs.quarantine(fcs.MetaService.getMetaDir(issue.Bucket), quarantineDir, MetaBasePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+			return fcs.quarantinePath(issue.Target, quarantineDir, BasePath), fcs.quarantine(issue.Target, quarantineDir, BasePath)
+		default:
+			if err := os.RemoveAll(fcs.MetaService.getMetaDir(issue.Bucket)); err != nil {
+				return "", err
+			}
+			return "", os.RemoveAll(issue.Target)
+		}
+
+	case FsckMissingBucketDir:
+		if action == FsckActionAdopt {
+			return "", os.MkdirAll(issue.Target, os.ModePerm)
+		}
+		if _, err := os.Stat(issue.Target); err == nil {
+			return "", errors.New("存储目录已存在,无需处理")
+		}
+		return "", fcs.BucketService.DeleteBucket(issue.Bucket)
+
+	case FsckOrphanMetadata:
+		if issue.Key == "" {
+			if _, err := fcs.BucketService.FindBucketInfo(issue.Bucket); err == nil {
+				return "", errors.New("存储桶配置已存在,无需处理")
+			}
+		} else {
+			// 与上传互斥,避免处理期间文件被重新上传
+			unlock := objectLocks.Lock(objectLockKey(issue.Bucket, issue.Key))
+			defer unlock()
+			if _, err := os.Stat(storagePath(issue.Bucket, issue.Key)); err == nil {
+				return "", errors.New("文件已存在,无需处理")
+			}
+		}
+		if action == FsckActionQuarantine {
+			return fcs.quarantinePath(issue.Target, quarantineDir, MetaBasePath), fcs.quarantine(issue.Target, quarantineDir, MetaBasePath)
+		}
+		return "", os.RemoveAll(issue.Target)
+
+	case FsckStaleTempFile:
+		return "", os.Remove(issue.Target)
+
+	case FsckStrayFile:
+		if action == FsckActionQuarantine {
+			return fcs.quarantinePath(issue.Target, quarantineDir, BasePath), fcs.quarantine(issue.Target, quarantineDir, BasePath)
+		}
+		return "", os.Remove(issue.Target)
+	}
+	return "", fmt.Errorf("问题类型 %s 不支持自动修复", issue.Type)
+}
+
+// quarantinePath 隔离后的位置,保留文件相对存储目录或元数据目录的路径
+func (fcs *FsckService) quarantinePath(path, quarantineDir, kind string) string {
+	root := fcs.getStorageDir()
+	if kind == MetaBasePath {
+		root = fcs.MetaService.getMetaDir("")
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	return filepath.Join(quarantineDir, kind, rel)
+}
+
+// quarantine 将文件或目录移动到隔离目录
+func (fcs *FsckService) quarantine(path, quarantineDir, kind string) error {
+	if _, err := os.Lstat(path); err != nil {
+		return err
+	}
+	destination := fcs.quarantinePath(path, quarantineDir, kind)
+	if err := os.MkdirAll(filepath.Dir(destination), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(path, destination)
+}
+
+// containsString 切片中是否包含指定的字符串
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
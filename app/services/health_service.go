@@ -0,0 +1,91 @@
+/*
+ * @PackageName: services
+ * @FileName: health_service.go
+ * @Description: 健康检查服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 16:40:27
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 16:40:27
+ */
+
+package services
+
+import (
+	"easy_dfs/pkg/config"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// 服务是否正在退出,退出期间就绪检查失败
+var draining atomic.Bool
+
+// HealthService 健康检查服务
+type HealthService struct {
+	BucketService    BucketService
+	AccessKeyService AccessKeyService
+}
+
+// SetDraining 标记服务是否正在退出
+func (hs *HealthService) SetDraining(on bool) {
+	draining.Store(on)
+}
+
+// IsDraining 服务是否正在退出
+func (hs *HealthService) IsDraining() bool {
+	return draining.Load()
+}
+
+// getStorageDir 根据应用环境返回文件存储目录
+func (hs *HealthService) getStorageDir() string {
+	storageDir := "storage"
+	if config.Get("app.env") != "prod" {
+		storageDir = "tmp/storage"
+	}
+	return storageDir
+}
+
+// CheckReady 就绪检查:存储目录可写、配置文件可正常加载,返回各项检查结果
+func (hs *HealthService) CheckReady() (map[string]string, error) {
+	checks := map[string]string{
+		"storage":    "ok",
+		"bucket":     "ok",
+		"access_key": "ok",
+	}
+	var failed error
+
+	if hs.IsDraining() {
+		failed = errors.New("服务正在退出")
+	}
+	if err := hs.checkStorageWritable(); err != nil {
+		checks["storage"] = err.Error()
+		failed = errors.New("存储目录不可写")
+	}
+	if _, err := hs.BucketService.GetBucketList(); err != nil {
+		checks["bucket"] = err.Error()
+		failed = errors.New("存储桶配置加载失败")
+	}
+	if _, err := hs.AccessKeyService.GetAccessKeyList(); err != nil {
+		checks["access_key"] = err.Error()
+		failed = errors.New("访问密钥配置加载失败")
+	}
+	return checks, failed
+}
+
+// checkStorageWritable 在存储目录中创建并删除临时文件,确认目录可写
+func (hs *HealthService) checkStorageWritable() error {
+	file, err := os.CreateTemp(hs.getStorageDir(), ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	_, err = file.WriteString("ok")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(name); err == nil && removeErr != nil {
+		err = fmt.Errorf("remove %s: %w", name, removeErr)
+	}
+	return err
+}
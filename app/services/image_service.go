@@ -0,0 +1,236 @@
+/*
+ * @PackageName: services
+ * @FileName: image_service.go
+ * @Description: 图片处理服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 13:31:09
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 13:31:09
+ */
+
+package services
+
+import (
+	"crypto/sha1"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/imageproc"
+	"easy_dfs/pkg/logger"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImageService 图片处理服务,处理结果缓存在磁盘上
+type ImageService struct {
+}
+
+// 图片缓存占用的空间,首次写入缓存时统计,之后随写入及清除调整。
+// 超出 image.cache_max_size 时按修改时间(命中缓存时更新)淘汰最久未使用的缓存
+var (
+	imageCacheMu   sync.Mutex
+	imageCacheSize int64
+	imageCacheRoot string // 统计时的缓存目录,为空表示尚未统计或需要重新统计
+)
+
+// imageCacheTmpSuffix 写入中的缓存文件的后缀,统计及淘汰时跳过
+const imageCacheTmpSuffix = ".tmp"
+
+// getCacheDir 根据应用环境返回图片缓存目录
+func (is *ImageService) getCacheDir() string {
+	cacheDir := "cache/image"
+	if config.Get("app.env") != "prod" {
+		cacheDir = "tmp/cache/image"
+	}
+	return cacheDir
+}
+
+// Enabled 是否开启图片处理
+func (is *ImageService) Enabled() bool {
+	return config.GetBool("image.enabled", true)
+}
+
+// Limits 图片处理限制
+func (is *ImageService) Limits() imageproc.Limits {
+	return imageproc.Limits{
+		MaxWidth:        config.GetInt("image.max_width", 4096),
+		MaxHeight:       config.GetInt("image.max_height", 4096),
+		MaxSourcePixels: config.GetInt("image.max_source_pixels", 50000000),
+		DefaultQuality:  config.GetInt("image.default_quality", 80),
+	}
+}
+
+// keyCacheDir 文件的缓存目录,按文件名的摘要区分,覆盖、删除或重命名文件时整个目录一并清除
+func (is *ImageService) keyCacheDir(bucket, key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(is.getCacheDir(), bucket, hex.EncodeToString(sum[:]))
+}
+
+// maxCacheSize 图片缓存的最大空间(字节),0 表示不限制
+func (is *ImageService) maxCacheSize() int64 {
+	return int64(config.GetInt("image.cache_max_size", 1024)) * 1024 * 1024
+}
+
+// ProcessImage 处理图片并返回缓存文件路径,相同原图(按大小和修改时间区分)和参数只处理一次
+func (is *ImageService) ProcessImage(bucket, filename string, src *os.File, srcInfo os.FileInfo, opts imageproc.Options) (string, error) {
+	// 缓存文件名由原图大小、修改时间和处理参数决定,原图被覆盖后自动失效
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%d|%s", srcInfo.Size(), srcInfo.ModTime().UnixNano(), opts.CacheKey())))
+	hash := hex.EncodeToString(sum[:])
+	cachePath := filepath.Join(is.keyCacheDir(bucket, filename), hash+"."+opts.Format)
+
+	if _, err := os.Stat(cachePath); err == nil {
+		// 更新修改时间,淘汰缓存时据此判断最近是否使用
+		now := time.Now()
+		_ = os.Chtimes(cachePath, now, now)
+		return cachePath, nil
+	}
+
+	if err := os.MkdirAll(is.getCacheDir(), os.ModePerm); err != nil {
+		return "", err
+	}
+	// 先写入缓存根目录下的临时文件再移动,避免并发请求读到未写完的缓存,
+	// 处理期间文件的缓存目录被清除也不影响写入
+	tmpFile, err := os.CreateTemp(is.getCacheDir(), hash+".*"+imageCacheTmpSuffix)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = imageproc.Process(src, tmpFile, opts, is.Limits())
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := is.store(tmpFile.Name(), cachePath); err != nil {
+		return "", err
+	}
+	return cachePath, nil
+}
+
+// store 将处理结果移动到缓存路径并计入缓存空间,超出限制时淘汰最久未使用的缓存
+func (is *ImageService) store(tmpPath, cachePath string) error {
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	imageCacheMu.Lock()
+	defer imageCacheMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err != nil {
+		return err
+	}
+	// 并发请求可能已写入相同的缓存,替换时不重复计算
+	var oldSize int64
+	if old, err := os.Stat(cachePath); err == nil {
+		oldSize = old.Size()
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
+		return err
+	}
+	imageCacheSize += info.Size() - oldSize
+
+	maxSize := is.maxCacheSize()
+	if maxSize <= 0 || (imageCacheRoot == is.getCacheDir() && imageCacheSize <= maxSize) {
+		return nil
+	}
+	return is.evict(maxSize, cachePath)
+}
+
+// imageCacheEntry 缓存文件
+type imageCacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// evict 重新统计缓存空间,超出限制时按修改时间从早到晚删除缓存,直至低于限制的 90%;
+// 不删除刚写入的缓存 keep。调用方需持有 imageCacheMu
+func (is *ImageService) evict(maxSize int64, keep string) error {
+	var entries []imageCacheEntry
+	var total int64
+	err := filepath.WalkDir(is.getCacheDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, imageCacheTmpSuffix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// 统计期间被删除的文件不计
+			return nil
+		}
+		total += info.Size()
+		if path != keep {
+			entries = append(entries, imageCacheEntry{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	imageCacheSize, imageCacheRoot = total, is.getCacheDir()
+	if imageCacheSize <= maxSize {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+	target := maxSize / 10 * 9
+	for _, entry := range entries {
+		if imageCacheSize <= target {
+			break
+		}
+		if err := os.Remove(entry.path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("ImageCache", zap.String("path", entry.path), zap.Error(err))
+			continue
+		}
+		imageCacheSize -= entry.size
+	}
+	return nil
+}
+
+// invalidateImageCache 清除文件的图片缓存,覆盖、删除或重命名文件时调用
+func invalidateImageCache(bucket, key string) {
+	removeImageCacheDir(new(ImageService).keyCacheDir(bucket, key))
+}
+
+// removeBucketImageCache 清除存储桶的图片缓存,删除或重命名存储桶时调用
+func removeBucketImageCache(bucket string) {
+	removeImageCacheDir(filepath.Join(new(ImageService).getCacheDir(), bucket))
+}
+
+// removeImageCacheDir 删除缓存目录并从缓存空间中扣除,目录不存在时直接返回
+func removeImageCacheDir(dir string) {
+	imageCacheMu.Lock()
+	defer imageCacheMu.Unlock()
+
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	var size int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Warn("ImageCache", zap.String("dir", dir), zap.Error(err))
+		// 部分文件可能已删除,下次写入缓存时重新统计
+		imageCacheRoot = ""
+		return
+	}
+	imageCacheSize -= size
+}
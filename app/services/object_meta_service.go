@@ -0,0 +1,115 @@
+/*
+ * @PackageName: services
+ * @FileName: object_meta_service.go
+ * @Description: 文件元数据服务,元数据以 JSON 文件保存在存储目录之外,不会出现在文件列表中
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 00:16:05
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 00:16:05
+ */
+
+package services
+
+import (
+	"easy_dfs/model"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/filesystem"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MetaBasePath 保存文件元数据的基础路径
+const MetaBasePath = "meta/"
+
+// ObjectMetaService 文件元数据服务,调用方负责与文件操作一同加锁
+type ObjectMetaService struct {
+}
+
+// getMetaDir 根据环境生成存储桶元数据目录的路径
+func (ms *ObjectMetaService) getMetaDir(bucket string) string {
+	basePath := MetaBasePath
+	if config.Get("app.env") != "prod" {
+		basePath = filepath.Join("tmp", MetaBasePath)
+	}
+	return filepath.Join(basePath, bucket)
+}
+
+// getMetaPath 生成文件元数据的路径
+func (ms *ObjectMetaService) getMetaPath(bucket, key string) string {
+	return filepath.Join(ms.getMetaDir(bucket), key+".json")
+}
+
+// Get 获取文件元数据,没有元数据时返回 nil
+func (ms *ObjectMetaService) Get(bucket, key string) (*model.ObjectMeta, error) {
+	data, err := os.ReadFile(ms.getMetaPath(bucket, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta model.ObjectMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// Save 保存文件元数据
+func (ms *ObjectMetaService) Save(bucket, key string, meta model.ObjectMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return filesystem.WriteFileAtomic(ms.getMetaPath(bucket, key), data, 0644)
+}
+
+// Delete 删除文件元数据,不存在时忽略
+func (ms *ObjectMetaService) Delete(bucket, key string) error {
+	err := os.Remove(ms.getMetaPath(bucket, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Rename 随文件重命名移动元数据,不存在时忽略
+func (ms *ObjectMetaService) Rename(bucket, from, to string) error {
+	target := ms.getMetaPath(bucket, to)
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	err := os.Rename(ms.getMetaPath(bucket, from), target)
+	if errors.Is(err, os.ErrNotExist) {
+		return ms.Delete(bucket, to)
+	}
+	return err
+}
+
+// List 列出存储桶下所有有元数据的文件名
+func (ms *ObjectMetaService) List(bucket string) ([]string, error) {
+	root := ms.getMetaDir(bucket)
+	var keys []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// 存储桶没有任何元数据时视为空
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filesystem.IsTempFile(path) || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, strings.TrimSuffix(path, ".json"))
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	return keys, err
+}
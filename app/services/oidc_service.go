@@ -0,0 +1,287 @@
+/*
+ * @PackageName: services
+ * @FileName: oidc_service.go
+ * @Description: OIDC 单点登录服务,按用户组映射角色及被授权的存储桶
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 06:51:37
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 06:51:37
+ */
+
+package services
+
+import (
+	"context"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/model"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/oidc"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 单点登录的错误
+var (
+	ErrOidcDisabled      = errors.New("未启用单点登录")
+	ErrOidcState         = errors.New("登录请求无效或已过期,请重新登录")
+	ErrOidcNoRole        = errors.New("所在用户组未被授权访问")
+	ErrOidcLocalUser     = errors.New("已存在同名的本地用户,不能通过单点登录")
+	ErrOidcUsernameTaken = errors.New("用户名已被其他单点登录账号使用,请联系管理员")
+	ErrOidcSubject       = errors.New("ID 令牌中缺少 iss 或 sub 声明")
+	ErrOidcRedirect      = errors.New("跳转地址只能为本站路径")
+)
+
+// 通过单点登录创建的用户的身份来源
+const oidcProvider = "oidc"
+
+const (
+	// 跳转到身份提供方后,需在此时间内完成登录
+	oidcLoginTTL = 10 * time.Minute
+	// 最多保留的未完成登录请求数
+	maxOidcPending = 10000
+)
+
+// OidcCallback 单点登录回调的处理结果
+type OidcCallback struct {
+	// 发起登录时指定的跳转地址,登录请求无效时为空
+	Redirect string
+	// 身份提供方返回的用户名(用户名声明),只用于显示及记录,校验 ID 令牌失败时为空
+	Username string
+	// 登录结果,登录失败时为空
+	Result *model.LoginResult
+}
+
+// oidcLogin 未完成的登录请求
+type oidcLogin struct {
+	nonce     string
+	verifier  string
+	redirect  string
+	expiresAt time.Time
+}
+
+var (
+	// 未完成的登录请求,按 state 索引,保存在内存中,多实例部署时回调需转发到发起登录的实例
+	oidcPendingMu sync.Mutex
+	oidcPending   = make(map[string]oidcLogin)
+	// 身份提供方,缓存其配置及公钥,配置变化后重新创建
+	oidcProviderMu  sync.Mutex
+	oidcProviderKey string
+	oidcProviderVal *oidc.Provider
+)
+
+// OidcService 单点登录服务
+type OidcService struct {
+	UserService UserService
+}
+
+// Enabled 是否启用单点登录
+func (s *OidcService) Enabled() bool {
+	return config.GetBool("oidc.enabled") && config.Get("oidc.issuer") != "" && config.Get("oidc.client_id") != ""
+}
+
+// Begin 发起登录,返回身份提供方的登录地址;redirect 为登录成功后跳转的本站路径,为空时回调接口直接返回会话令牌
+func (s *OidcService) Begin(ctx context.Context, redirect string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrOidcDisabled
+	}
+	if redirect != "" && !IsLocalRedirect(redirect) {
+		return "", ErrOidcRedirect
+	}
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	authURL, err := s.provider().AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	oidcPendingMu.Lock()
+	defer oidcPendingMu.Unlock()
+	now := time.Now()
+	for k, v := range oidcPending {
+		if now.After(v.expiresAt) {
+			delete(oidcPending, k)
+		}
+	}
+	if len(oidcPending) >= maxOidcPending {
+		return "", errors.New("未完成的登录请求过多,请稍后再试")
+	}
+	oidcPending[state] = oidcLogin{nonce: nonce, verifier: verifier, redirect: redirect, expiresAt: now.Add(oidcLoginTTL)}
+	return authURL, nil
+}
+
+// PendingRedirect 返回登录请求的跳转地址并作废该请求,用于身份提供方返回错误时
+func (s *OidcService) PendingRedirect(state string) string {
+	login, ok := takeOidcLogin(state)
+	if !ok {
+		return ""
+	}
+	return login.redirect
+}
+
+// Complete 处理身份提供方的回调:换取并校验 ID 令牌,创建或更新用户并签发会话令牌,
+// 失败时返回的处理结果中同样包含跳转地址及已知的用户名
+func (s *OidcService) Complete(ctx context.Context, code, state string) (*OidcCallback, error) {
+	callback := &OidcCallback{}
+	if !s.Enabled() {
+		return callback, ErrOidcDisabled
+	}
+	login, ok := takeOidcLogin(state)
+	if !ok {
+		return callback, ErrOidcState
+	}
+	callback.Redirect = login.redirect
+
+	provider := s.provider()
+	idToken, err := provider.Exchange(ctx, code, login.verifier)
+	if err != nil {
+		return callback, err
+	}
+	claims, err := provider.VerifyIDToken(ctx, idToken, login.nonce, time.Now())
+	if err != nil {
+		return callback, err
+	}
+
+	usernameClaim := config.Get("oidc.username_claim", "preferred_username")
+	callback.Username = oidc.StringClaim(claims, usernameClaim)
+	if callback.Username == "" {
+		return callback, fmt.Errorf("ID 令牌中缺少 %s 声明", usernameClaim)
+	}
+	groups := oidc.StringsClaim(claims, config.Get("oidc.groups_claim", "groups"))
+	role, err := OidcRole(groups)
+	if err != nil {
+		return callback, err
+	}
+
+	issuer, _ := claims["iss"].(string)
+	subject, _ := claims["sub"].(string)
+	user, err := s.UserService.SyncOidcUser(issuer, subject, callback.Username, role, groups)
+	if err != nil {
+		return callback, err
+	}
+	callback.Result, err = s.UserService.issueToken(user)
+	return callback, err
+}
+
+// provider 返回当前配置对应的身份提供方
+func (s *OidcService) provider() *oidc.Provider {
+	redirectURL := config.Get("oidc.redirect_url")
+	if redirectURL == "" {
+		redirectURL = strings.TrimSuffix(config.Get("app.url"), "/") + "/auth/oidc/callback"
+	}
+	provider := &oidc.Provider{
+		Issuer:       config.Get("oidc.issuer"),
+		ClientID:     config.Get("oidc.client_id"),
+		ClientSecret: config.Get("oidc.client_secret"),
+		RedirectURL:  redirectURL,
+		Scopes:       strings.Fields(config.Get("oidc.scopes", "openid")),
+	}
+	key := strings.Join([]string{provider.Issuer, provider.ClientID, provider.ClientSecret, provider.RedirectURL, strings.Join(provider.Scopes, " ")}, "\n")
+
+	oidcProviderMu.Lock()
+	defer oidcProviderMu.Unlock()
+	if oidcProviderVal == nil || oidcProviderKey != key {
+		oidcProviderKey = key
+		oidcProviderVal = provider
+	}
+	return oidcProviderVal
+}
+
+// takeOidcLogin 取出并作废未过期的登录请求,每个 state 只能使用一次
+func takeOidcLogin(state string) (oidcLogin, bool) {
+	oidcPendingMu.Lock()
+	defer oidcPendingMu.Unlock()
+	login, ok := oidcPending[state]
+	if !ok {
+		return oidcLogin{}, false
+	}
+	delete(oidcPending, state)
+	if time.Now().After(login.expiresAt) {
+		return oidcLogin{}, false
+	}
+	return login, true
+}
+
+// OidcRole 按用户组映射角色,用户组名称不区分大小写;同时属于多个用户组时取权限最高的角色,都不属于时使用默认角色
+func OidcRole(groups []string) (string, error) {
+	mappings := []struct {
+		role string
+		path string
+	}{
+		{user_role.ADMIN, "oidc.admin_groups"},
+		{user_role.BUCKET_OWNER, "oidc.owner_groups"},
+		{user_role.READ_ONLY, "oidc.readonly_groups"},
+	}
+	for _, m := range mappings {
+		for _, group := range strings.Split(config.Get(m.path), ",") {
+			group = strings.TrimSpace(group)
+			for _, v := range groups {
+				if group != "" && strings.EqualFold(v, group) {
+					return m.role, nil
+				}
+			}
+		}
+	}
+	if role := config.Get("oidc.default_role"); user_role.IsValid(role) {
+		return role, nil
+	}
+	return "", ErrOidcNoRole
+}
+
+// groupBuckets 返回用户组被授权的存储桶;配置中的用户组名称不区分大小写
+func groupBuckets(groups []string) []string {
+	if len(groups) == 0 {
+		return nil
+	}
+	grants := make(map[string]string)
+	for group, buckets := range config.GetStringMapString("oidc.group_buckets") {
+		grants[strings.ToLower(group)] = buckets
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	var buckets []string
+	for _, group := range groups {
+		for _, bucket := range strings.Split(grants[strings.ToLower(group)], ",") {
+			if bucket = strings.TrimSpace(bucket); bucket != "" && !containsString(buckets, bucket) {
+				buckets = append(buckets, bucket)
+			}
+		}
+	}
+	return buckets
+}
+
+// IsLocalRedirect 是否为本站路径,避免登录后跳转到其他站点。
+// 浏览器会忽略地址中的制表符、换行并把 \ 视为 /,如 "/\t/evil.example" 会跳转到 evil.example,
+// 因此原始地址及解码后的路径中均不能包含控制字符、空白字符及 \
+func IsLocalRedirect(redirect string) bool {
+	if !strings.HasPrefix(redirect, "/") || containsUnsafeRedirectChar(redirect) {
+		return false
+	}
+	u, err := url.Parse(redirect)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil || u.Opaque != "" {
+		return false
+	}
+	return strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(u.Path, "//") && !containsUnsafeRedirectChar(u.Path)
+}
+
+// containsUnsafeRedirectChar 是否包含控制字符、空白字符或 \
+func containsUnsafeRedirectChar(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r == '\\' || unicode.IsControl(r) || unicode.IsSpace(r)
+	}) >= 0
+}
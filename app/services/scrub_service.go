@@ -0,0 +1,319 @@
+/*
+ * @PackageName: services
+ * @FileName: scrub_service.go
+ * @Description: 数据完整性巡检服务,定期重新读取文件并与上传时记录的摘要比对
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 02:21:37
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 02:21:37
+ */
+
+package services
+
+import (
+	"context"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/bandwidth"
+	"easy_dfs/pkg/checksum"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/filesystem"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/metrics"
+	"easy_dfs/pkg/utils/str_util"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 巡检触发方式
+const (
+	ScrubTriggerSchedule = "schedule"
+	ScrubTriggerManual   = "manual"
+)
+
+// 巡检状态
+const (
+	ScrubRunning   = "running"
+	ScrubCompleted = "completed"
+	ScrubFailed    = "failed"
+)
+
+// 巡检发现的问题类型
+const (
+	ScrubIssueCorrupted    = "corrupted"
+	ScrubIssueSizeMismatch = "size_mismatch"
+	ScrubIssueMissing      = "missing"
+	ScrubIssueReadError    = "read_error"
+)
+
+// ErrScrubRunning 已有巡检进行中
+var ErrScrubRunning = errors.New("已有巡检正在进行")
+
+var (
+	scrubMu        sync.Mutex
+	scrubCurrent   *model.ScrubReport // 进行中的巡检,为空表示没有进行中的巡检
+	scrubStartOnce sync.Once
+)
+
+// ScrubService 数据完整性巡检服务
+type ScrubService struct {
+	FileService FileService
+}
+
+// getReportPath 根据应用环境返回巡检报告的路径,只保留最近一次的报告
+func (ss *ScrubService) getReportPath() string {
+	reportPath := "config/scrub_report.json"
+	if config.Get("app.env") != "prod" {
+		reportPath = "tmp/config/scrub_report.json"
+	}
+	return reportPath
+}
+
+// ScheduleInterval 定期巡检的间隔,未开启定期巡检时返回 0
+func (ss *ScrubService) ScheduleInterval() time.Duration {
+	interval := time.Duration(config.GetInt("scrub.interval", 24)) * time.Hour
+	if !config.GetBool("scrub.enabled", true) || interval <= 0 {
+		return 0
+	}
+	return interval
+}
+
+// StartScheduler 启动定期巡检协程,未开启定期巡检时只响应手动触发
+func (ss *ScrubService) StartScheduler() {
+	scrubStartOnce.Do(func() {
+		go func() {
+			for {
+				interval := ss.ScheduleInterval()
+				if interval == 0 {
+					return
+				}
+				time.Sleep(interval)
+				report, err := ss.begin(ScrubTriggerSchedule)
+				if err != nil {
+					// 手动触发的巡检仍在进行,跳过本次
+					continue
+				}
+				ss.run(report)
+			}
+		}()
+	})
+}
+
+// Trigger 手动触发巡检,在后台执行,返回进行中的巡检报告
+func (ss *ScrubService) Trigger() (*model.ScrubReport, error) {
+	report, err := ss.begin(ScrubTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := snapshotReport(report)
+	go ss.run(report)
+	return snapshot, nil
+}
+
+// Report 返回进行中的巡检报告,没有进行中的巡检时返回最近一次的报告,从未巡检时返回 nil
+func (ss *ScrubService) Report() (*model.ScrubReport, error) {
+	scrubMu.Lock()
+	var current *model.ScrubReport
+	if scrubCurrent != nil {
+		current = snapshotReport(scrubCurrent)
+	}
+	scrubMu.Unlock()
+	if current != nil {
+		return current, nil
+	}
+
+	data, err := os.ReadFile(ss.getReportPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report model.ScrubReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// begin 创建巡检报告并标记为进行中,同一时间只允许一次巡检
+func (ss *ScrubService) begin(trigger string) (*model.ScrubReport, error) {
+	scrubMu.Lock()
+	defer scrubMu.Unlock()
+	if scrubCurrent != nil {
+		return nil, ErrScrubRunning
+	}
+	scrubCurrent = &model.ScrubReport{
+		ID:        str_util.SimpleUUID(),
+		Trigger:   trigger,
+		Status:    ScrubRunning,
+		StartedAt: app.TimenowInTimezone().Format(time.RFC3339),
+		Issues:    []model.ScrubIssue{},
+	}
+	return scrubCurrent, nil
+}
+
+// run 执行巡检并保存报告
+func (ss *ScrubService) run(report *model.ScrubReport) {
+	limiter := bandwidth.NewLimiter(int64(config.GetInt("scrub.rate", 10240)) * 1024)
+	throttle := bandwidth.NewThrottle(context.Background(), limiter)
+	err := ss.scan(report, throttle)
+
+	scrubMu.Lock()
+	report.FinishedAt = app.TimenowInTimezone().Format(time.RFC3339)
+	report.Status = ScrubCompleted
+	if err != nil {
+		report.Status = ScrubFailed
+		report.Error = err.Error()
+	}
+	// 先保存报告再清除进行中的状态,查询时总能得到最新的结果
+	data, marshalErr := json.Marshal(report)
+	if marshalErr == nil {
+		marshalErr = filesystem.WriteFileAtomic(ss.getReportPath(), data, 0644)
+	}
+	scrubCurrent = nil
+	scrubMu.Unlock()
+	logger.LogIf(marshalErr)
+
+	logger.Info("Scrub",
+		zap.String("id", report.ID),
+		zap.String("status", report.Status),
+		zap.Int64("objects", report.Objects),
+		zap.Int64("corrupted", report.Corrupted),
+		zap.Int64("missing", report.Missing),
+		zap.Int64("failed", report.Failed),
+		zap.Error(err))
+	metrics.ScrubFinished(map[string]int64{
+		ScrubIssueCorrupted: report.Corrupted,
+		ScrubIssueMissing:   report.Missing,
+		ScrubIssueReadError: report.Failed,
+	})
+}
+
+// scan 依次检查每个存储桶的文件,以及记录了元数据但文件已不存在的情况
+func (ss *ScrubService) scan(report *model.ScrubReport, throttle *bandwidth.Throttle) error {
+	fs := &ss.FileService
+	buckets, err := fs.BucketService.GetBucketList()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		keys, err := fs.Storage.ListFilesUnder(fs.getFilePath(bucket.Name, ""))
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			seen[key] = true
+			ss.verify(report, bucket.Name, key, throttle)
+		}
+
+		metaKeys, err := fs.MetaService.List(bucket.Name)
+		if err != nil {
+			return err
+		}
+		for _, key := range metaKeys {
+			if seen[key] {
+				continue
+			}
+			// 列出文件之后才上传的文件不算丢失
+			if _, err := fs.GetFileInfo(bucket.Name, key); err == nil {
+				continue
+			}
+			ss.addIssue(report, model.ScrubIssue{Type: ScrubIssueMissing, Bucket: bucket.Name, Key: key, Message: "文件已不存在"})
+		}
+	}
+	return nil
+}
+
+// verify 重新读取文件并与记录的大小及 SHA-256 比对
+func (ss *ScrubService) verify(report *model.ScrubReport, bucket, key string, throttle *bandwidth.Throttle) {
+	fs := &ss.FileService
+
+	// 文件与元数据在同一把读锁内获取,保证两者属于同一次写入;读取内容时不持有锁
+	unlock := objectLocks.RLock(objectLockKey(bucket, key))
+	file, info, err := fs.Storage.Open(fs.getFilePath(bucket, key))
+	meta, metaErr := fs.MetaService.Get(bucket, key)
+	unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		// 列出文件之后已被删除
+		return
+	}
+	if err == nil && metaErr != nil {
+		file.Close()
+		err = metaErr
+	}
+	if err != nil {
+		ss.addIssue(report, model.ScrubIssue{Type: ScrubIssueReadError, Bucket: bucket, Key: key, Message: err.Error()})
+		return
+	}
+	defer file.Close()
+
+	if meta == nil || meta.SHA256 == "" {
+		ss.count(report, func() { report.Objects++; report.Unverified++ })
+		return
+	}
+	if info.Size() != meta.Size {
+		ss.addIssue(report, model.ScrubIssue{
+			Type:     ScrubIssueSizeMismatch,
+			Bucket:   bucket,
+			Key:      key,
+			Expected: strconv.FormatInt(meta.Size, 10),
+			Actual:   strconv.FormatInt(info.Size(), 10),
+		})
+		return
+	}
+
+	reader, _ := checksum.NewReader(throttle.Reader(file), "", "")
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		ss.addIssue(report, model.ScrubIssue{Type: ScrubIssueReadError, Bucket: bucket, Key: key, Message: err.Error()})
+		return
+	}
+	if actual := reader.SHA256(); actual != meta.SHA256 {
+		ss.addIssue(report, model.ScrubIssue{Type: ScrubIssueCorrupted, Bucket: bucket, Key: key, Expected: meta.SHA256, Actual: actual})
+		return
+	}
+	ss.count(report, func() { report.Objects++; report.Bytes += reader.N(); report.Verified++ })
+}
+
+// count 在锁内更新报告的统计数据,以便查询进行中的巡检
+func (ss *ScrubService) count(report *model.ScrubReport, fn func()) {
+	scrubMu.Lock()
+	defer scrubMu.Unlock()
+	fn()
+}
+
+// addIssue 记录问题,超出上限时只计数
+func (ss *ScrubService) addIssue(report *model.ScrubReport, issue model.ScrubIssue) {
+	logger.Warn("Scrub", zap.String("type", issue.Type), zap.String("bucket", issue.Bucket), zap.String("key", issue.Key),
+		zap.String("expected", issue.Expected), zap.String("actual", issue.Actual), zap.String("message", issue.Message))
+
+	scrubMu.Lock()
+	defer scrubMu.Unlock()
+	switch issue.Type {
+	case ScrubIssueMissing:
+		report.Missing++
+	case ScrubIssueReadError:
+		report.Objects++
+		report.Failed++
+	default:
+		report.Objects++
+		report.Corrupted++
+	}
+	if len(report.Issues) < config.GetInt("scrub.max_issues", 1000) {
+		report.Issues = append(report.Issues, issue)
+	}
+}
+
+// snapshotReport 复制报告,调用方需持有 scrubMu 或报告不再被修改
+func snapshotReport(report *model.ScrubReport) *model.ScrubReport {
+	snapshot := *report
+	snapshot.Issues = append([]model.ScrubIssue{}, report.Issues...)
+	return &snapshot
+}
@@ -0,0 +1,577 @@
+/*
+ * @PackageName: services
+ * @FileName: user_service.go
+ * @Description: 用户服务,管理用户及角色,校验密码并签发会话令牌
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:14:26
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:14:26
+ */
+
+package services
+
+import (
+	"crypto/rand"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/filesystem"
+	"easy_dfs/pkg/jwt"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 用户操作的错误
+var (
+	ErrUserNotFound       = errors.New("用户不存在")
+	ErrUserExists         = errors.New("用户已存在")
+	ErrUserDisabled       = errors.New("用户已禁用")
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrLastAdmin          = errors.New("至少需要保留一个启用的管理员")
+	ErrInvalidAccessKey   = errors.New("密钥无效")
+	ErrPermissionDenied   = errors.New("没有操作权限")
+	ErrReadOnly           = errors.New("只读用户不能修改数据")
+	ErrNotBucketOwner     = errors.New("只能操作自己的存储桶")
+	ErrInvalidUsername    = errors.New("用户名只能包含字母、数字、点、下划线、中划线及 @,长度为 3-64 个字符")
+	ErrExternalUser       = errors.New("单点登录用户的密码由身份提供方管理")
+)
+
+// 用户名格式:字母或数字开头,3-64 个字母、数字、点、下划线、中划线或 @,兼容以邮箱作为用户名的身份提供方
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]{2,63}$`)
+
+// 密码长度限制,bcrypt 只使用前 72 个字节
+const (
+	minPasswordLen = 8
+	maxPasswordLen = 72
+)
+
+var (
+	// 创建、修改及删除用户时持有,保证读取及写回配置之间不会被其他修改覆盖
+	userLifecycleMu sync.Mutex
+	// 自动生成的会话令牌签名密钥
+	jwtSecretMu sync.Mutex
+	jwtSecret   []byte
+)
+
+// UserService 用户服务
+type UserService struct {
+	mu               sync.Mutex
+	AccessKeyService AccessKeyService // 访问密钥服务,禁用及删除用户时一并处理其访问密钥
+}
+
+// UserUpdate 修改用户的参数,为空的项不修改
+type UserUpdate struct {
+	Role     *string
+	Password *string
+}
+
+// getConfigPath 根据应用环境返回用户配置文件的路径
+func (us *UserService) getConfigPath() string {
+	userConfigPath := "config/user.json"
+	if config.Get("app.env") != "prod" {
+		userConfigPath = "tmp/config/user.json"
+	}
+	return userConfigPath
+}
+
+// getSecretPath 根据应用环境返回自动生成的签名密钥文件的路径
+func (us *UserService) getSecretPath() string {
+	secretPath := "config/jwt_secret"
+	if config.Get("app.env") != "prod" {
+		secretPath = "tmp/config/jwt_secret"
+	}
+	return secretPath
+}
+
+// readUserConfig 从文件中读取用户配置并返回解析后的数据
+func (us *UserService) readUserConfig() ([]model.UserInfo, error) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	byteValue, err := os.ReadFile(us.getConfigPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var users []model.UserInfo
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &users); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// writeUserConfig 将用户配置写入文件,文件中保存密码哈希,只允许服务进程读取
+func (us *UserService) writeUserConfig(users []model.UserInfo) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	userConfigJson, err := json.Marshal(users)
+	if err != nil {
+		return err
+	}
+	return filesystem.WriteFileAtomic(us.getConfigPath(), userConfigJson, 0600)
+}
+
+// HasUsers 是否已创建用户
+func (us *UserService) HasUsers() (bool, error) {
+	users, err := us.readUserConfig()
+	return len(users) > 0, err
+}
+
+// CreateUser 创建用户,第一个用户必须为管理员
+func (us *UserService) CreateUser(username, password, role string) (*model.UserInfo, error) {
+	if !usernamePattern.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+	if !user_role.IsValid(role) {
+		return nil, fmt.Errorf("角色只能为 %s、%s 或 %s", user_role.ADMIN, user_role.BUCKET_OWNER, user_role.READ_ONLY)
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	userLifecycleMu.Lock()
+	defer userLifecycleMu.Unlock()
+
+	users, err := us.readUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 && role != user_role.ADMIN {
+		return nil, errors.New("第一个用户必须为管理员")
+	}
+	for _, v := range users {
+		if strings.EqualFold(v.Username, username) {
+			return nil, ErrUserExists
+		}
+	}
+
+	user := model.UserInfo{
+		Username:  username,
+		Password:  hash,
+		Role:      role,
+		Status:    1,
+		CreatedAt: app.TimenowInTimezone().Format(time.RFC3339),
+	}
+	if err := us.writeUserConfig(append(users, user)); err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return &user, nil
+}
+
+// GetUserList 返回所有用户,不包含密码哈希
+func (us *UserService) GetUserList() ([]model.UserInfo, error) {
+	users, err := us.readUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+// FindUser 根据用户名查找用户,不包含密码哈希
+func (us *UserService) FindUser(username string) (*model.UserInfo, error) {
+	user, err := us.findUser(username)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// findUser 根据用户名查找用户
+func (us *UserService) findUser(username string) (*model.UserInfo, error) {
+	users, err := us.readUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range users {
+		if v.Username == username {
+			return &v, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// UpdateUser 修改用户的角色或密码,修改密码后已签发的会话令牌失效
+func (us *UserService) UpdateUser(username string, update UserUpdate) (*model.UserInfo, error) {
+	if update.Role != nil && !user_role.IsValid(*update.Role) {
+		return nil, fmt.Errorf("角色只能为 %s、%s 或 %s", user_role.ADMIN, user_role.BUCKET_OWNER, user_role.READ_ONLY)
+	}
+	var hash string
+	if update.Password != nil {
+		var err error
+		if hash, err = hashPassword(*update.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	var updated *model.UserInfo
+	err := us.modifyUser(username, func(users []model.UserInfo, i int) ([]model.UserInfo, error) {
+		if update.Role != nil && *update.Role != user_role.ADMIN && isLastAdmin(users, i) {
+			return nil, ErrLastAdmin
+		}
+		if update.Role != nil {
+			users[i].Role = *update.Role
+		}
+		if update.Password != nil {
+			users[i].Password = hash
+			users[i].TokenVersion++
+		}
+		user := users[i]
+		updated = &user
+		return users, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated.Password = ""
+	return updated, nil
+}
+
+// ChangePassword 校验原密码后修改密码
+func (us *UserService) ChangePassword(username, oldPassword, newPassword string) error {
+	user, err := us.findUser(username)
+	if err != nil {
+		return err
+	}
+	if user.Provider != "" {
+		return ErrExternalUser
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)) != nil {
+		return ErrInvalidCredentials
+	}
+	_, err = us.UpdateUser(username, UserUpdate{Password: &newPassword})
+	return err
+}
+
+// RevokeUser 禁用用户,同时吊销其全部访问密钥并使已签发的会话令牌失效
+func (us *UserService) RevokeUser(username string) error {
+	err := us.modifyUser(username, func(users []model.UserInfo, i int) ([]model.UserInfo, error) {
+		if isLastAdmin(users, i) {
+			return nil, ErrLastAdmin
+		}
+		users[i].Status = -1
+		users[i].TokenVersion++
+		return users, nil
+	})
+	if err != nil {
+		return err
+	}
+	return us.AccessKeyService.RevokeOwnerKeys(username)
+}
+
+// DeleteUser 删除用户及其全部访问密钥,其拥有的存储桶保留,只有管理员可以管理
+func (us *UserService) DeleteUser(username string) error {
+	err := us.modifyUser(username, func(users []model.UserInfo, i int) ([]model.UserInfo, error) {
+		if isLastAdmin(users, i) {
+			return nil, ErrLastAdmin
+		}
+		return append(users[:i], users[i+1:]...), nil
+	})
+	if err != nil {
+		return err
+	}
+	return us.AccessKeyService.DeleteOwnerKeys(username)
+}
+
+// modifyUser 在持有锁的情况下修改用户配置并写回
+func (us *UserService) modifyUser(username string, modify func(users []model.UserInfo, i int) ([]model.UserInfo, error)) error {
+	userLifecycleMu.Lock()
+	defer userLifecycleMu.Unlock()
+
+	users, err := us.readUserConfig()
+	if err != nil {
+		return err
+	}
+	for i, v := range users {
+		if v.Username != username {
+			continue
+		}
+		users, err = modify(users, i)
+		if err != nil {
+			return err
+		}
+		return us.writeUserConfig(users)
+	}
+	return ErrUserNotFound
+}
+
+// isLastAdmin 第 i 个用户是否为唯一启用的管理员
+func isLastAdmin(users []model.UserInfo, i int) bool {
+	if users[i].Role != user_role.ADMIN || users[i].Status != 1 {
+		return false
+	}
+	for j, v := range users {
+		if j != i && v.Role == user_role.ADMIN && v.Status == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Login 校验用户名及密码,签发会话令牌
+func (us *UserService) Login(username, password string) (*model.LoginResult, error) {
+	user, err := us.findUser(username)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Status != 1 {
+		return nil, ErrUserDisabled
+	}
+	return us.issueToken(user)
+}
+
+// SyncOidcUser 单点登录成功后创建或更新用户,角色及用户组以身份提供方为准。
+// 按签发方及 sub 声明匹配用户,username 只在首次登录创建用户时作为用户名;
+// 用户名已被本地用户或其他单点登录账号使用时拒绝登录,避免身份提供方中同名的账号接管已有账号
+func (us *UserService) SyncOidcUser(issuer, subject, username, role string, groups []string) (*model.UserInfo, error) {
+	if issuer == "" || subject == "" {
+		return nil, ErrOidcSubject
+	}
+
+	userLifecycleMu.Lock()
+	defer userLifecycleMu.Unlock()
+
+	users, err := us.readUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range users {
+		if v.Provider != oidcProvider || v.Issuer != issuer || v.Subject != subject {
+			continue
+		}
+		if v.Status != 1 {
+			return nil, ErrUserDisabled
+		}
+		users[i].Role = role
+		users[i].Groups = groups
+		if err := us.writeUserConfig(users); err != nil {
+			return nil, err
+		}
+		user := users[i]
+		return &user, nil
+	}
+
+	if !usernamePattern.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+	for _, v := range users {
+		if !strings.EqualFold(v.Username, username) {
+			continue
+		}
+		if v.Provider != oidcProvider {
+			return nil, ErrOidcLocalUser
+		}
+		return nil, ErrOidcUsernameTaken
+	}
+
+	user := model.UserInfo{
+		Username:  username,
+		Role:      role,
+		Status:    1,
+		Provider:  oidcProvider,
+		Issuer:    issuer,
+		Subject:   subject,
+		Groups:    groups,
+		CreatedAt: app.TimenowInTimezone().Format(time.RFC3339),
+	}
+	if err := us.writeUserConfig(append(users, user)); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// issueToken 为用户签发会话令牌
+func (us *UserService) issueToken(user *model.UserInfo) (*model.LoginResult, error) {
+	secret, err := us.secret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.GetInt("auth.session_ttl", 24)) * time.Hour)
+	token, err := jwt.Sign(secret, jwt.Claims{
+		Subject:   user.Username,
+		Version:   user.TokenVersion,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return &model.LoginResult{
+		Token:     token,
+		ExpiresAt: expiresAt.In(app.TimenowInTimezone().Location()).Format(time.RFC3339),
+		User:      *user,
+	}, nil
+}
+
+// Authenticate 校验会话令牌,返回令牌对应的启用中的用户
+func (us *UserService) Authenticate(token string) (*model.Principal, error) {
+	secret, err := us.secret()
+	if err != nil {
+		return nil, err
+	}
+	claims, err := jwt.Parse(secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	user, err := us.FindUser(claims.Subject)
+	if err != nil {
+		return nil, jwt.ErrInvalidToken
+	}
+	if user.Status != 1 {
+		return nil, ErrUserDisabled
+	}
+	// 修改密码或禁用后令牌版本已变化
+	if user.TokenVersion != claims.Version {
+		return nil, jwt.ErrInvalidToken
+	}
+	return principalOf(user, ""), nil
+}
+
+// AuthenticateAccessKey 校验访问密钥,返回密钥所属用户
+func (us *UserService) AuthenticateAccessKey(accessKey, secretKey string) (*model.Principal, error) {
+	if !us.AccessKeyService.CheckAccessKey(accessKey, secretKey) {
+		return nil, ErrInvalidAccessKey
+	}
+	return us.AccessKeyPrincipal(accessKey)
+}
+
+// AccessKeyPrincipal 返回访问密钥所属用户,不校验秘钥;升级前创建的密钥没有所属用户,视为管理员
+func (us *UserService) AccessKeyPrincipal(accessKey string) (*model.Principal, error) {
+	info, err := us.AccessKeyService.FindByAccessKey(accessKey)
+	if err != nil {
+		return nil, ErrInvalidAccessKey
+	}
+	if info.Owner == "" {
+		return &model.Principal{Role: user_role.ADMIN, AccessKey: accessKey}, nil
+	}
+	user, err := us.FindUser(info.Owner)
+	if err != nil {
+		return nil, ErrInvalidAccessKey
+	}
+	if user.Status != 1 {
+		return nil, ErrUserDisabled
+	}
+	return principalOf(user, accessKey), nil
+}
+
+// principalOf 返回用户对应的请求方,并按用户组计算被授权的存储桶
+func principalOf(user *model.UserInfo, accessKey string) *model.Principal {
+	return &model.Principal{
+		Username:  user.Username,
+		Role:      user.Role,
+		AccessKey: accessKey,
+		Buckets:   groupBuckets(user.Groups),
+	}
+}
+
+// AuthorizeBucket 校验请求方能否访问存储桶,write 表示是否修改数据:
+// 管理员不受限制;只读用户只能查看及下载;存储桶所有者只能访问自己的及通过用户组被授权的存储桶
+func AuthorizeBucket(principal model.Principal, bucketInfo *model.BucketInfo, write bool) error {
+	switch principal.Role {
+	case user_role.ADMIN:
+		return nil
+	case user_role.READ_ONLY:
+		if write {
+			return ErrReadOnly
+		}
+		return nil
+	case user_role.BUCKET_OWNER:
+		if OwnsBucket(principal, bucketInfo.Owner, bucketInfo.Name) {
+			return nil
+		}
+		return ErrNotBucketOwner
+	}
+	return ErrPermissionDenied
+}
+
+// OwnsBucket 存储桶是否属于请求方,或通过用户组授权给了请求方
+func OwnsBucket(principal model.Principal, owner, bucket string) bool {
+	return (principal.Username != "" && owner == principal.Username) || containsString(principal.Buckets, bucket)
+}
+
+// NeedsBootstrap 尚未创建任何用户及访问密钥时返回 true,此时允许不经校验创建第一个用户或访问密钥
+func (us *UserService) NeedsBootstrap() (bool, error) {
+	hasUsers, err := us.HasUsers()
+	if err != nil || hasUsers {
+		return false, err
+	}
+	keys, err := us.AccessKeyService.GetAccessKeyList()
+	return len(keys) == 0, err
+}
+
+// secret 返回会话令牌的签名密钥,未配置时使用配置目录中自动生成的密钥
+func (us *UserService) secret() ([]byte, error) {
+	if secret := config.Get("auth.jwt_secret"); secret != "" {
+		return []byte(secret), nil
+	}
+
+	jwtSecretMu.Lock()
+	defer jwtSecretMu.Unlock()
+	if jwtSecret != nil {
+		return jwtSecret, nil
+	}
+
+	secretPath := us.getSecretPath()
+	secret, err := os.ReadFile(secretPath)
+	if errors.Is(err, os.ErrNotExist) {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = []byte(hex.EncodeToString(buf))
+		err = filesystem.WriteFileAtomic(secretPath, secret, 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("签名密钥文件 %s 为空", secretPath)
+	}
+	jwtSecret = secret
+	return jwtSecret, nil
+}
+
+// ValidatePassword 校验密码长度
+func ValidatePassword(password string) error {
+	if len(password) < minPasswordLen || len(password) > maxPasswordLen {
+		return fmt.Errorf("密码长度应为 %d-%d 个字符", minPasswordLen, maxPasswordLen)
+	}
+	return nil
+}
+
+// hashPassword 校验密码长度并计算 bcrypt 哈希
+func hashPassword(password string) (string, error) {
+	if err := ValidatePassword(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
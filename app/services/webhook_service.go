@@ -0,0 +1,466 @@
+/*
+ * @PackageName: services
+ * @FileName: webhook_service.go
+ * @Description: 事件通知服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 14:31:16
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 14:31:16
+ */
+
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"easy_dfs/model"
+	"easy_dfs/pkg/app"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/filesystem"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/utils/str_util"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 待投递
+	DeliveryPending = "pending"
+	// 投递成功
+	DeliverySuccess = "success"
+	// 投递失败(超出最大重试次数)
+	DeliveryFailed = "failed"
+)
+
+// webhookMaxWorkers 同时投递的地址数上限
+const webhookMaxWorkers = 16
+
+// 发件箱保存在内存中,首次使用时从文件读取,之后每次修改写回文件。
+// 服务以值的形式嵌入各控制器,因此状态及锁需全局共享
+var (
+	// 保护发件箱及正在投递的地址
+	webhookMu sync.Mutex
+	// 发件箱,按创建时间排序
+	webhookDeliveries []model.WebhookDelivery
+	// 已读取的发件箱文件的绝对路径,路径变化(如工作目录变化)时重新读取
+	webhookPath string
+	// 发件箱的版本,每次修改加一
+	webhookVersion int64
+	// 正在投递的地址,同一地址同时只有一个协程按顺序投递
+	webhookInflight = make(map[string]bool)
+
+	// 写入发件箱文件时持有,并发的修改合并为一次写入
+	webhookFlushMu sync.Mutex
+	// 已写入文件的版本
+	webhookFlushed int64
+
+	// 有新事件时唤醒投递协程
+	webhookNotify = make(chan struct{}, 1)
+	// 保护 webhookStop
+	webhookRunMu sync.Mutex
+	// 关闭后投递协程退出,为空表示未启动
+	webhookStop chan struct{}
+	// 投递协程及各地址的投递协程
+	webhookWorkers sync.WaitGroup
+)
+
+// WebhookService 事件通知服务,事件先写入发件箱文件,再由后台协程投递,重启后继续投递未完成的事件
+type WebhookService struct {
+}
+
+// getConfigPath 根据应用环境返回投递记录文件的路径
+func (ws *WebhookService) getConfigPath() string {
+	deliveryConfigPath := "config/webhook_delivery.json"
+	if config.Get("app.env") != "prod" {
+		deliveryConfigPath = "tmp/config/webhook_delivery.json"
+	}
+	return deliveryConfigPath
+}
+
+// readDeliveries 从文件读取投递记录
+func (ws *WebhookService) readDeliveries(path string) ([]model.WebhookDelivery, error) {
+	byteValue, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var deliveries []model.WebhookDelivery
+	if len(byteValue) > 0 {
+		err = json.Unmarshal(byteValue, &deliveries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return deliveries, nil
+}
+
+// loadDeliveries 返回内存中的发件箱,首次使用或文件路径变化时从文件读取,调用方需持有 webhookMu
+func (ws *WebhookService) loadDeliveries() ([]model.WebhookDelivery, error) {
+	path, err := filepath.Abs(ws.getConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	if path == webhookPath {
+		return webhookDeliveries, nil
+	}
+	deliveries, err := ws.readDeliveries(path)
+	if err != nil {
+		return nil, err
+	}
+	webhookDeliveries, webhookPath = deliveries, path
+	clear(webhookInflight)
+	return deliveries, nil
+}
+
+// pruneDeliveries 只保留最近的已完成记录
+func pruneDeliveries(deliveries []model.WebhookDelivery) []model.WebhookDelivery {
+	maxHistory := config.GetInt("webhook.max_history", 1000)
+	finished := 0
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		if deliveries[i].Status == DeliveryPending {
+			continue
+		}
+		finished++
+		if finished > maxHistory {
+			deliveries = append(deliveries[:i], deliveries[i+1:]...)
+		}
+	}
+	return deliveries
+}
+
+// updateDeliveries 修改发件箱并写入文件,返回时修改已写入文件。
+// path 不为空时只修改该文件对应的发件箱,发件箱已切换到其他文件时放弃修改
+func (ws *WebhookService) updateDeliveries(path string, modify func([]model.WebhookDelivery) []model.WebhookDelivery) error {
+	webhookMu.Lock()
+	deliveries, err := ws.loadDeliveries()
+	if err != nil {
+		webhookMu.Unlock()
+		return err
+	}
+	if path != "" && path != webhookPath {
+		webhookMu.Unlock()
+		return nil
+	}
+	webhookDeliveries = pruneDeliveries(modify(deliveries))
+	webhookVersion++
+	version := webhookVersion
+	webhookMu.Unlock()
+
+	return ws.flush(version)
+}
+
+// flush 将发件箱写入文件;等待期间其他协程已写入该版本时直接返回,
+// 并发发布的事件因此只需写入一次文件
+func (ws *WebhookService) flush(version int64) error {
+	webhookFlushMu.Lock()
+	defer webhookFlushMu.Unlock()
+	if webhookFlushed >= version {
+		return nil
+	}
+
+	webhookMu.Lock()
+	snapshot := append([]model.WebhookDelivery(nil), webhookDeliveries...)
+	path, current := webhookPath, webhookVersion
+	webhookMu.Unlock()
+
+	deliveriesJson, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	// 投递记录中包含签名密钥
+	if err := filesystem.WriteFileAtomic(path, deliveriesJson, 0600); err != nil {
+		return err
+	}
+	webhookFlushed = current
+	return nil
+}
+
+// Publish 发布存储桶事件,为订阅该事件的每个地址生成一条投递记录
+func (ws *WebhookService) Publish(eventType string, bucketInfo model.BucketInfo, key string, size int64) error {
+	event := model.Event{
+		ID:     str_util.SimpleUUID(),
+		Type:   eventType,
+		Bucket: bucketInfo.Name,
+		Key:    key,
+		Size:   size,
+		Time:   app.TimenowInTimezone().Format(time.RFC3339),
+	}
+
+	var newDeliveries []model.WebhookDelivery
+	for _, hook := range bucketInfo.Webhooks {
+		if !subscribes(hook, eventType) {
+			continue
+		}
+		now := app.TimenowInTimezone().Format("2006-01-02 15:04:05")
+		newDeliveries = append(newDeliveries, model.WebhookDelivery{
+			ID:            str_util.SimpleUUID(),
+			Event:         event,
+			URL:           hook.URL,
+			Secret:        hook.Secret,
+			Status:        DeliveryPending,
+			NextAttemptAt: time.Now().Unix(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	if len(newDeliveries) == 0 {
+		return nil
+	}
+
+	err := ws.updateDeliveries("", func(deliveries []model.WebhookDelivery) []model.WebhookDelivery {
+		return append(deliveries, newDeliveries...)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 唤醒投递协程,通道已满时说明已有待处理的唤醒
+	select {
+	case webhookNotify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// PublishQuietly 发布事件,失败时只记录日志,不影响调用方的主流程
+func (ws *WebhookService) PublishQuietly(eventType string, bucketInfo model.BucketInfo, key string, size int64) {
+	if err := ws.Publish(eventType, bucketInfo, key, size); err != nil {
+		logger.Error("Webhook", zap.String("event", eventType), zap.String("bucket", bucketInfo.Name), zap.Error(err))
+	}
+}
+
+// ListDeliveries 查询投递记录,按创建时间倒序,bucket 和 status 为空时不过滤
+func (ws *WebhookService) ListDeliveries(bucket, status string, limit int) ([]model.WebhookDelivery, error) {
+	webhookMu.Lock()
+	defer webhookMu.Unlock()
+	deliveries, err := ws.loadDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.WebhookDelivery, 0)
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		d := deliveries[i]
+		if (bucket != "" && d.Event.Bucket != bucket) || (status != "" && d.Status != status) {
+			continue
+		}
+		// 不对外返回签名密钥
+		d.Secret = ""
+		result = append(result, d)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// StartDispatcher 启动后台投递协程,已启动时直接返回
+func (ws *WebhookService) StartDispatcher() {
+	webhookRunMu.Lock()
+	defer webhookRunMu.Unlock()
+	if webhookStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	webhookStop = stop
+
+	webhookWorkers.Add(1)
+	go func() {
+		defer webhookWorkers.Done()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			ws.dispatchDue()
+			select {
+			case <-ticker.C:
+			case <-webhookNotify:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopDispatcher 停止后台投递协程,并等待进行中的投递结束、结果写入文件
+func (ws *WebhookService) StopDispatcher() {
+	webhookRunMu.Lock()
+	if webhookStop == nil {
+		webhookRunMu.Unlock()
+		return
+	}
+	close(webhookStop)
+	webhookStop = nil
+	webhookRunMu.Unlock()
+
+	webhookWorkers.Wait()
+}
+
+// dispatchDue 按地址分组到期的待投递记录,每个地址由单独的协程按顺序投递,
+// 响应慢的地址只延迟自己的记录;正在投递的地址留待其协程结束后的下一轮
+func (ws *WebhookService) dispatchDue() {
+	webhookMu.Lock()
+	deliveries, err := ws.loadDeliveries()
+	if err != nil {
+		webhookMu.Unlock()
+		logger.LogIf(err)
+		return
+	}
+	now := time.Now().Unix()
+	due := make(map[string][]model.WebhookDelivery)
+	for _, d := range deliveries {
+		if d.Status != DeliveryPending || d.NextAttemptAt > now || webhookInflight[d.URL] {
+			continue
+		}
+		if _, ok := due[d.URL]; !ok && len(webhookInflight)+len(due) >= webhookMaxWorkers {
+			continue
+		}
+		due[d.URL] = append(due[d.URL], d)
+	}
+	for url := range due {
+		webhookInflight[url] = true
+	}
+	path := webhookPath
+	webhookMu.Unlock()
+
+	// 在投递协程内调用,计数不为零,可安全地增加
+	webhookWorkers.Add(len(due))
+	for url, list := range due {
+		go ws.dispatchEndpoint(path, url, list)
+	}
+}
+
+// dispatchEndpoint 按顺序投递同一地址的记录,每次投递后写回结果,发送请求时不持有锁
+func (ws *WebhookService) dispatchEndpoint(path, url string, deliveries []model.WebhookDelivery) {
+	defer webhookWorkers.Done()
+	defer func() {
+		webhookMu.Lock()
+		if path == webhookPath {
+			delete(webhookInflight, url)
+		}
+		webhookMu.Unlock()
+		// 投递期间该地址可能有新的到期记录
+		select {
+		case webhookNotify <- struct{}{}:
+		default:
+		}
+	}()
+
+	for _, d := range deliveries {
+		result := ws.attempt(d)
+		err := ws.updateDeliveries(path, func(deliveries []model.WebhookDelivery) []model.WebhookDelivery {
+			for i := range deliveries {
+				if deliveries[i].ID == result.ID {
+					deliveries[i] = result
+					break
+				}
+			}
+			return deliveries
+		})
+		logger.LogIf(err)
+	}
+}
+
+// attempt 投递一次并返回更新后的投递记录
+func (ws *WebhookService) attempt(d model.WebhookDelivery) model.WebhookDelivery {
+	d.Attempts++
+	d.UpdatedAt = app.TimenowInTimezone().Format("2006-01-02 15:04:05")
+
+	statusCode, err := ws.send(d)
+	d.LastStatusCode = statusCode
+	if err == nil {
+		d.Status = DeliverySuccess
+		d.LastError = ""
+		return d
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= config.GetInt("webhook.max_attempts", 8) {
+		d.Status = DeliveryFailed
+		logger.Warn("Webhook", zap.String("delivery", d.ID), zap.String("url", d.URL), zap.Error(err))
+		return d
+	}
+	d.NextAttemptAt = time.Now().Add(retryDelay(d.Attempts)).Unix()
+	return d
+}
+
+// send 发送事件通知,响应 2xx 视为成功
+func (ws *WebhookService) send(d model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "EasyDFS-Webhook")
+	req.Header.Set("X-EasyDFS-Event", d.Event.Type)
+	req.Header.Set("X-EasyDFS-Delivery", d.ID)
+	req.Header.Set("X-EasyDFS-Timestamp", timestamp)
+	if d.Secret != "" {
+		req.Header.Set("X-EasyDFS-Signature", "sha256="+SignWebhookPayload(d.Secret, timestamp, body))
+	}
+
+	client := &http.Client{Timeout: time.Duration(config.GetInt("webhook.timeout", 10)) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload 计算签名:HMAC-SHA256(secret, timestamp + "." + body),接收方据此校验请求来源及防重放
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay 计算第 attempts 次失败后的重试等待时间(指数退避)
+func retryDelay(attempts int) time.Duration {
+	base := time.Duration(config.GetInt("webhook.retry_base", 5)) * time.Second
+	maxDelay := time.Duration(config.GetInt("webhook.retry_max", 3600)) * time.Second
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// subscribes 判断通知配置是否订阅了该事件
+func subscribes(hook model.WebhookConfig, eventType string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+/*
+ * @PackageName: bootstrap
+ * @FileName: metrics.go
+ * @Description: 监控指标
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 16:04:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 16:04:12
+ */
+
+package bootstrap
+
+import (
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/metrics"
+	"time"
+)
+
+// SetupMetrics 注册存储桶占用空间指标
+func SetupMetrics() {
+	fs := new(services.FileService)
+	ttl := time.Duration(config.GetInt("metrics.usage_ttl", 60)) * time.Second
+	logger.LogIf(metrics.RegisterStorageUsage(fs.BucketUsage, ttl))
+}
@@ -11,12 +11,23 @@
 package bootstrap
 
 import (
+	"context"
 	"easy_dfs/app/enum/response_code"
 	"easy_dfs/app/middlewares"
+	"easy_dfs/app/services"
 	"easy_dfs/pkg/config"
 	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/pkg/logger"
 	"easy_dfs/routes"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // 引导安装路由
@@ -24,24 +35,75 @@ func SetupRoute() {
 	// GIN设为生产模式,不输出日志
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// 仅信任配置的代理转发的客户端IP,避免伪造 X-Forwarded-For 绕过按IP限流
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		panic(err)
+	}
 
 	// 找不到路由时的返回
 	router.NoRoute(func(ctx *gin.Context) {
 		http_response.Response(ctx, response_code.QUERY_EMPTY, false, "资源不存在", nil, nil)
 	})
+	// 监控指标中间件,需在路由注册前加入才会对已注册的路由生效
+	router.Use(middlewares.Metrics())
+
 	// 路由注册
 	routes.RegisterRoutes(router)
 
 	// 全局中间件
-	// 跨域处理中间件,文件URL检查中间件
+	// 文件URL检查中间件,跨域由各路由组按存储桶或全局配置处理
 	router.Use(
-		middlewares.Cors(),
 		middlewares.FileUrlCheck(),
 		middlewares.Logger(),
 		middlewares.Recovery(),
 	)
-	err := router.Run(":" + config.Get("app.port"))
-	if err != nil {
-		panic(err)
+
+	server := &http.Server{
+		Addr:    ":" + config.Get("app.port"),
+		Handler: middlewares.VirtualHost(router),
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+
+	// 等待退出信号,收到后优雅退出
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	_ = Shutdown(server)
+}
+
+// trustedProxies 读取 app.trusted_proxies 配置(逗号分隔的IP或网段)
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(config.GetString("app.trusted_proxies"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// Shutdown 优雅退出:先标记未就绪,再停止接收新请求并等待进行中的上传、下载完成,
+// 超过 app.drain_timeout 仍未完成时返回错误
+func Shutdown(server *http.Server) error {
+	log.Println("Server shutting down...")
+	hs := new(services.HealthService)
+	hs.SetDraining(true)
+
+	// 等待负载均衡感知 /readyz 失败后不再转发新请求
+	time.Sleep(time.Duration(config.GetInt("app.drain_delay", 0)) * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.GetInt("app.drain_timeout", 30))*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		// 超时仍未完成的请求会被强制中断,未写完的文件由存储层清理
+		log.Println("Server forced to shutdown:", err)
+		logger.LogIf(err)
+		return err
 	}
+	log.Println("Server exited")
+	return nil
 }
@@ -0,0 +1,19 @@
+/*
+ * @PackageName: bootstrap
+ * @FileName: scrub.go
+ * @Description: 数据完整性巡检
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 02:49:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 02:49:52
+ */
+
+package bootstrap
+
+import "easy_dfs/app/services"
+
+// SetupScrub 启动定期数据完整性巡检
+func SetupScrub() {
+	ss := new(services.ScrubService)
+	ss.StartScheduler()
+}
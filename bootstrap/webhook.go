@@ -0,0 +1,19 @@
+/*
+ * @PackageName: bootstrap
+ * @FileName: webhook.go
+ * @Description: 事件通知
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 15:02:44
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 15:02:44
+ */
+
+package bootstrap
+
+import "easy_dfs/app/services"
+
+// SetupWebhook 启动事件通知投递协程,继续投递重启前未完成的事件
+func SetupWebhook() {
+	ws := new(services.WebhookService)
+	ws.StartDispatcher()
+}
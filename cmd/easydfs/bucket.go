@@ -0,0 +1,81 @@
+/*
+ * @PackageName: main
+ * @FileName: bucket.go
+ * @Description: 存储桶相关命令(mb/rb)
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:52:03
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:52:03
+ */
+
+package main
+
+import (
+	"easy_dfs/model"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// runMakeBucket 创建存储桶
+func runMakeBucket(c *cli, args []string) error {
+	flags := flag.NewFlagSet("mb", flag.ContinueOnError)
+	policy := flags.String("policy", "private", "访问策略")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("用法: easydfs mb [-policy private] dfs://bucket")
+	}
+	loc, err := parseRemote(flags.Arg(0), false)
+	if err != nil {
+		return err
+	}
+	if err := c.api.CreateBucket(ctx, model.BucketInfo{Name: loc.bucket, AccessPolicy: *policy}); err != nil {
+		return err
+	}
+	fmt.Printf("已创建存储桶 %s\n", loc.bucket)
+	return nil
+}
+
+// runRemoveBucket 删除存储桶,指定 -force 时删除其中的所有文件并等待删除完成
+func runRemoveBucket(c *cli, args []string) error {
+	flags := flag.NewFlagSet("rb", flag.ContinueOnError)
+	force := flags.Bool("force", false, "删除存储桶中的所有文件")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("用法: easydfs rb [-force] dfs://bucket")
+	}
+	loc, err := parseRemote(flags.Arg(0), false)
+	if err != nil {
+		return err
+	}
+	if !*force {
+		if err := c.api.DeleteBucket(ctx, loc.bucket); err != nil {
+			return err
+		}
+		fmt.Printf("已删除存储桶 %s\n", loc.bucket)
+		return nil
+	}
+
+	job, err := c.api.ForceDeleteBucket(ctx, loc.bucket)
+	if err != nil {
+		return err
+	}
+	for job.Status == "running" {
+		fmt.Printf("\r已删除 %d/%d 个文件", job.Deleted, job.Total)
+		time.Sleep(time.Second)
+		if job, err = c.api.BucketDeleteJob(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("\r已删除 %d/%d 个文件\n", job.Deleted, job.Total)
+	if job.Status != "completed" {
+		return fmt.Errorf("删除存储桶 %s 失败: %s", loc.bucket, job.Error)
+	}
+	fmt.Printf("已删除存储桶 %s\n", loc.bucket)
+	return nil
+}
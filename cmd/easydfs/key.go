@@ -0,0 +1,106 @@
+/*
+ * @PackageName: main
+ * @FileName: key.go
+ * @Description: 访问密钥相关命令
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:55:48
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:55:48
+ */
+
+package main
+
+import (
+	"easy_dfs/model"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+const keyUsage = "用法: easydfs key create [-expire 过期时间] <名称> | key ls | key info <名称> | key rm <名称>"
+
+// runKey 管理访问密钥
+func runKey(c *cli, args []string) error {
+	if len(args) == 0 {
+		return errors.New(keyUsage)
+	}
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("key create", flag.ContinueOnError)
+		expire := flags.String("expire", "", "过期时间")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New(keyUsage)
+		}
+		info, err := c.api.CreateAccessKey(ctx, flags.Arg(0), *expire)
+		if err != nil {
+			return err
+		}
+		printAccessKey(*info, true)
+
+	case "ls":
+		list, err := c.api.ListAccessKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, info := range list {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", info.Name, info.AccessKey, keyStatus(info.Status), info.ExpireTime, info.Owner)
+		}
+
+	case "info":
+		if len(args) != 2 {
+			return errors.New(keyUsage)
+		}
+		info, err := c.api.GetAccessKey(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		printAccessKey(*info, false)
+
+	case "rm":
+		if len(args) != 2 {
+			return errors.New(keyUsage)
+		}
+		if err := c.api.DeleteAccessKey(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("已删除访问密钥 %s\n", args[1])
+
+	default:
+		return errors.New(keyUsage)
+	}
+	return nil
+}
+
+// printAccessKey 输出访问密钥,秘钥密码仅在创建时显示
+func printAccessKey(info model.AccessKeyInfo, withSecret bool) {
+	fmt.Printf("Name:\t%s\nAccessKey:\t%s\n", info.Name, info.AccessKey)
+	if withSecret {
+		fmt.Printf("SecretKey:\t%s\n", info.SecretKey)
+	}
+	fmt.Printf("Status:\t%s\nExpireTime:\t%s\n", keyStatus(info.Status), info.ExpireTime)
+}
+
+// keyStatus 返回状态名称
+func keyStatus(status int) string {
+	if status == 1 {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// runWhoami 输出当前访问密钥所属的用户及角色
+func runWhoami(c *cli, args []string) error {
+	principal, err := c.api.Me(ctx)
+	if err != nil {
+		return err
+	}
+	username := principal.Username
+	if username == "" {
+		username = "-"
+	}
+	fmt.Printf("%s\t%s\n", username, principal.Role)
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+ * @PackageName: main
+ * @FileName: main.go
+ * @Description: 命令行客户端入口
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:20:05
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:20:05
+ */
+
+package main
+
+import (
+	"easy_dfs/pkg/client"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// 进程退出码
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitDenied   = 3
+	exitNotFound = 4
+)
+
+const usage = `easydfs - easy_dfs 命令行客户端
+
+用法:
+  easydfs [--profile 名称] [--endpoint 地址] <命令> [参数]
+
+命令:
+  configure                         配置访问地址及密钥(保存到配置文件的 profile 中)
+  ls [dfs://bucket[/prefix]]        列出存储桶,或存储桶内的文件
+  cp [-r] <源> <目标>               上传、下载或复制文件,本地路径与 dfs://bucket/key 任意组合
+  mv [-r] <源> <目标>               移动文件(复制成功后删除源文件)
+  rm [-r] dfs://bucket/key          删除文件,-r 删除前缀下的所有文件
+  cat dfs://bucket/key              输出文件内容
+  stat dfs://bucket/key             查看文件信息
+  mb [-policy private] dfs://bucket 创建存储桶
+  rb [-force] dfs://bucket          删除存储桶,-force 同时删除其中的文件
+  presign [-expires 1h] dfs://bucket/key  生成文件的签名访问地址
+  key create|ls|info|rm             管理访问密钥
+  whoami                            查看当前访问密钥所属的用户及角色
+
+环境变量:
+  EASYDFS_CONFIG      配置文件路径,默认 ~/.easydfs/config.json
+  EASYDFS_PROFILE     使用的 profile,默认 default
+  EASYDFS_ENDPOINT    服务地址,优先于 profile
+  EASYDFS_ACCESS_KEY  访问密钥,优先于 profile
+  EASYDFS_SECRET_KEY  秘钥密码,优先于 profile
+`
+
+// command 子命令
+type command func(cli *cli, args []string) error
+
+var commands = map[string]command{
+	"configure": runConfigure,
+	"ls":        runList,
+	"cp":        runCopy,
+	"mv":        runMove,
+	"rm":        runRemove,
+	"cat":       runCat,
+	"stat":      runStat,
+	"mb":        runMakeBucket,
+	"rb":        runRemoveBucket,
+	"presign":   runPresign,
+	"key":       runKey,
+	"whoami":    runWhoami,
+}
+
+// cli 命令执行上下文
+type cli struct {
+	profileName string
+	profile     Profile
+	api         *client.Client
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 解析全局参数并执行子命令,返回进程退出码
+func run(args []string) int {
+	fs := flag.NewFlagSet("easydfs", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	profileName := fs.String("profile", envOr("EASYDFS_PROFILE", defaultProfile), "使用的 profile")
+	endpoint := fs.String("endpoint", "", "服务地址")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+		fs.Usage()
+		return exitUsage
+	}
+
+	profile, err := loadProfile(*profileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "easydfs:", err)
+		return exitError
+	}
+	if *endpoint != "" {
+		profile.Endpoint = *endpoint
+	}
+
+	c := &cli{
+		profileName: *profileName,
+		profile:     profile,
+		api:         client.New(profile.Endpoint, profile.AccessKey, profile.SecretKey),
+	}
+	if err := cmd(c, fs.Args()[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitUsage
+		}
+		fmt.Fprintln(os.Stderr, "easydfs:", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// envOr 读取环境变量,未设置时返回默认值
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// exitCode 根据错误类型返回进程退出码,便于脚本判断
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, client.ErrParam):
+		return exitUsage
+	case errors.Is(err, client.ErrDenied), errors.Is(err, client.ErrTokenInvalid):
+		return exitDenied
+	case errors.Is(err, client.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		return exitNotFound
+	}
+	return exitError
+}
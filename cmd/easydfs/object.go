@@ -0,0 +1,387 @@
+/*
+ * @PackageName: main
+ * @FileName: object.go
+ * @Description: 文件相关命令(ls/cp/mv/rm/cat/stat/presign)
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:40:27
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:40:27
+ */
+
+package main
+
+import (
+	"context"
+	"easy_dfs/pkg/client"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 远程路径前缀
+const scheme = "dfs://"
+
+// ctx 命令行请求不设超时,由用户中断
+var ctx = context.Background()
+
+// location 本地路径或远程路径
+type location struct {
+	remote bool
+	bucket string
+	key    string
+	local  string
+}
+
+func (l location) String() string {
+	if l.remote {
+		return scheme + path.Join(l.bucket, l.key)
+	}
+	return l.local
+}
+
+// parseLocation 解析命令行中的路径,dfs://bucket/key 为远程路径,其余为本地路径
+func parseLocation(arg string) (location, error) {
+	if !strings.HasPrefix(arg, scheme) {
+		return location{local: arg}, nil
+	}
+	rest := strings.TrimPrefix(arg, scheme)
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return location{}, fmt.Errorf("远程路径缺少存储桶名称: %s", arg)
+	}
+	// 保留结尾的 /,用于区分目录前缀
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if strings.HasSuffix(key, "/") && cleaned != "" {
+		cleaned += "/"
+	}
+	return location{remote: true, bucket: bucket, key: cleaned}, nil
+}
+
+// parseRemote 解析远程路径,requireKey 为 true 时必须包含文件名
+func parseRemote(arg string, requireKey bool) (location, error) {
+	loc, err := parseLocation(arg)
+	if err != nil {
+		return loc, err
+	}
+	if !loc.remote {
+		return loc, fmt.Errorf("需要远程路径(%sbucket/key): %s", scheme, arg)
+	}
+	if requireKey && (loc.key == "" || strings.HasSuffix(loc.key, "/")) {
+		return loc, fmt.Errorf("远程路径缺少文件名: %s", arg)
+	}
+	return loc, nil
+}
+
+// runList 列出存储桶或文件
+func runList(c *cli, args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		buckets, err := c.api.ListBuckets(ctx)
+		if err != nil {
+			return err
+		}
+		for _, b := range buckets {
+			fmt.Printf("%s\t%s\n", b.Name, b.AccessPolicy)
+		}
+		return nil
+	}
+
+	loc, err := parseRemote(flags.Arg(0), false)
+	if err != nil {
+		return err
+	}
+	keys, err := c.api.ListFiles(ctx, loc.bucket, loc.key)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+	return nil
+}
+
+// transfer 一次文件复制
+type transfer struct {
+	src, dst location
+}
+
+// runCopy 复制文件
+func runCopy(c *cli, args []string) error {
+	_, err := c.copyCommand("cp", args)
+	return err
+}
+
+// runMove 移动文件,全部复制成功后再删除源文件
+func runMove(c *cli, args []string) error {
+	transfers, err := c.copyCommand("mv", args)
+	if err != nil {
+		return err
+	}
+	for _, t := range transfers {
+		if t.src.remote {
+			err = c.api.DeleteFile(ctx, t.src.bucket, t.src.key)
+		} else {
+			err = os.Remove(t.src.local)
+		}
+		if err != nil {
+			return fmt.Errorf("删除源文件 %s 失败: %w", t.src, err)
+		}
+	}
+	return nil
+}
+
+// copyCommand 解析 cp/mv 参数并执行复制,返回已完成的复制
+func (c *cli) copyCommand(name string, args []string) ([]transfer, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	recursive := flags.Bool("r", false, "递归复制目录或前缀下的所有文件")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 2 {
+		return nil, fmt.Errorf("用法: easydfs %s [-r] <源> <目标>", name)
+	}
+	src, err := parseLocation(flags.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseLocation(flags.Arg(1))
+	if err != nil {
+		return nil, err
+	}
+	if !src.remote && !dst.remote {
+		return nil, errors.New("源和目标不能都是本地路径")
+	}
+
+	transfers, err := c.planTransfers(src, dst, *recursive)
+	if err != nil {
+		return nil, err
+	}
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("%w: %s", client.ErrNotFound, src)
+	}
+	for i, t := range transfers {
+		if err := c.copyOne(t.src, t.dst); err != nil {
+			return transfers[:i], fmt.Errorf("%s -> %s: %w", t.src, t.dst, err)
+		}
+		if t.dst.local != "-" {
+			fmt.Fprintf(os.Stderr, "%s -> %s\n", t.src, t.dst)
+		}
+	}
+	return transfers, nil
+}
+
+// planTransfers 展开需要复制的文件列表
+func (c *cli) planTransfers(src, dst location, recursive bool) ([]transfer, error) {
+	var sources []location
+	var rels []string
+
+	if src.remote {
+		if recursive {
+			keys, err := c.api.ListFiles(ctx, src.bucket, src.key)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				sources = append(sources, location{remote: true, bucket: src.bucket, key: key})
+				rels = append(rels, strings.TrimPrefix(strings.TrimPrefix(key, src.key), "/"))
+			}
+		} else {
+			if src.key == "" || strings.HasSuffix(src.key, "/") {
+				return nil, fmt.Errorf("%s 是前缀,复制前缀下的所有文件需使用 -r", src)
+			}
+			sources = append(sources, src)
+			rels = append(rels, path.Base(src.key))
+		}
+	} else {
+		info, err := os.Stat(src.local)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			if !recursive {
+				return nil, fmt.Errorf("%s 是目录,复制目录需使用 -r", src)
+			}
+			err := filepath.WalkDir(src.local, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(src.local, p)
+				if err != nil {
+					return err
+				}
+				sources = append(sources, location{local: p})
+				rels = append(rels, filepath.ToSlash(rel))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			sources = append(sources, src)
+			rels = append(rels, filepath.Base(src.local))
+		}
+	}
+
+	// 目标为目录(递归复制、以 / 结尾、本地已存在的目录或存储桶根目录)时追加相对路径
+	dstIsDir := recursive
+	if dst.remote {
+		dstIsDir = dstIsDir || dst.key == "" || strings.HasSuffix(dst.key, "/")
+	} else if dst.local != "-" {
+		info, err := os.Stat(dst.local)
+		dstIsDir = dstIsDir || strings.HasSuffix(dst.local, string(os.PathSeparator)) || (err == nil && info.IsDir())
+	}
+
+	transfers := make([]transfer, 0, len(sources))
+	for i, s := range sources {
+		d := dst
+		if dstIsDir {
+			if d.remote {
+				d.key = path.Join(d.key, rels[i])
+			} else {
+				d.local = filepath.Join(d.local, filepath.FromSlash(rels[i]))
+			}
+		}
+		transfers = append(transfers, transfer{src: s, dst: d})
+	}
+	return transfers, nil
+}
+
+// copyOne 复制单个文件,远程之间的复制先下载到临时文件再上传
+func (c *cli) copyOne(src, dst location) error {
+	switch {
+	case !src.remote:
+		_, err := c.api.UploadFile(ctx, dst.bucket, dst.key, src.local)
+		return err
+
+	case !dst.remote:
+		if dst.local == "-" {
+			_, err := c.api.Download(ctx, src.bucket, src.key, os.Stdout)
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst.local), 0755); err != nil {
+			return err
+		}
+		// 先写入临时文件,下载完成后再重命名,避免失败时留下不完整的文件
+		tmp, err := os.CreateTemp(filepath.Dir(dst.local), ".easydfs-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := c.api.Download(ctx, src.bucket, src.key, tmp); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp.Name(), dst.local)
+
+	default:
+		tmp, err := os.CreateTemp("", "easydfs-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		size, err := c.api.Download(ctx, src.bucket, src.key, tmp)
+		if err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err = c.api.Upload(ctx, dst.bucket, dst.key, tmp, size)
+		return err
+	}
+}
+
+// runRemove 删除文件
+func runRemove(c *cli, args []string) error {
+	flags := flag.NewFlagSet("rm", flag.ContinueOnError)
+	recursive := flags.Bool("r", false, "删除前缀下的所有文件")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("用法: easydfs rm [-r] dfs://bucket/key")
+	}
+	loc, err := parseRemote(flags.Arg(0), !*recursive)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{loc.key}
+	if *recursive {
+		if keys, err = c.api.ListFiles(ctx, loc.bucket, loc.key); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if err := c.api.DeleteFile(ctx, loc.bucket, key); err != nil {
+			return fmt.Errorf("%s%s/%s: %w", scheme, loc.bucket, key, err)
+		}
+		fmt.Fprintf(os.Stderr, "已删除 %s%s/%s\n", scheme, loc.bucket, key)
+	}
+	return nil
+}
+
+// runCat 输出文件内容
+func runCat(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errors.New("用法: easydfs cat dfs://bucket/key")
+	}
+	loc, err := parseRemote(args[0], true)
+	if err != nil {
+		return err
+	}
+	_, err = c.api.Download(ctx, loc.bucket, loc.key, os.Stdout)
+	return err
+}
+
+// runStat 查看文件信息
+func runStat(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errors.New("用法: easydfs stat dfs://bucket/key")
+	}
+	loc, err := parseRemote(args[0], true)
+	if err != nil {
+		return err
+	}
+	info, err := c.api.StatFile(ctx, loc.bucket, loc.key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Bucket:\t%s\nKey:\t%s\nName:\t%s\nExt:\t%s\nSize:\t%d\n", loc.bucket, loc.key, info.FileName, info.FileExt, info.FileSize)
+	return nil
+}
+
+// runPresign 生成签名访问地址,签名在本地计算,无需请求服务端
+func runPresign(c *cli, args []string) error {
+	flags := flag.NewFlagSet("presign", flag.ContinueOnError)
+	expires := flags.Duration("expires", time.Hour, "有效时长")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("用法: easydfs presign [-expires 1h] dfs://bucket/key")
+	}
+	loc, err := parseRemote(flags.Arg(0), true)
+	if err != nil {
+		return err
+	}
+	if c.profile.AccessKey == "" || c.profile.SecretKey == "" {
+		return errors.New("生成签名地址需要访问密钥,请先执行 easydfs configure")
+	}
+	fmt.Println(c.api.PresignURL(loc.bucket, loc.key, *expires))
+	return nil
+}
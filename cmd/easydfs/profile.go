@@ -0,0 +1,157 @@
+/*
+ * @PackageName: main
+ * @FileName: profile.go
+ * @Description: 命令行客户端配置(profile)
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:24:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:24:40
+ */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultProfile  = "default"
+	defaultEndpoint = "http://localhost:18088"
+)
+
+// Profile 一组服务地址及访问密钥
+type Profile struct {
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+// profileConfig 配置文件内容
+type profileConfig struct {
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// configPath 返回配置文件路径
+func configPath() (string, error) {
+	if p := os.Getenv("EASYDFS_CONFIG"); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".easydfs", "config.json"), nil
+}
+
+// readProfileConfig 读取配置文件,文件不存在时返回空配置
+func readProfileConfig() (profileConfig, error) {
+	cfg := profileConfig{Profiles: map[string]Profile{}}
+	p, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	byteValue, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := json.Unmarshal(byteValue, &cfg); err != nil {
+		return cfg, fmt.Errorf("配置文件 %s 格式有误: %w", p, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	return cfg, nil
+}
+
+// writeProfileConfig 写入配置文件,包含秘钥密码,仅当前用户可读写
+func writeProfileConfig(cfg profileConfig) error {
+	p, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	byteValue, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, byteValue, 0600)
+}
+
+// loadProfile 读取 profile,环境变量优先于配置文件
+func loadProfile(name string) (Profile, error) {
+	cfg, err := readProfileConfig()
+	if err != nil {
+		return Profile{}, err
+	}
+	profile := cfg.Profiles[name]
+	profile.Endpoint = envOr("EASYDFS_ENDPOINT", profile.Endpoint)
+	profile.AccessKey = envOr("EASYDFS_ACCESS_KEY", profile.AccessKey)
+	profile.SecretKey = envOr("EASYDFS_SECRET_KEY", profile.SecretKey)
+	if profile.Endpoint == "" {
+		profile.Endpoint = defaultEndpoint
+	}
+	return profile, nil
+}
+
+// runConfigure 配置 profile,未通过参数指定的项从标准输入读取,直接回车保留原值
+func runConfigure(c *cli, args []string) error {
+	fs := flag.NewFlagSet("configure", flag.ContinueOnError)
+	endpoint := fs.String("endpoint", "", "服务地址")
+	accessKey := fs.String("access-key", "", "访问密钥")
+	secretKey := fs.String("secret-key", "", "秘钥密码")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := readProfileConfig()
+	if err != nil {
+		return err
+	}
+	profile := cfg.Profiles[c.profileName]
+	if profile.Endpoint == "" {
+		profile.Endpoint = defaultEndpoint
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	prompt := func(label, flagValue, current string) string {
+		if flagValue != "" {
+			return flagValue
+		}
+		fmt.Printf("%s [%s]: ", label, mask(label, current))
+		line, _ := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+		return current
+	}
+	profile.Endpoint = prompt("Endpoint", *endpoint, profile.Endpoint)
+	profile.AccessKey = prompt("Access Key", *accessKey, profile.AccessKey)
+	profile.SecretKey = prompt("Secret Key", *secretKey, profile.SecretKey)
+
+	cfg.Profiles[c.profileName] = profile
+	if err := writeProfileConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Printf("profile %s 已保存\n", c.profileName)
+	return nil
+}
+
+// mask 提示时隐藏秘钥密码
+func mask(label, value string) string {
+	if label != "Secret Key" || len(value) <= 4 {
+		return value
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
@@ -0,0 +1,80 @@
+/*
+ * @PackageName: main
+ * @FileName: main.go
+ * @Description: 本地测试单点登录用的模拟 OIDC 身份提供方
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 07:18:30
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 07:18:30
+ */
+
+package main
+
+import (
+	"easy_dfs/pkg/oidc/oidctest"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// userFlags 可重复指定的 --user 参数
+type userFlags []oidctest.User
+
+func (u *userFlags) String() string {
+	return fmt.Sprint(len(*u))
+}
+
+// Set 解析 用户名=用户组1,用户组2 格式的参数
+func (u *userFlags) Set(value string) error {
+	name, groups, _ := strings.Cut(value, "=")
+	if name == "" {
+		return fmt.Errorf("用户名不能为空: %s", value)
+	}
+	user := oidctest.User{Username: name}
+	for _, group := range strings.Split(groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			user.Groups = append(user.Groups, group)
+		}
+	}
+	*u = append(*u, user)
+	return nil
+}
+
+func main() {
+	var users userFlags
+	addr := flag.String("addr", "127.0.0.1:18100", "监听地址")
+	issuer := flag.String("issuer", "", "签发方地址,默认 http://<监听地址>")
+	clientID := flag.String("client-id", "easydfs", "客户端 ID")
+	clientSecret := flag.String("client-secret", "", "客户端密钥,为空时不校验")
+	flag.Var(&users, "user", "预置用户,格式 用户名=用户组1,用户组2,可重复指定")
+	flag.Parse()
+
+	if *issuer == "" {
+		*issuer = "http://" + *addr
+	}
+	if len(users) == 0 {
+		_ = users.Set("alice=easydfs-admins")
+	}
+
+	provider, err := oidctest.New(strings.TrimSuffix(*issuer, "/"), *clientID, *clientSecret)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, user := range users {
+		// 重启后 sub 保持不变,否则同一用户再次登录会被视为其他账号
+		user.Subject = "mock-" + user.Username
+		if err := provider.AddUser(user); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("用户 %s(sub %s) 用户组 %v\n", user.Username, user.Subject, user.Groups)
+	}
+	fmt.Printf("模拟 OIDC 身份提供方 %s 已启动,客户端 ID %s\n", provider.Issuer, *clientID)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
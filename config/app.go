@@ -31,6 +31,12 @@ func init() {
 			"timezone": config.Env("app.timezone", "Asia/Shanghai"),
 			// API 域名，未设置的话所有 API URL 加 api 前缀，如 http://domain.com/api/v1/users
 			"api_domain": config.Env("app.api-url", ""),
+			// 信任的反向代理IP或网段，逗号分隔，仅信任这些代理转发的 X-Forwarded-For，未设置时使用连接的来源IP
+			"trusted_proxies": config.Env("app.trusted-proxies", ""),
+			// 收到退出信号后，等待多久再停止接收新请求，便于负载均衡感知 /readyz 失败，单位：秒
+			"drain_delay": config.Env("app.drain-delay", 0),
+			// 等待进行中的请求（如上传、下载）完成的最长时间，超时后强制退出，单位：秒
+			"drain_timeout": config.Env("app.drain-timeout", 30),
 		}
 	})
 }
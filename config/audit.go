@@ -0,0 +1,22 @@
+/*
+ * @PackageName: config
+ * @FileName: audit.go
+ * @Description: 审计日志配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:08:15
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:08:15
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("audit", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否记录审计日志（存储桶、密钥、文件的变更操作）
+			"enabled": config.Env("audit.enabled", true),
+		}
+	})
+}
@@ -0,0 +1,24 @@
+/*
+ * @PackageName: config
+ * @FileName: auth.go
+ * @Description: 用户登录及会话令牌配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:04:51
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:04:51
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("auth", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 会话令牌的签名密钥,为空时自动生成并保存在配置目录的 jwt_secret 文件中;多实例部署时需配置为相同的值
+			"jwt_secret": config.Env("auth.jwt-secret", ""),
+			// 会话令牌有效期,单位：小时
+			"session_ttl": config.Env("auth.session-ttl", 24),
+		}
+	})
+}
@@ -0,0 +1,31 @@
+/*
+ * @PackageName: config
+ * @FileName: bandwidth.go
+ * @Description: 带宽限速配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 20:20:11
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 20:20:11
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("bandwidth", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否开启带宽限速
+			"enabled": config.Env("bandwidth.enabled", false),
+			// 以下单位均为 KB/s，0 表示不限制
+			// 下载（/storage、/file/download）：每个连接、每个访问密钥、每个存储桶
+			"download_per_connection": config.Env("bandwidth.download-per-connection", 0),
+			"download_per_key":        config.Env("bandwidth.download-per-key", 0),
+			"download_per_bucket":     config.Env("bandwidth.download-per-bucket", 0),
+			// 上传（写入存储桶）：每个连接、每个访问密钥、每个存储桶
+			"upload_per_connection": config.Env("bandwidth.upload-per-connection", 0),
+			"upload_per_key":        config.Env("bandwidth.upload-per-key", 0),
+			"upload_per_bucket":     config.Env("bandwidth.upload-per-bucket", 0),
+		}
+	})
+}
@@ -0,0 +1,22 @@
+/*
+ * @PackageName: config
+ * @FileName: console.go
+ * @Description: 管理控制台配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 20:26:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 20:26:40
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("console", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否开启内嵌的管理控制台，访问地址 /console/
+			"enabled": config.Env("console.enabled", true),
+		}
+	})
+}
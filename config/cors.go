@@ -0,0 +1,31 @@
+/*
+ * @PackageName: config
+ * @FileName: cors.go
+ * @Description: 跨域配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 21:03:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 21:03:40
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("cors", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 管理接口（/bucket、/access_key）及未指定存储桶的文件接口允许的来源，逗号分隔，支持 https://*.example.com，为空时不允许跨域
+			// 文件相关接口（/storage、/file）优先使用存储桶配置的跨域规则
+			"allowed_origins": config.Env("cors.allowed-origins", ""),
+			// 允许的请求方法
+			"allowed_methods": config.Env("cors.allowed-methods", "GET, POST, PUT, DELETE"),
+			// 允许的请求头
+			"allowed_headers": config.Env("cors.allowed-headers", "Content-Type, X-Access-Key, X-Secret-Key, Authorization"),
+			// 允许浏览器读取的响应头
+			"expose_headers": config.Env("cors.expose-headers", "Content-Disposition, Content-Length, Retry-After"),
+			// 预检请求结果的缓存时间，单位：秒
+			"max_age": config.Env("cors.max-age", 600),
+		}
+	})
+}
@@ -0,0 +1,32 @@
+/*
+ * @PackageName: config
+ * @FileName: image.go
+ * @Description: 图片处理配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 13:22:47
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 13:22:47
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("image", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否开启访问图片时的实时处理(缩放、裁剪、旋转、格式转换)
+			"enabled": config.Env("image.enabled", true),
+			// 输出图片的最大宽度
+			"max_width": config.Env("image.max-width", 4096),
+			// 输出图片的最大高度
+			"max_height": config.Env("image.max-height", 4096),
+			// 原图最大像素数，超出则拒绝处理，防止解码大图耗尽内存
+			"max_source_pixels": config.Env("image.max-source-pixels", 50000000),
+			// 未指定 quality 时的默认输出质量
+			"default_quality": config.Env("image.default-quality", 80),
+			// 处理结果缓存的最大空间(MB),超出时淘汰最久未使用的缓存,0 表示不限制
+			"cache_max_size": config.Env("image.cache-max-size", 1024),
+		}
+	})
+}
@@ -0,0 +1,26 @@
+/*
+ * @PackageName: config
+ * @FileName: metrics.go
+ * @Description: 监控指标配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 15:52:30
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 15:52:30
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("metrics", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否开启 /metrics 监控指标接口
+			"enabled": config.Env("metrics.enabled", false),
+			// 访问令牌，设置后需携带请求头 Authorization: Bearer <token> 访问
+			"token": config.Env("metrics.token", ""),
+			// 存储桶占用空间的统计缓存时间，单位：秒
+			"usage_ttl": config.Env("metrics.usage-ttl", 60),
+		}
+	})
+}
@@ -0,0 +1,43 @@
+/*
+ * @PackageName: config
+ * @FileName: oidc.go
+ * @Description: OIDC 单点登录配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 06:38:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 06:38:12
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("oidc", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否启用 OIDC 单点登录
+			"enabled": config.Env("oidc.enabled", false),
+			// 身份提供方地址,须与其 ID 令牌中的 iss 一致,如 https://sso.example.com/realms/main
+			"issuer": config.Env("oidc.issuer", ""),
+			// 在身份提供方注册的客户端 ID 及密钥,公共客户端的密钥为空
+			"client_id":     config.Env("oidc.client-id", ""),
+			"client_secret": config.Env("oidc.client-secret", ""),
+			// 登录回调地址,为空时使用 app.url + /auth/oidc/callback,须在身份提供方登记
+			"redirect_url": config.Env("oidc.redirect-url", ""),
+			// 请求的授权范围，空格分隔
+			"scopes": config.Env("oidc.scopes", "openid profile email groups"),
+			// 作为用户名的声明,只在首次登录创建用户时使用,之后按 iss 及 sub 声明匹配用户
+			"username_claim": config.Env("oidc.username-claim", "preferred_username"),
+			// 用户组声明
+			"groups_claim": config.Env("oidc.groups-claim", "groups"),
+			// 映射为管理员、存储桶所有者及只读用户的用户组，逗号分隔，同时属于多个时取权限最高的角色
+			"admin_groups":    config.Env("oidc.admin-groups", ""),
+			"owner_groups":    config.Env("oidc.owner-groups", ""),
+			"readonly_groups": config.Env("oidc.readonly-groups", ""),
+			// 不属于以上用户组时的角色，为空时拒绝登录
+			"default_role": config.Env("oidc.default-role", ""),
+			// 用户组被授权的存储桶，如 {team-a: "photos,docs"}，存储桶所有者可以操作所在用户组被授权的存储桶
+			"group_buckets": config.Env("oidc.group-buckets", map[string]interface{}{}),
+		}
+	})
+}
@@ -0,0 +1,35 @@
+/*
+ * @PackageName: config
+ * @FileName: rate_limit.go
+ * @Description: 限流配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:45:36
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:45:36
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("rate_limit", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否开启限流，超出限制时返回 40029 及 Retry-After 响应头
+			"enabled": config.Env("rate_limit.enabled", false),
+			// 以下 rate 为每秒请求数，0 表示不限制；burst 为允许的突发请求数，0 时取 rate 向上取整
+			// 接口请求（/bucket、/file、/access_key）：全局、每个访问密钥、每个客户端IP
+			"api_global_rate":  config.Env("rate_limit.api-global-rate", 0),
+			"api_global_burst": config.Env("rate_limit.api-global-burst", 0),
+			"api_key_rate":     config.Env("rate_limit.api-key-rate", 20),
+			"api_key_burst":    config.Env("rate_limit.api-key-burst", 40),
+			"api_ip_rate":      config.Env("rate_limit.api-ip-rate", 20),
+			"api_ip_burst":     config.Env("rate_limit.api-ip-burst", 40),
+			// 公开文件下载（/storage）：全局、每个客户端IP
+			"storage_global_rate":  config.Env("rate_limit.storage-global-rate", 0),
+			"storage_global_burst": config.Env("rate_limit.storage-global-burst", 0),
+			"storage_ip_rate":      config.Env("rate_limit.storage-ip-rate", 50),
+			"storage_ip_burst":     config.Env("rate_limit.storage-ip-burst", 100),
+		}
+	})
+}
@@ -0,0 +1,28 @@
+/*
+ * @PackageName: config
+ * @FileName: scrub.go
+ * @Description: 数据完整性巡检配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 02:10:26
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 02:10:26
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("scrub", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 是否定期巡检,关闭后仍可通过接口手动触发
+			"enabled": config.Env("scrub.enabled", true),
+			// 巡检间隔,单位：小时,服务启动后经过一个间隔开始首次巡检
+			"interval": config.Env("scrub.interval", 24),
+			// 巡检读取文件的速度上限，单位：KB/s，0 表示不限速；限速可避免影响正常的上传下载
+			"rate": config.Env("scrub.rate", 10240),
+			// 巡检报告中最多记录的问题条数
+			"max_issues": config.Env("scrub.max-issues", 1000),
+		}
+	})
+}
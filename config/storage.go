@@ -0,0 +1,26 @@
+/*
+ * @PackageName: config
+ * @FileName: storage.go
+ * @Description: 文件访问配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:14:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:14:52
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("storage", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 私有存储桶的文件是否只能通过签名URL访问，开启前私有存储桶的文件URL仍可直接访问
+			"enforce_private": config.Env("storage.enforce-private", false),
+			// 虚拟主机访问的根域名，如 dfs.example.com，设置后可通过 http://<bucket>.dfs.example.com/<文件名> 访问文件
+			"domain": config.Env("storage.domain", ""),
+			// 写入文件后是否同步到磁盘(fsync),关闭可提升写入性能,但断电或宕机时可能丢失刚上传的文件
+			"fsync": config.Env("storage.fsync", true),
+		}
+	})
+}
@@ -0,0 +1,30 @@
+/*
+ * @PackageName: config
+ * @FileName: webhook.go
+ * @Description: 事件通知配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 14:25:48
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 14:25:48
+ */
+
+package config
+
+import "easy_dfs/pkg/config"
+
+func init() {
+	config.Add("webhook", func() map[string]interface{} {
+		return map[string]interface{}{
+			// 单次投递的超时时间，单位：秒
+			"timeout": config.Env("webhook.timeout", 10),
+			// 最多尝试投递次数，超出后标记为失败
+			"max_attempts": config.Env("webhook.max-attempts", 8),
+			// 首次重试的等待时间，之后每次翻倍，单位：秒
+			"retry_base": config.Env("webhook.retry-base", 5),
+			// 重试等待时间上限，单位：秒
+			"retry_max": config.Env("webhook.retry-max", 3600),
+			// 保留的已完成投递记录条数
+			"max_history": config.Env("webhook.max-history", 1000),
+		}
+	})
+}
@@ -11,9 +11,10 @@
 package main
 
 import (
-	"easy_dfs/bootstrap"
+	"easy_dfs/app/cmd"
 	btsConfig "easy_dfs/config"
 	"log"
+	"os"
 )
 
 func init() {
@@ -25,9 +26,6 @@ func main() {
 	// 设置日志格式: 日期 时间 状态:成功/失败(成功则是绿色,失败则是红色)
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
-	// 程序启动时打印
-	log.Println("Server starting...")
-	bootstrap.SetupLogger()
-	bootstrap.SetupConfigDir()
-	bootstrap.SetupRoute()
+	// 执行子命令,未指定时启动服务
+	os.Exit(cmd.Execute(os.Args[1:]))
 }
@@ -22,4 +22,6 @@ type AccessKeyInfo struct {
 	ExpireTime string `json:"expireTime"`
 	// 状态:1=启用,-1=禁用
 	Status int `json:"status"`
+	// 所属用户名,为空时为升级前创建的密钥,视为管理员
+	Owner string `json:"owner,omitempty"`
 }
@@ -0,0 +1,45 @@
+/*
+ * @PackageName: model
+ * @FileName: audit.go
+ * @Description: 审计记录
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:02:13
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:02:13
+ */
+
+package model
+
+// AuditRecord 审计记录,每次变更操作一条
+type AuditRecord struct {
+	// 记录ID
+	ID string `json:"id"`
+	// 操作时间
+	Time string `json:"time"`
+	// 操作人(访问密钥名称,使用会话令牌时为用户名),未通过校验时为空
+	Actor string `json:"actor,omitempty"`
+	// 发起请求的用户名
+	User string `json:"user,omitempty"`
+	// 请求携带的访问密钥
+	AccessKey string `json:"accessKey,omitempty"`
+	// 操作类型
+	Action string `json:"action"`
+	// 存储桶名称
+	Bucket string `json:"bucket,omitempty"`
+	// 文件名
+	Object string `json:"object,omitempty"`
+	// 其他操作对象,如访问密钥名称、重命名后的文件名
+	Resource string `json:"resource,omitempty"`
+	// 文件版本
+	Version string `json:"version,omitempty"`
+	// 客户端IP
+	IP string `json:"ip"`
+	// 客户端标识
+	UserAgent string `json:"userAgent,omitempty"`
+	// 是否成功
+	Success bool `json:"success"`
+	// 返回码
+	Code string `json:"code"`
+	// 失败原因
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,35 @@
+/*
+ * @PackageName: model
+ * @FileName: bucket_delete_job.go
+ * @Description: 强制删除存储桶的后台任务
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 03:48:17
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 03:48:17
+ */
+
+package model
+
+// BucketDeleteJob 强制删除存储桶的任务进度
+type BucketDeleteJob struct {
+	// 任务 ID
+	ID string `json:"id"`
+	// 存储桶名称
+	Bucket string `json:"bucket"`
+	// 存储桶所有者
+	Owner string `json:"owner,omitempty"`
+	// 状态:running、completed、failed
+	Status string `json:"status"`
+	// 待删除的文件数
+	Total int64 `json:"total"`
+	// 已删除的文件数
+	Deleted int64 `json:"deleted"`
+	// 已删除的字节数
+	Bytes int64 `json:"bytes"`
+	// 失败原因
+	Error string `json:"error,omitempty"`
+	// 开始时间
+	StartedAt string `json:"startedAt"`
+	// 结束时间
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
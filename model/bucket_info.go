@@ -10,6 +10,12 @@
 
 package model
 
+import (
+	"easy_dfs/pkg/cors"
+	"easy_dfs/pkg/referer"
+	"easy_dfs/pkg/website"
+)
+
 type BucketInfo struct {
 	// 存储桶名称
 	Name string `json:"name"`
@@ -17,4 +23,30 @@ type BucketInfo struct {
 	AccessPolicy string `json:"accessPolicy"`
 	// 存储类型
 	StorageType string `json:"storageType"`
+	// 事件通知配置
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+	// 跨域规则,为空时不允许跨域访问文件
+	Cors []cors.Rule `json:"cors,omitempty"`
+	// 防盗链规则,为空时不校验请求来源
+	Referer *referer.Rule `json:"referer,omitempty"`
+	// 自定义域名,解析到本服务后可通过 http://<域名>/<文件名> 访问存储桶内的文件
+	Domains []string `json:"domains,omitempty"`
+	// 静态网站托管配置,为空时不开启
+	Website *website.Config `json:"website,omitempty"`
+	// 描述
+	Description string `json:"description,omitempty"`
+	// 标签
+	Tags map[string]string `json:"tags,omitempty"`
+	// 配额,为空时不限制
+	Quota *BucketQuota `json:"quota,omitempty"`
+	// 所有者用户名,为空时只有管理员可以管理
+	Owner string `json:"owner,omitempty"`
+}
+
+// BucketQuota 存储桶配额,超出时拒绝上传;为 0 的项不限制
+type BucketQuota struct {
+	// 最大占用空间(字节)
+	MaxSize int64 `json:"maxSize,omitempty"`
+	// 最大文件数
+	MaxObjects int64 `json:"maxObjects,omitempty"`
 }
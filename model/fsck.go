@@ -0,0 +1,40 @@
+/*
+ * @PackageName: model
+ * @FileName: fsck.go
+ * @Description: 数据一致性检查发现的问题及修复结果
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 03:05:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 03:05:52
+ */
+
+package model
+
+// FsckIssue 检查发现的问题
+type FsckIssue struct {
+	// 问题类型
+	Type string `json:"type"`
+	// 问题对象,如存储桶名称、配置文件路径
+	Target string `json:"target"`
+	// 问题描述
+	Message string `json:"message"`
+	// 所属存储桶
+	Bucket string `json:"bucket,omitempty"`
+	// 所属文件,仅文件级别的问题有值
+	Key string `json:"key,omitempty"`
+	// 可用的修复方式,为空表示需要手动处理
+	Actions []string `json:"actions,omitempty"`
+}
+
+// FsckResult 修复结果
+type FsckResult struct {
+	FsckIssue
+	// 执行的修复方式
+	Action string `json:"action"`
+	// 是否已执行,试运行时为 false
+	Applied bool `json:"applied"`
+	// 隔离时文件移动到的位置
+	Destination string `json:"destination,omitempty"`
+	// 执行失败的原因
+	Error string `json:"error,omitempty"`
+}
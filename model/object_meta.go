@@ -0,0 +1,27 @@
+/*
+ * @PackageName: model
+ * @FileName: object_meta.go
+ * @Description: 文件元数据
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 00:12:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 00:12:40
+ */
+
+package model
+
+// ObjectMeta 文件元数据,上传时记录摘要,用于 ETag 及完整性校验
+type ObjectMeta struct {
+	// 上传时指定的内容类型,为空时按文件内容检测
+	ContentType string `json:"contentType,omitempty"`
+	// 文件大小
+	Size int64 `json:"size"`
+	// 文件内容的 MD5,十六进制编码,同时作为 ETag
+	MD5 string `json:"md5"`
+	// 文件内容的 SHA-256,十六进制编码
+	SHA256 string `json:"sha256"`
+	// 自定义元数据,来自 X-Meta-* 请求头,键为小写
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// 上传时间
+	LastModified string `json:"lastModified"`
+}
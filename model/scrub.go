@@ -0,0 +1,53 @@
+/*
+ * @PackageName: model
+ * @FileName: scrub.go
+ * @Description: 数据完整性巡检报告
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 02:14:09
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 02:14:09
+ */
+
+package model
+
+// ScrubReport 数据完整性巡检报告
+type ScrubReport struct {
+	// 巡检ID
+	ID string `json:"id"`
+	// 触发方式:schedule 定期巡检,manual 手动触发
+	Trigger string `json:"trigger"`
+	// 状态:running 进行中,completed 已完成,failed 异常中止
+	Status string `json:"status"`
+	// 开始及结束时间
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	// 已检查的文件数及字节数
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+	// 校验通过的文件数
+	Verified int64 `json:"verified"`
+	// 没有记录摘要、无法校验的文件数(如升级前上传的文件)
+	Unverified int64 `json:"unverified"`
+	// 内容与记录的摘要或大小不一致的文件数
+	Corrupted int64 `json:"corrupted"`
+	// 记录了元数据但文件已不存在的数量
+	Missing int64 `json:"missing"`
+	// 读取失败的文件数
+	Failed int64 `json:"failed"`
+	// 发现的问题,超出上限时不再记录,但仍计入上述数量
+	Issues []ScrubIssue `json:"issues"`
+	// 异常中止的原因
+	Error string `json:"error,omitempty"`
+}
+
+// ScrubIssue 巡检发现的问题
+type ScrubIssue struct {
+	// 问题类型:corrupted 内容不一致,size_mismatch 大小不一致,missing 文件丢失,read_error 读取失败
+	Type   string `json:"type"`
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	// 记录的值及实际的值
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
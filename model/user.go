@@ -0,0 +1,57 @@
+/*
+ * @PackageName: model
+ * @FileName: user.go
+ * @Description: 用户信息
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:08:37
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:08:37
+ */
+
+package model
+
+// UserInfo 用户信息
+type UserInfo struct {
+	// 用户名
+	Username string `json:"username"`
+	// 密码的 bcrypt 哈希,接口返回时清空
+	Password string `json:"password,omitempty"`
+	// 角色:admin、bucket-owner、read-only
+	Role string `json:"role"`
+	// 状态:1=启用,-1=禁用
+	Status int `json:"status"`
+	// 令牌版本,修改密码或禁用后递增,使已签发的会话令牌失效
+	TokenVersion int `json:"tokenVersion"`
+	// 身份来源,通过 OIDC 单点登录自动创建的用户为 oidc,本地用户为空
+	Provider string `json:"provider,omitempty"`
+	// 单点登录用户的签发方(iss 声明),与 Subject 一起唯一标识身份提供方中的账号
+	Issuer string `json:"issuer,omitempty"`
+	// 单点登录用户在身份提供方中的唯一标识(sub 声明),单点登录时按签发方及此标识匹配用户,不按用户名
+	Subject string `json:"subject,omitempty"`
+	// 身份提供方声明的用户组,每次单点登录时更新,用于计算被授权的存储桶
+	Groups []string `json:"groups,omitempty"`
+	// 创建时间
+	CreatedAt string `json:"createdAt"`
+}
+
+// Principal 通过校验的请求方
+type Principal struct {
+	// 用户名,使用升级前创建的访问密钥时为空
+	Username string `json:"username,omitempty"`
+	// 角色
+	Role string `json:"role"`
+	// 使用访问密钥时为访问密钥,使用会话令牌时为空
+	AccessKey string `json:"accessKey,omitempty"`
+	// 通过用户组被授权的存储桶,存储桶所有者除自己的存储桶外还可以操作这些存储桶
+	Buckets []string `json:"buckets,omitempty"`
+}
+
+// LoginResult 登录结果
+type LoginResult struct {
+	// 会话令牌,请求时放在 Authorization: Bearer <令牌> 请求头中
+	Token string `json:"token"`
+	// 过期时间
+	ExpiresAt string `json:"expiresAt"`
+	// 用户信息
+	User UserInfo `json:"user"`
+}
@@ -0,0 +1,65 @@
+/*
+ * @PackageName: model
+ * @FileName: webhook.go
+ * @Description: 事件通知及投递记录
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 14:22:31
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 14:22:31
+ */
+
+package model
+
+// WebhookConfig 存储桶的事件通知配置
+type WebhookConfig struct {
+	// 接收通知的地址
+	URL string `json:"url"`
+	// 签名密钥,用于计算 HMAC-SHA256 签名;接口返回时清空,只通过 HasSecret 表示是否已设置
+	Secret string `json:"secret,omitempty"`
+	// 是否已设置签名密钥,仅在接口返回时设置;修改配置时 Secret 为空且此项为 true 表示沿用同一地址原有的密钥
+	HasSecret bool `json:"hasSecret,omitempty"`
+	// 订阅的事件类型,为空表示订阅全部事件
+	Events []string `json:"events"`
+}
+
+// Event 存储桶事件
+type Event struct {
+	// 事件ID
+	ID string `json:"id"`
+	// 事件类型
+	Type string `json:"type"`
+	// 存储桶名称
+	Bucket string `json:"bucket"`
+	// 文件名,存储桶事件为空
+	Key string `json:"key,omitempty"`
+	// 文件大小
+	Size int64 `json:"size,omitempty"`
+	// 事件发生时间
+	Time string `json:"time"`
+}
+
+// WebhookDelivery 事件投递记录
+type WebhookDelivery struct {
+	// 投递ID
+	ID string `json:"id"`
+	// 事件内容
+	Event Event `json:"event"`
+	// 接收通知的地址
+	URL string `json:"url"`
+	// 签名密钥
+	Secret string `json:"secret,omitempty"`
+	// 投递状态:pending=待投递,success=成功,failed=失败
+	Status string `json:"status"`
+	// 已尝试次数
+	Attempts int `json:"attempts"`
+	// 最近一次响应状态码
+	LastStatusCode int `json:"lastStatusCode"`
+	// 最近一次错误信息
+	LastError string `json:"lastError"`
+	// 下次尝试时间(Unix 秒)
+	NextAttemptAt int64 `json:"nextAttemptAt"`
+	// 创建时间
+	CreatedAt string `json:"createdAt"`
+	// 更新时间
+	UpdatedAt string `json:"updatedAt"`
+}
@@ -0,0 +1,49 @@
+/*
+ * @PackageName: audit
+ * @FileName: audit.go
+ * @Description: 在请求上下文中传递审计信息
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:06:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:06:52
+ */
+
+package audit
+
+import "github.com/gin-gonic/gin"
+
+// 上下文中保存操作对象的键
+const (
+	bucketKey   = "audit.bucket"
+	objectKey   = "audit.object"
+	versionKey  = "audit.version"
+	resourceKey = "audit.resource"
+)
+
+// SetTarget 记录本次请求操作的存储桶及文件,由控制器在解析参数后调用
+func SetTarget(ctx *gin.Context, bucket, object string) {
+	ctx.Set(bucketKey, bucket)
+	if object != "" {
+		ctx.Set(objectKey, object)
+	}
+}
+
+// SetVersion 记录本次请求操作的文件版本
+func SetVersion(ctx *gin.Context, version string) {
+	ctx.Set(versionKey, version)
+}
+
+// SetResource 记录本次请求的其他操作对象,如访问密钥名称、重命名后的文件名
+func SetResource(ctx *gin.Context, resource string) {
+	ctx.Set(resourceKey, resource)
+}
+
+// Resource 返回控制器记录的其他操作对象
+func Resource(ctx *gin.Context) string {
+	return ctx.GetString(resourceKey)
+}
+
+// Target 返回控制器记录的存储桶、文件及版本
+func Target(ctx *gin.Context) (bucket, object, version string) {
+	return ctx.GetString(bucketKey), ctx.GetString(objectKey), ctx.GetString(versionKey)
+}
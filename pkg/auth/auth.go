@@ -0,0 +1,55 @@
+/*
+ * @PackageName: auth
+ * @FileName: auth.go
+ * @Description: 在请求上下文中传递通过校验的请求方
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:10:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:10:12
+ */
+
+package auth
+
+import (
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/model"
+	"github.com/gin-gonic/gin"
+)
+
+// 上下文中保存请求方的键
+const principalKey = "auth.principal"
+
+// SetPrincipal 记录通过校验的请求方,由密钥校验中间件调用
+func SetPrincipal(ctx *gin.Context, principal model.Principal) {
+	ctx.Set(principalKey, principal)
+}
+
+// Principal 返回通过校验的请求方,未经校验时返回 false
+func Principal(ctx *gin.Context) (model.Principal, bool) {
+	value, ok := ctx.Get(principalKey)
+	if !ok {
+		return model.Principal{}, false
+	}
+	principal, ok := value.(model.Principal)
+	return principal, ok
+}
+
+// IsAdmin 请求方是否为管理员
+func IsAdmin(ctx *gin.Context) bool {
+	principal, ok := Principal(ctx)
+	return ok && principal.Role == user_role.ADMIN
+}
+
+// 上下文中保存请求操作的存储桶的键
+const bucketKey = "auth.bucket"
+
+// SetBucket 记录请求操作的存储桶,由权限校验中间件调用
+func SetBucket(ctx *gin.Context, bucket string) {
+	ctx.Set(bucketKey, bucket)
+}
+
+// Bucket 返回权限校验中间件解析出的存储桶名称,控制器只应使用该名称,
+// 避免校验的存储桶与实际操作的存储桶不一致
+func Bucket(ctx *gin.Context) string {
+	return ctx.GetString(bucketKey)
+}
@@ -0,0 +1,222 @@
+/*
+ * @PackageName: bandwidth
+ * @FileName: bandwidth.go
+ * @Description: 带宽限速,以令牌桶限制读写的字节速率
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 20:12:45
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 20:12:45
+ */
+
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// 单次读写的最大字节数,避免一次消耗过多令牌导致长时间停顿
+const chunkSize = 32 * 1024
+
+// 空闲多久的限速器会被清理
+const idleTimeout = 10 * time.Minute
+
+// Limiter 字节速率限速器,可被多个传输共享,共享时按预约顺序排队
+type Limiter struct {
+	rate  float64 // 每秒字节数
+	burst float64 // 最多累积的字节数
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter 创建限速器,bytesPerSecond <= 0 时返回 nil,表示不限速
+func NewLimiter(bytesPerSecond int64) *Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	burst := float64(bytesPerSecond)
+	if burst < chunkSize {
+		burst = chunkSize
+	}
+	return &Limiter{rate: float64(bytesPerSecond), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve 预约 n 个字节,返回需要等待的时间,令牌可透支以保证共享时的先后顺序
+func (l *Limiter) reserve(n int) time.Duration {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// lastUsed 最近一次使用时间
+func (l *Limiter) lastUsed() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// Group 按 key(如访问密钥、存储桶)区分的限速器集合
+type Group struct {
+	bytesPerSecond int64
+
+	mu          sync.Mutex
+	limiters    map[string]*Limiter
+	lastCleanup time.Time
+}
+
+// NewGroup 创建限速器集合,bytesPerSecond <= 0 时返回 nil,表示不限速
+func NewGroup(bytesPerSecond int64) *Group {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &Group{bytesPerSecond: bytesPerSecond, limiters: make(map[string]*Limiter), lastCleanup: time.Now()}
+}
+
+// Get 返回 key 对应的限速器,同一 key 的传输共享速率
+func (g *Group) Get(key string) *Limiter {
+	if g == nil || key == "" {
+		return nil
+	}
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// 定期清理长时间未使用的限速器,避免 key 数量无限增长
+	if now.Sub(g.lastCleanup) >= idleTimeout {
+		g.lastCleanup = now
+		for k, l := range g.limiters {
+			if now.Sub(l.lastUsed()) >= idleTimeout {
+				delete(g.limiters, k)
+			}
+		}
+	}
+
+	l, ok := g.limiters[key]
+	if !ok {
+		l = NewLimiter(g.bytesPerSecond)
+		g.limiters[key] = l
+	}
+	return l
+}
+
+// Throttle 一次传输的限速,同时受多个限速器约束
+type Throttle struct {
+	ctx      context.Context
+	limiters []*Limiter
+	// OnWait 每次因限速等待后调用,可用于统计
+	OnWait func(wait time.Duration)
+}
+
+// NewThrottle 创建限速,忽略为 nil 的限速器,全部为 nil 时返回 nil,表示不限速
+func NewThrottle(ctx context.Context, limiters ...*Limiter) *Throttle {
+	t := &Throttle{ctx: ctx}
+	for _, l := range limiters {
+		if l != nil {
+			t.limiters = append(t.limiters, l)
+		}
+	}
+	if len(t.limiters) == 0 {
+		return nil
+	}
+	return t
+}
+
+// wait 向所有限速器预约 n 个字节并等待最长的时间,ctx 取消时返回错误
+func (t *Throttle) wait(n int) error {
+	var wait time.Duration
+	for _, l := range t.limiters {
+		if d := l.reserve(n); d > wait {
+			wait = d
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+	if t.OnWait != nil {
+		t.OnWait(wait)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
+
+// Reader 返回限速后的 Reader,t 为 nil 时原样返回
+func (t *Throttle) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &reader{r: r, t: t}
+}
+
+// Writer 返回限速后的 Writer,t 为 nil 时原样返回
+func (t *Throttle) Writer(w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return &writer{w: w, t: t}
+}
+
+type reader struct {
+	r io.Reader
+	t *Throttle
+}
+
+// Read 先读取再按实际读取的字节数等待
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.t.wait(n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+type writer struct {
+	w io.Writer
+	t *Throttle
+}
+
+// Write 按块等待后写入
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		if err := w.t.wait(len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,50 @@
+/*
+ * @PackageName: bucketname
+ * @FileName: bucketname.go
+ * @Description: 存储桶名称校验,名称需兼容 DNS,以便用作自定义域名及虚拟主机的子域名
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 04:21:09
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 04:21:09
+ */
+
+package bucketname
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// 名称长度限制
+const (
+	MinLength = 3
+	MaxLength = 63
+)
+
+// Validate 校验存储桶名称:3 到 63 个字符,只能包含小写字母、数字、点及短横线,
+// 以字母或数字开头和结尾,点不能与点或短横线相邻,且不能是 IP 地址格式
+func Validate(name string) error {
+	if len(name) < MinLength || len(name) > MaxLength {
+		return errors.New("存储桶名称长度应为 3 到 63 个字符")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-') {
+			return errors.New("存储桶名称只能包含小写字母、数字、点及短横线")
+		}
+	}
+	if !isAlnum(name[0]) || !isAlnum(name[len(name)-1]) {
+		return errors.New("存储桶名称应以小写字母或数字开头和结尾")
+	}
+	if strings.Contains(name, "..") || strings.Contains(name, ".-") || strings.Contains(name, "-.") {
+		return errors.New("存储桶名称中的点不能与点或短横线相邻")
+	}
+	if net.ParseIP(name) != nil {
+		return errors.New("存储桶名称不能是 IP 地址格式")
+	}
+	return nil
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,111 @@
+/*
+ * @PackageName: checksum
+ * @FileName: checksum.go
+ * @Description: 读取数据的同时计算 MD5 及 SHA-256,读取结束时与客户端提供的摘要比对
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 00:05:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 00:05:12
+ */
+
+package checksum
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"strings"
+)
+
+// 摘要不一致时返回的错误
+var (
+	ErrMD5Mismatch    = errors.New("Content-MD5 校验失败")
+	ErrSHA256Mismatch = errors.New("SHA-256 校验失败")
+)
+
+// IsMismatch 是否为摘要不一致的错误
+func IsMismatch(err error) bool {
+	return errors.Is(err, ErrMD5Mismatch) || errors.Is(err, ErrSHA256Mismatch)
+}
+
+// Reader 计算摘要的 Reader,数据读完时校验摘要,不一致时以错误代替 io.EOF 返回
+type Reader struct {
+	r         io.Reader
+	md5       hash.Hash
+	sha256    hash.Hash
+	wantMD5   []byte
+	wantSHA   []byte
+	n         int64
+	verifyErr error
+}
+
+// NewReader 创建计算摘要的 Reader。contentMD5 为 Base64 编码的 MD5(即 Content-MD5 请求头),
+// sha256Hex 为十六进制的 SHA-256,为空时不校验对应的摘要
+func NewReader(r io.Reader, contentMD5, sha256Hex string) (*Reader, error) {
+	cr := &Reader{r: r, md5: md5.New(), sha256: sha256.New()}
+	if contentMD5 != "" {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(contentMD5))
+		if err != nil || len(sum) != md5.Size {
+			return nil, errors.New("Content-MD5 格式有误,应为 Base64 编码的 MD5")
+		}
+		cr.wantMD5 = sum
+	}
+	if sha256Hex != "" {
+		sum, err := hex.DecodeString(strings.TrimSpace(sha256Hex))
+		if err != nil || len(sum) != sha256.Size {
+			return nil, errors.New("SHA-256 格式有误,应为十六进制编码")
+		}
+		cr.wantSHA = sum
+	}
+	return cr, nil
+}
+
+func (cr *Reader) Read(p []byte) (int, error) {
+	if cr.verifyErr != nil {
+		return 0, cr.verifyErr
+	}
+	n, err := cr.r.Read(p)
+	if n > 0 {
+		cr.md5.Write(p[:n])
+		cr.sha256.Write(p[:n])
+		cr.n += int64(n)
+	}
+	if err == io.EOF {
+		if verifyErr := cr.verify(); verifyErr != nil {
+			cr.verifyErr = verifyErr
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+// verify 比对摘要
+func (cr *Reader) verify() error {
+	if cr.wantMD5 != nil && !bytes.Equal(cr.md5.Sum(nil), cr.wantMD5) {
+		return ErrMD5Mismatch
+	}
+	if cr.wantSHA != nil && !bytes.Equal(cr.sha256.Sum(nil), cr.wantSHA) {
+		return ErrSHA256Mismatch
+	}
+	return nil
+}
+
+// N 已读取的字节数
+func (cr *Reader) N() int64 {
+	return cr.n
+}
+
+// MD5 已读取数据的 MD5,十六进制编码
+func (cr *Reader) MD5() string {
+	return hex.EncodeToString(cr.md5.Sum(nil))
+}
+
+// SHA256 已读取数据的 SHA-256,十六进制编码
+func (cr *Reader) SHA256() string {
+	return hex.EncodeToString(cr.sha256.Sum(nil))
+}
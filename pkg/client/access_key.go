@@ -0,0 +1,57 @@
+/*
+ * @PackageName: client
+ * @FileName: access_key.go
+ * @Description: 访问密钥接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:15:31
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:15:31
+ */
+
+package client
+
+import (
+	"context"
+	"easy_dfs/model"
+	"net/http"
+	"net/url"
+)
+
+// CreateAccessKey 为当前用户创建访问密钥,返回的秘钥密码只在创建时可见,需妥善保存
+func (c *Client) CreateAccessKey(ctx context.Context, name, expireTime string) (*model.AccessKeyInfo, error) {
+	var info model.AccessKeyInfo
+	if err := c.callJSON(ctx, http.MethodPost, "/access_key/create", model.AccessKeyInfo{Name: name, ExpireTime: expireTime}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CreateAccessKeyFor 为指定用户创建访问密钥,需管理员权限
+func (c *Client) CreateAccessKeyFor(ctx context.Context, owner, name, expireTime string) (*model.AccessKeyInfo, error) {
+	var info model.AccessKeyInfo
+	if err := c.callJSON(ctx, http.MethodPost, "/access_key/create", model.AccessKeyInfo{Name: name, ExpireTime: expireTime, Owner: owner}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ListAccessKeys 获取访问密钥列表,非管理员只返回自己的密钥
+func (c *Client) ListAccessKeys(ctx context.Context) ([]model.AccessKeyInfo, error) {
+	var list []model.AccessKeyInfo
+	err := c.call(ctx, http.MethodGet, "/access_key/list", nil, &list)
+	return list, err
+}
+
+// GetAccessKey 获取访问密钥信息
+func (c *Client) GetAccessKey(ctx context.Context, name string) (*model.AccessKeyInfo, error) {
+	var info model.AccessKeyInfo
+	if err := c.call(ctx, http.MethodGet, "/access_key/info", url.Values{"name": {name}}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// DeleteAccessKey 删除访问密钥
+func (c *Client) DeleteAccessKey(ctx context.Context, name string) error {
+	return c.call(ctx, http.MethodDelete, "/access_key/delete", url.Values{"name": {name}}, nil)
+}
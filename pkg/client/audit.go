@@ -0,0 +1,52 @@
+/*
+ * @PackageName: client
+ * @FileName: audit.go
+ * @Description: 审计日志相关接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 22:48:06
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 22:48:06
+ */
+
+package client
+
+import (
+	"context"
+	"easy_dfs/model"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AuditQuery 审计日志查询条件,为空的条件不过滤
+type AuditQuery struct {
+	Start  time.Time
+	End    time.Time
+	Key    string // 访问密钥或其名称
+	Bucket string
+	Action string
+	Limit  int
+}
+
+// ListAuditRecords 查询审计日志,按时间倒序
+func (c *Client) ListAuditRecords(ctx context.Context, q AuditQuery) ([]model.AuditRecord, error) {
+	query := url.Values{}
+	if !q.Start.IsZero() {
+		query.Set("start", q.Start.Format(time.RFC3339))
+	}
+	if !q.End.IsZero() {
+		query.Set("end", q.End.Format(time.RFC3339))
+	}
+	for name, value := range map[string]string{"key": q.Key, "bucket": q.Bucket, "action": q.Action} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+	var records []model.AuditRecord
+	err := c.call(ctx, http.MethodGet, "/audit/list", query, &records)
+	return records, err
+}
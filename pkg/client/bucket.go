@@ -0,0 +1,176 @@
+/*
+ * @PackageName: client
+ * @FileName: bucket.go
+ * @Description: 存储桶接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:12:05
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:12:05
+ */
+
+package client
+
+import (
+	"context"
+	"easy_dfs/model"
+	"easy_dfs/pkg/cors"
+	"easy_dfs/pkg/referer"
+	"easy_dfs/pkg/website"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListBuckets 获取存储桶列表
+func (c *Client) ListBuckets(ctx context.Context) ([]model.BucketInfo, error) {
+	var buckets []model.BucketInfo
+	err := c.call(ctx, http.MethodGet, "/bucket/list", nil, &buckets)
+	return buckets, err
+}
+
+// GetBucket 获取存储桶信息
+func (c *Client) GetBucket(ctx context.Context, bucket string) (*model.BucketInfo, error) {
+	var info model.BucketInfo
+	if err := c.call(ctx, http.MethodGet, "/bucket/info", url.Values{"bucket": {bucket}}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CreateBucket 创建存储桶,AccessPolicy 为空时默认为 private
+func (c *Client) CreateBucket(ctx context.Context, info model.BucketInfo) error {
+	return c.callJSON(ctx, http.MethodPost, "/bucket/create", info, nil)
+}
+
+// DeleteBucket 删除空存储桶,存储桶中仍有文件时返回 ErrDenied
+func (c *Client) DeleteBucket(ctx context.Context, bucket string) error {
+	return c.call(ctx, http.MethodDelete, "/bucket/delete", url.Values{"bucket": {bucket}}, nil)
+}
+
+// ForceDeleteBucket 删除存储桶及其中的所有文件,文件在服务端后台删除,通过 BucketDeleteJob 查询进度
+func (c *Client) ForceDeleteBucket(ctx context.Context, bucket string) (*model.BucketDeleteJob, error) {
+	var job model.BucketDeleteJob
+	if err := c.call(ctx, http.MethodDelete, "/bucket/delete", url.Values{"bucket": {bucket}, "force": {"true"}}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// BucketDeleteJob 获取强制删除存储桶的任务进度
+func (c *Client) BucketDeleteJob(ctx context.Context, id string) (*model.BucketDeleteJob, error) {
+	var job model.BucketDeleteJob
+	if err := c.call(ctx, http.MethodGet, "/bucket/delete/job", url.Values{"id": {id}}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// BucketUpdate 修改存储桶的内容,为 nil 的项不修改
+type BucketUpdate struct {
+	AccessPolicy *string            `json:"accessPolicy,omitempty"`
+	StorageType  *string            `json:"storageType,omitempty"`
+	Description  *string            `json:"description,omitempty"`
+	Tags         *map[string]string `json:"tags,omitempty"`  // 为空映射时清除全部标签
+	Quota        *model.BucketQuota `json:"quota,omitempty"` // 各项均为 0 时取消配额
+	Owner        *string            `json:"owner,omitempty"` // 需管理员权限,为空字符串时只有管理员可以管理
+}
+
+// UpdateBucket 修改存储桶的访问策略、存储类型、描述、标签、配额及所有者,返回修改后的存储桶信息
+func (c *Client) UpdateBucket(ctx context.Context, bucket string, update BucketUpdate) (*model.BucketInfo, error) {
+	payload := struct {
+		Bucket string `json:"bucket"`
+		BucketUpdate
+	}{bucket, update}
+	var info model.BucketInfo
+	if err := c.callJSON(ctx, http.MethodPut, "/bucket/update", payload, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// RenameBucket 重命名存储桶,存储桶内文件的访问地址随之改变
+func (c *Client) RenameBucket(ctx context.Context, bucket, newName string) error {
+	payload := struct {
+		Bucket  string `json:"bucket"`
+		NewName string `json:"newName"`
+	}{bucket, newName}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/rename", payload, nil)
+}
+
+// SetBucketPolicy 设置存储桶的访问策略
+func (c *Client) SetBucketPolicy(ctx context.Context, bucket, accessPolicy string) error {
+	payload := struct {
+		Bucket       string `json:"bucket"`
+		AccessPolicy string `json:"accessPolicy"`
+	}{bucket, accessPolicy}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/policy", payload, nil)
+}
+
+// BucketUsage 获取各存储桶占用空间(字节)
+func (c *Client) BucketUsage(ctx context.Context) (map[string]int64, error) {
+	var usage map[string]int64
+	err := c.call(ctx, http.MethodGet, "/bucket/usage", nil, &usage)
+	return usage, err
+}
+
+// SetBucketWebhooks 设置存储桶的事件通知配置,传空列表时清除
+func (c *Client) SetBucketWebhooks(ctx context.Context, bucket string, webhooks []model.WebhookConfig) error {
+	payload := struct {
+		Bucket   string                `json:"bucket"`
+		Webhooks []model.WebhookConfig `json:"webhooks"`
+	}{bucket, webhooks}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/webhook", payload, nil)
+}
+
+// ListWebhookDeliveries 查询事件通知投递记录,bucket 和 status 为空时不过滤
+func (c *Client) ListWebhookDeliveries(ctx context.Context, bucket, status string, limit int) ([]model.WebhookDelivery, error) {
+	query := url.Values{}
+	if bucket != "" {
+		query.Set("bucket", bucket)
+	}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var deliveries []model.WebhookDelivery
+	err := c.call(ctx, http.MethodGet, "/bucket/webhook/deliveries", query, &deliveries)
+	return deliveries, err
+}
+
+// SetBucketCors 设置存储桶的跨域规则,rules 为空时清除
+func (c *Client) SetBucketCors(ctx context.Context, bucket string, rules []cors.Rule) error {
+	payload := struct {
+		Bucket string      `json:"bucket"`
+		Cors   []cors.Rule `json:"cors"`
+	}{bucket, rules}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/cors", payload, nil)
+}
+
+// SetBucketReferer 设置存储桶的防盗链规则,rule 为 nil 时关闭
+func (c *Client) SetBucketReferer(ctx context.Context, bucket string, rule *referer.Rule) error {
+	payload := struct {
+		Bucket  string        `json:"bucket"`
+		Referer *referer.Rule `json:"referer"`
+	}{bucket, rule}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/referer", payload, nil)
+}
+
+// SetBucketDomains 设置存储桶的自定义域名,domains 为空时清除
+func (c *Client) SetBucketDomains(ctx context.Context, bucket string, domains []string) error {
+	payload := struct {
+		Bucket  string   `json:"bucket"`
+		Domains []string `json:"domains"`
+	}{bucket, domains}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/domain", payload, nil)
+}
+
+// SetBucketWebsite 设置存储桶的静态网站托管,site 为 nil 时关闭
+func (c *Client) SetBucketWebsite(ctx context.Context, bucket string, site *website.Config) error {
+	payload := struct {
+		Bucket  string          `json:"bucket"`
+		Website *website.Config `json:"website"`
+	}{bucket, site}
+	return c.callJSON(ctx, http.MethodPut, "/bucket/website", payload, nil)
+}
@@ -0,0 +1,256 @@
+/*
+ * @PackageName: client
+ * @FileName: client.go
+ * @Description: easy_dfs Go 客户端
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:02:18
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:02:18
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/pkg/http/http_response"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 默认最大重试次数
+	defaultMaxRetries = 3
+	// 默认首次重试等待时间
+	defaultRetryBase = 200 * time.Millisecond
+	// 默认最长重试等待时间
+	defaultRetryMax = 10 * time.Second
+	// 默认大文件阈值,超过时以分块传输编码流式上传
+	defaultLargeFileThreshold = 8 << 20
+)
+
+// Client easy_dfs 客户端,可在多个协程中共用
+type Client struct {
+	endpoint  string
+	accessKey string
+	secretKey string
+	token     string
+
+	httpClient         *http.Client
+	maxRetries         int
+	retryBase          time.Duration
+	retryMax           time.Duration
+	largeFileThreshold int64
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRetry 设置最大重试次数及退避时间,maxRetries 为 0 时不重试
+func WithRetry(maxRetries int, base, max time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBase = base
+		c.retryMax = max
+	}
+}
+
+// WithToken 使用登录后获取的会话令牌代替访问密钥,令牌过期后需重新登录
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithLargeFileThreshold 设置大文件阈值(字节)
+func WithLargeFileThreshold(n int64) Option {
+	return func(c *Client) {
+		c.largeFileThreshold = n
+	}
+}
+
+// New 创建客户端,endpoint 如 http://localhost:18088
+func New(endpoint, accessKey, secretKey string, opts ...Option) *Client {
+	c := &Client{
+		endpoint:           strings.TrimSuffix(endpoint, "/"),
+		accessKey:          accessKey,
+		secretKey:          secretKey,
+		httpClient:         http.DefaultClient,
+		maxRetries:         defaultMaxRetries,
+		retryBase:          defaultRetryBase,
+		retryMax:           defaultRetryMax,
+		largeFileThreshold: defaultLargeFileThreshold,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Endpoint 返回服务地址
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// newRequest 创建请求并设置访问密钥或会话令牌
+func (c *Client) newRequest(ctx context.Context, method, apiPath string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := c.endpoint + apiPath
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		return req, nil
+	}
+	req.Header.Set("X-Access-Key", c.accessKey)
+	req.Header.Set("X-Secret-Key", c.secretKey)
+	return req, nil
+}
+
+// send 发送请求,网络错误、5xx、429 及请求频繁时按指数退避重试;
+// 请求体无法重放(GetBody 为空)时不重试
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		retry, wait := c.shouldRetry(resp, err)
+		if !retry || attempt >= c.maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// shouldRetry 判断是否需要重试,服务端返回 Retry-After 时按其等待
+func (c *Client) shouldRetry(resp *http.Response, err error) (bool, time.Duration) {
+	if err != nil {
+		// 调用方取消时不重试
+		return resp == nil && !isContextError(err), 0
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, retryAfter(resp)
+	}
+	return false, 0
+}
+
+// backoff 第 attempt 次重试前的等待时间,带随机抖动
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.retryBase << attempt
+	if wait <= 0 || wait > c.retryMax {
+		wait = c.retryMax
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// do 发送请求并解析统一返回结构,data 不为 nil 时将返回数据解析到 data
+func (c *Client) do(req *http.Request, data interface{}) error {
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, data)
+}
+
+// call 发送不带请求体的请求
+func (c *Client) call(ctx context.Context, method, apiPath string, query url.Values, data interface{}) error {
+	req, err := c.newRequest(ctx, method, apiPath, query, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, data)
+}
+
+// callJSON 发送 JSON 请求体
+func (c *Client) callJSON(ctx context.Context, method, apiPath string, payload, data interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := c.newRequest(ctx, method, apiPath, nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, data)
+}
+
+// decodeResponse 解析统一返回结构,业务失败时返回 *Error
+func decodeResponse(resp *http.Response, data interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		http_response.ResponseData
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Code == "" {
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Code:       response_code.REQUEST_FAILS,
+			Message:    fmt.Sprintf("服务端返回了无法识别的响应: %s", strings.TrimSpace(string(body))),
+		}
+	}
+	if !result.Success {
+		return &Error{StatusCode: resp.StatusCode, Code: result.Code, Message: result.Message, Errors: result.Errors}
+	}
+	if data != nil && len(result.Data) > 0 {
+		return json.Unmarshal(result.Data, data)
+	}
+	return nil
+}
+
+// retryAfter 解析 Retry-After 响应头(秒)
+func retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// isContextError 是否为上下文取消或超时
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,69 @@
+/*
+ * @PackageName: client
+ * @FileName: errors.go
+ * @Description: 客户端错误类型
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:08:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:08:40
+ */
+
+package client
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 按返回码区分的错误,可通过 errors.Is 判断
+var (
+	// 参数有误
+	ErrParam = errors.New("参数有误")
+	// 拒绝访问
+	ErrDenied = errors.New("拒绝访问")
+	// 资源不存在
+	ErrNotFound = errors.New("资源不存在")
+	// 密钥无效
+	ErrTokenInvalid = errors.New("密钥无效")
+	// 请求频繁
+	ErrTooManyRequests = errors.New("请求频繁")
+	// 操作失败
+	ErrFailed = errors.New("操作失败")
+)
+
+// codeErrors 返回码与错误的对应关系
+var codeErrors = map[string]error{
+	response_code.PARAM_ERROR:      ErrParam,
+	response_code.REQUEST_DENIED:   ErrDenied,
+	response_code.QUERY_EMPTY:      ErrNotFound,
+	response_code.TOKEN_INVALID:    ErrTokenInvalid,
+	response_code.REQUEST_FREQUENT: ErrTooManyRequests,
+	response_code.REQUEST_FAILS:    ErrFailed,
+}
+
+// Error 服务端返回的业务错误
+type Error struct {
+	// HTTP 状态码
+	StatusCode int
+	// 返回码,见 response_code
+	Code string
+	// 返回信息
+	Message string
+	// 错误详情
+	Errors []string
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if len(e.Errors) > 0 {
+		msg += ": " + strings.Join(e.Errors, "; ")
+	}
+	return fmt.Sprintf("%s (%s)", msg, e.Code)
+}
+
+// Is 支持 errors.Is(err, client.ErrNotFound) 等判断
+func (e *Error) Is(target error) bool {
+	return codeErrors[e.Code] == target
+}
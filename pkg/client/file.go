@@ -0,0 +1,347 @@
+/*
+ * @PackageName: client
+ * @FileName: file.go
+ * @Description: 文件接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:20:47
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:20:47
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/pkg/presign"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UploadResult 上传结果,与服务端上传接口的返回数据一致
+type UploadResult struct {
+	Bucket       string `json:"bucket"`
+	OriginalName string `json:"originalName"`
+	FileName     string `json:"fileName"`
+	FileUrl      string `json:"fileUrl"`
+	FileExt      string `json:"fileExt"`
+	FileSize     int64  `json:"fileSize"`
+}
+
+// FileInfo 文件信息
+type FileInfo struct {
+	FileName string `json:"fileName"`
+	FilePath string `json:"filePath"`
+	FileExt  string `json:"fileExt"`
+	FileSize int64  `json:"fileSize"`
+}
+
+// Upload 上传文件到 bucket 的 key,size 未知时传 -1。
+// r 实现 io.Seeker 或 size 不超过大文件阈值时请求体可重放,失败后自动重试;
+// 其余情况以流的方式上传,size 未知时使用分块传输编码,不重试
+func (c *Client) Upload(ctx context.Context, bucket, key string, r io.Reader, size int64) (*UploadResult, error) {
+	prefix, suffix, contentType, err := uploadEnvelope(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求体的文件部分,可重放时每次重试重新生成
+	var content func() (io.Reader, error)
+	rs, seekable := r.(io.ReadSeeker)
+	var start int64
+	if seekable {
+		// 管道等类型虽实现了 io.Seeker,但无法定位
+		start, err = rs.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+	}
+	switch {
+	case seekable:
+		content = func() (io.Reader, error) {
+			_, err := rs.Seek(start, io.SeekStart)
+			return rs, err
+		}
+	case size >= 0 && size <= c.largeFileThreshold:
+		data, err := io.ReadAll(io.LimitReader(r, size+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) != size {
+			return nil, fmt.Errorf("文件大小与 size 不一致: %d != %d", len(data), size)
+		}
+		content = func() (io.Reader, error) {
+			return bytes.NewReader(data), nil
+		}
+	}
+
+	newBody := func() (io.ReadCloser, error) {
+		part := r
+		if content != nil {
+			var err error
+			if part, err = content(); err != nil {
+				return nil, err
+			}
+		}
+		return io.NopCloser(io.MultiReader(bytes.NewReader(prefix), part, bytes.NewReader(suffix))), nil
+	}
+	body, err := newBody()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/file/upload", nil, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	// size 未知时 ContentLength 为 -1,使用分块传输编码
+	req.ContentLength = -1
+	if size >= 0 {
+		req.ContentLength = int64(len(prefix)) + size + int64(len(suffix))
+	}
+	if content != nil {
+		req.GetBody = newBody
+	}
+
+	var result UploadResult
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UploadFile 上传本地文件
+func (c *Client) UploadFile(ctx context.Context, bucket, key, localPath string) (*UploadResult, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return c.Upload(ctx, bucket, key, f, fileSize(info))
+}
+
+// PutObjectOptions 以原始请求体上传时的可选参数
+type PutObjectOptions struct {
+	ContentType string            // 内容类型,下载时按其返回
+	ContentMD5  string            // Base64 编码的 MD5,服务端校验不一致时拒绝
+	SHA256      string            // 十六进制编码的 SHA-256,服务端校验不一致时拒绝
+	Metadata    map[string]string // 自定义元数据,以 X-Meta-* 请求头发送
+}
+
+// PutObjectResult 以原始请求体上传的结果
+type PutObjectResult struct {
+	Bucket      string `json:"bucket"`
+	FileName    string `json:"fileName"`
+	FileUrl     string `json:"fileUrl"`
+	FileSize    int64  `json:"fileSize"`
+	ContentType string `json:"contentType"`
+	MD5         string `json:"md5"`
+	SHA256      string `json:"sha256"`
+}
+
+// PutObject 以原始请求体上传文件到 bucket 的 key,服务端直接写入存储,不生成临时文件;size 未知时传 -1。
+// r 实现 io.Seeker 时请求体可重放,失败后自动重试
+func (c *Client) PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64, opts *PutObjectOptions) (*PutObjectResult, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" {
+		return nil, fmt.Errorf("文件名有误: %q", key)
+	}
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, "/file/object/"+url.PathEscape(bucket)+"/"+strings.Join(segments, "/"), nil, io.NopCloser(r))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if start, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				_, err := rs.Seek(start, io.SeekStart)
+				return io.NopCloser(rs), err
+			}
+		}
+	}
+	if opts != nil {
+		if opts.ContentType != "" {
+			req.Header.Set("Content-Type", opts.ContentType)
+		}
+		if opts.ContentMD5 != "" {
+			req.Header.Set("Content-MD5", opts.ContentMD5)
+		}
+		if opts.SHA256 != "" {
+			req.Header.Set("X-Content-SHA256", opts.SHA256)
+		}
+		for k, v := range opts.Metadata {
+			req.Header.Set("X-Meta-"+k, v)
+		}
+	}
+
+	var result PutObjectResult
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// fileSize 普通文件返回文件大小,管道等其他类型返回 -1
+func fileSize(info os.FileInfo) int64 {
+	if !info.Mode().IsRegular() {
+		return -1
+	}
+	return info.Size()
+}
+
+// uploadEnvelope 生成 multipart 请求体中文件内容前后的部分。
+// 上传接口按 savePath + saveName + 原文件后缀 保存,据此拆分文件名
+func uploadEnvelope(bucket, key string) (prefix, suffix []byte, contentType string, err error) {
+	key = strings.TrimPrefix(key, "/")
+	dir, base := path.Split(key)
+	ext := path.Ext(base)
+	saveName := strings.TrimSuffix(base, ext)
+	if saveName == "" {
+		return nil, nil, "", fmt.Errorf("文件名有误: %q", key)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, field := range [][2]string{{"bucket", bucket}, {"savePath", dir}, {"saveName", saveName}} {
+		if err := mw.WriteField(field[0], field[1]); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	if _, err := mw.CreateFormFile("file", base); err != nil {
+		return nil, nil, "", err
+	}
+	prefix = append([]byte(nil), buf.Bytes()...)
+	buf.Reset()
+	if err := mw.Close(); err != nil {
+		return nil, nil, "", err
+	}
+	return prefix, buf.Bytes(), mw.FormDataContentType(), nil
+}
+
+// GetObject 下载文件,返回文件内容及大小(未知时为 -1),调用方负责关闭
+func (c *Client) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/file/download", url.Values{"bucket": {bucket}, "filename": {key}}, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := checkStreamResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// Download 下载文件写入 w,返回写入的字节数
+func (c *Client) Download(ctx context.Context, bucket, key string, w io.Writer) (int64, error) {
+	body, _, err := c.GetObject(ctx, bucket, key)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	return io.Copy(w, body)
+}
+
+// DownloadArchive 打包下载 prefix 下的文件及 keys 指定的文件,format 为 zip 或 tar.gz
+func (c *Client) DownloadArchive(ctx context.Context, bucket, prefix string, keys []string, format string, w io.Writer) (int64, error) {
+	payload, err := json.Marshal(map[string]interface{}{"bucket": bucket, "prefix": prefix, "keys": keys, "format": format})
+	if err != nil {
+		return 0, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/file/archive", nil, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.send(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := checkStreamResponse(resp); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// checkStreamResponse 检查下载类接口的响应,出错时服务端返回统一结构的 JSON
+func checkStreamResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return &Error{StatusCode: resp.StatusCode, Code: response_code.QUERY_EMPTY, Message: "文件不存在"}
+	}
+	if resp.StatusCode != http.StatusOK || strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		if err := decodeResponse(resp, nil); err != nil {
+			return err
+		}
+		return &Error{StatusCode: resp.StatusCode, Code: response_code.REQUEST_FAILS, Message: "下载失败"}
+	}
+	return nil
+}
+
+// ListFiles 列出存储桶中以 prefix 开头的文件,返回按名称排序的文件名
+func (c *Client) ListFiles(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var files []string
+	if err := c.call(ctx, http.MethodGet, "/file/list", url.Values{"bucket": {bucket}}, &files); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(files))
+	for _, f := range files {
+		// 接口返回的文件名以存储桶名称开头
+		key := strings.TrimPrefix(f, bucket+"/")
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// StatFile 获取文件信息
+func (c *Client) StatFile(ctx context.Context, bucket, key string) (*FileInfo, error) {
+	var info FileInfo
+	if err := c.call(ctx, http.MethodGet, "/file/info", url.Values{"bucket": {bucket}, "filename": {key}}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// RenameFile 重命名存储桶内的文件,目标文件已存在时返回错误
+func (c *Client) RenameFile(ctx context.Context, bucket, from, to string) error {
+	payload := struct {
+		Bucket string `json:"bucket"`
+		From   string `json:"from"`
+		To     string `json:"to"`
+	}{bucket, from, to}
+	return c.callJSON(ctx, http.MethodPost, "/file/rename", payload, nil)
+}
+
+// DeleteFile 删除文件
+func (c *Client) DeleteFile(ctx context.Context, bucket, key string) error {
+	return c.call(ctx, http.MethodDelete, "/file/delete", url.Values{"bucket": {bucket}, "filename": {key}}, nil)
+}
+
+// PresignURL 生成文件的签名访问地址,签名在本地计算
+func (c *Client) PresignURL(bucket, key string, expires time.Duration) string {
+	return presign.URL(c.endpoint, bucket, key, c.accessKey, c.secretKey, time.Now().Add(expires))
+}
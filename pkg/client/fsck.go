@@ -0,0 +1,37 @@
+/*
+ * @PackageName: client
+ * @FileName: fsck.go
+ * @Description: 数据一致性检查接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 03:21:06
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 03:21:06
+ */
+
+package client
+
+import (
+	"context"
+	"easy_dfs/model"
+	"net/http"
+)
+
+// FsckCheck 检查配置文件与存储目录是否一致,返回发现的问题
+func (c *Client) FsckCheck(ctx context.Context) ([]model.FsckIssue, error) {
+	var issues []model.FsckIssue
+	if err := c.call(ctx, http.MethodGet, "/fsck/check", nil, &issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// FsckReconcile 按问题类型指定的修复方式(adopt、quarantine、delete)修复问题。
+// targets 为空时处理全部问题;dryRun 为 true 时只返回将执行的操作
+func (c *Client) FsckReconcile(ctx context.Context, actions map[string]string, targets []string, dryRun bool) ([]model.FsckResult, error) {
+	payload := map[string]interface{}{"actions": actions, "targets": targets, "dryRun": dryRun}
+	var results []model.FsckResult
+	if err := c.callJSON(ctx, http.MethodPost, "/fsck/reconcile", payload, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,35 @@
+/*
+ * @PackageName: client
+ * @FileName: scrub.go
+ * @Description: 数据完整性巡检接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 02:58:31
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 02:58:31
+ */
+
+package client
+
+import (
+	"context"
+	"easy_dfs/model"
+	"net/http"
+)
+
+// RunScrub 手动触发数据完整性巡检,巡检在服务端后台执行
+func (c *Client) RunScrub(ctx context.Context) (*model.ScrubReport, error) {
+	var report model.ScrubReport
+	if err := c.call(ctx, http.MethodPost, "/scrub/run", nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ScrubReport 获取进行中的巡检进度,没有进行中的巡检时返回最近一次的报告
+func (c *Client) ScrubReport(ctx context.Context) (*model.ScrubReport, error) {
+	var report model.ScrubReport
+	if err := c.call(ctx, http.MethodGet, "/scrub/report", nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * @PackageName: client
+ * @FileName: user.go
+ * @Description: 登录及用户管理接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 06:03:17
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 06:03:17
+ */
+
+package client
+
+import (
+	"context"
+	"easy_dfs/model"
+	"net/http"
+	"net/url"
+)
+
+// UserUpdate 修改用户的内容,为 nil 的项不修改
+type UserUpdate struct {
+	Role     *string `json:"role,omitempty"`
+	Password *string `json:"password,omitempty"`
+}
+
+// Login 使用用户名及密码登录,返回的令牌可通过 WithToken 创建客户端
+func (c *Client) Login(ctx context.Context, username, password string) (*model.LoginResult, error) {
+	var result model.LoginResult
+	payload := map[string]string{"username": username, "password": password}
+	if err := c.callJSON(ctx, http.MethodPost, "/auth/login", payload, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LoginProviders 可用的登录方式
+type LoginProviders struct {
+	Password bool `json:"password"`
+	OIDC     bool `json:"oidc"`
+}
+
+// Providers 获取服务端可用的登录方式
+func (c *Client) Providers(ctx context.Context) (*LoginProviders, error) {
+	var providers LoginProviders
+	if err := c.call(ctx, http.MethodGet, "/auth/providers", nil, &providers); err != nil {
+		return nil, err
+	}
+	return &providers, nil
+}
+
+// OIDCLoginURL 返回单点登录地址,在浏览器中打开并登录后,回调接口返回的会话令牌可通过 WithToken 使用
+func (c *Client) OIDCLoginURL() string {
+	return c.endpoint + "/auth/oidc/login"
+}
+
+// Me 获取当前请求方
+func (c *Client) Me(ctx context.Context) (*model.Principal, error) {
+	var principal model.Principal
+	if err := c.call(ctx, http.MethodGet, "/auth/me", nil, &principal); err != nil {
+		return nil, err
+	}
+	return &principal, nil
+}
+
+// ChangePassword 修改当前用户的密码,修改后已签发的会话令牌失效
+func (c *Client) ChangePassword(ctx context.Context, oldPassword, newPassword string) error {
+	payload := map[string]string{"oldPassword": oldPassword, "newPassword": newPassword}
+	return c.callJSON(ctx, http.MethodPost, "/auth/password", payload, nil)
+}
+
+// CreateUser 创建用户,需管理员权限
+func (c *Client) CreateUser(ctx context.Context, username, password, role string) (*model.UserInfo, error) {
+	var user model.UserInfo
+	payload := map[string]string{"username": username, "password": password, "role": role}
+	if err := c.callJSON(ctx, http.MethodPost, "/user/create", payload, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers 获取用户列表,需管理员权限
+func (c *Client) ListUsers(ctx context.Context) ([]model.UserInfo, error) {
+	var users []model.UserInfo
+	err := c.call(ctx, http.MethodGet, "/user/list", nil, &users)
+	return users, err
+}
+
+// UpdateUser 修改用户角色或重置密码,需管理员权限
+func (c *Client) UpdateUser(ctx context.Context, username string, update UserUpdate) (*model.UserInfo, error) {
+	var user model.UserInfo
+	payload := struct {
+		Username string `json:"username"`
+		UserUpdate
+	}{username, update}
+	if err := c.callJSON(ctx, http.MethodPut, "/user/update", payload, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RevokeUser 禁用用户并吊销其全部访问密钥,需管理员权限
+func (c *Client) RevokeUser(ctx context.Context, username string) error {
+	return c.call(ctx, http.MethodPost, "/user/revoke", url.Values{"username": {username}}, nil)
+}
+
+// DeleteUser 删除用户及其全部访问密钥,需管理员权限
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	return c.call(ctx, http.MethodDelete, "/user/delete", url.Values{"username": {username}}, nil)
+}
@@ -60,10 +60,10 @@ func InitConfig(env string) {
 	loadConfig()
 }
 
-// loadConfig 加载配置
+// loadConfig 加载配置，注册的配置项作为默认值，app.yml 中已配置的项优先
 func loadConfig() {
 	for name, fn := range ConfigFuncs {
-		viper.Set(name, fn())
+		viper.SetDefault(name, fn())
 	}
 }
 
@@ -158,7 +158,11 @@ func GetUint(path string, defaultValue ...interface{}) uint {
 }
 
 // GetBool 获取 Bool 类型的配置信息
+// false 是有效的配置值,不像其他类型那样视为未配置,只有未配置或为空字符串时才使用默认值
 func GetBool(path string, defaultValue ...interface{}) bool {
+	if value := viper.Get(path); viper.IsSet(path) && value != nil && value != "" {
+		return cast.ToBool(value)
+	}
 	return cast.ToBool(internalGet(path, defaultValue...))
 }
 
@@ -171,3 +175,8 @@ func ViperGetStringMap(path string) map[string]interface{} {
 func GetStringMapString(path string) map[string]string {
 	return viper.GetStringMapString(path)
 }
+
+// Set 覆盖配置项,优先于配置文件及环境变量,用于测试或运行时调整
+func Set(path string, value interface{}) {
+	viper.Set(path, value)
+}
@@ -0,0 +1,171 @@
+/*
+ * @PackageName: cors
+ * @FileName: cors.go
+ * @Description: 跨域资源共享(CORS)规则匹配
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 20:52:16
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 20:52:16
+ */
+
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 规则中允许配置的请求方法
+var allowedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// Rule 跨域规则,按配置顺序匹配,使用第一条匹配的规则
+type Rule struct {
+	// 允许的来源,如 https://example.com、https://*.example.com,* 表示任意来源
+	AllowedOrigins []string `json:"allowedOrigins"`
+	// 允许的请求方法
+	AllowedMethods []string `json:"allowedMethods"`
+	// 允许的请求头,* 表示任意请求头
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	// 允许浏览器读取的响应头
+	ExposeHeaders []string `json:"exposeHeaders,omitempty"`
+	// 预检请求结果的缓存时间,单位:秒
+	MaxAgeSeconds int `json:"maxAgeSeconds,omitempty"`
+}
+
+// Validate 校验规则
+func (r Rule) Validate() error {
+	if len(r.AllowedOrigins) == 0 {
+		return errors.New("allowedOrigins不能为空")
+	}
+	for _, origin := range r.AllowedOrigins {
+		if strings.Count(origin, "*") > 1 {
+			return errors.New("来源最多包含一个通配符: " + origin)
+		}
+	}
+	if len(r.AllowedMethods) == 0 {
+		return errors.New("allowedMethods不能为空")
+	}
+	for _, method := range r.AllowedMethods {
+		if !allowedMethods[strings.ToUpper(method)] {
+			return errors.New("请求方法非法: " + method)
+		}
+	}
+	if r.MaxAgeSeconds < 0 {
+		return errors.New("maxAgeSeconds不能小于0")
+	}
+	return nil
+}
+
+// Match 返回第一条允许该来源、请求方法及请求头的规则,没有时返回 nil
+func Match(rules []Rule, origin, method string, headers []string) *Rule {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.allowsOrigin(origin) && rule.allowsMethod(method) && rule.allowsHeaders(headers) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Apply 根据规则为跨域请求设置响应头,返回是否为预检请求以及是否允许
+// 非跨域请求(无 Origin 请求头)视为允许;未匹配规则时不设置跨域响应头,由浏览器拒绝
+func Apply(header http.Header, req *http.Request, rules []Rule) (preflight, allowed bool) {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false, true
+	}
+	header.Add("Vary", "Origin")
+
+	method := req.Method
+	var requestHeaders []string
+	requestMethod := req.Header.Get("Access-Control-Request-Method")
+	preflight = req.Method == http.MethodOptions && requestMethod != ""
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		method = requestMethod
+		requestHeaders = ParseList(req.Header.Get("Access-Control-Request-Headers"))
+	}
+
+	rule := Match(rules, origin, method, requestHeaders)
+	if rule == nil {
+		return preflight, false
+	}
+
+	header.Set("Access-Control-Allow-Origin", origin)
+	if preflight {
+		header.Set("Access-Control-Allow-Methods", strings.ToUpper(strings.Join(rule.AllowedMethods, ", ")))
+		if len(requestHeaders) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+		}
+		if rule.MaxAgeSeconds > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(rule.MaxAgeSeconds))
+		}
+	} else if len(rule.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(rule.ExposeHeaders, ", "))
+	}
+	return preflight, true
+}
+
+// ParseList 解析逗号分隔的列表,去除空白及空项
+func ParseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (r *Rule) allowsOrigin(origin string) bool {
+	for _, allowed := range r.AllowedOrigins {
+		if matchWildcard(strings.ToLower(allowed), strings.ToLower(origin)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) allowsMethod(method string) bool {
+	for _, allowed := range r.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) allowsHeaders(headers []string) bool {
+	for _, h := range headers {
+		found := false
+		for _, allowed := range r.AllowedHeaders {
+			if allowed == "*" || strings.EqualFold(allowed, h) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchWildcard 匹配最多包含一个 * 的模式
+func matchWildcard(pattern, s string) bool {
+	i := strings.Index(pattern, "*")
+	if i < 0 {
+		return pattern == s
+	}
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	return len(s) >= len(prefix)+len(suffix) && strings.HasPrefix(s, prefix) && strings.HasSuffix(s, suffix)
+}
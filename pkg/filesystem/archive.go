@@ -0,0 +1,111 @@
+/*
+ * @PackageName: filesystem
+ * @FileName: archive.go
+ * @Description: 文件打包
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 10:12:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 10:12:40
+ */
+
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// ZIP格式
+	ArchiveFormatZip = "zip"
+	// TAR.GZ格式
+	ArchiveFormatTarGz = "tar.gz"
+)
+
+// ArchiveWriter 流式打包写入器,文件逐个写入,不在内存中缓存整个压缩包
+type ArchiveWriter interface {
+	// AddFile 写入一个文件
+	AddFile(name string, size int64, modTime time.Time, data io.Reader) error
+	// Close 写入压缩包结尾
+	Close() error
+}
+
+// NewArchiveWriter 根据格式创建打包写入器
+func NewArchiveWriter(w io.Writer, format string) (ArchiveWriter, error) {
+	switch format {
+	case ArchiveFormatZip:
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}, nil
+	case ArchiveFormatTarGz:
+		gw := gzip.NewWriter(w)
+		return &tarGzArchiveWriter{gw: gw, tw: tar.NewWriter(gw)}, nil
+	}
+	return nil, errors.New("不支持的打包格式")
+}
+
+// ArchiveExt 返回打包格式对应的文件后缀
+func ArchiveExt(format string) string {
+	return "." + format
+}
+
+// ArchiveContentType 返回打包格式对应的Content-Type
+func ArchiveContentType(format string) string {
+	if format == ArchiveFormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipArchiveWriter) AddFile(name string, size int64, modTime time.Time, data io.Reader) error {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	}
+	header.SetMode(0644)
+	w, err := z.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, data)
+	return err
+}
+
+func (z *zipArchiveWriter) Close() error {
+	return z.zw.Close()
+}
+
+type tarGzArchiveWriter struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func (t *tarGzArchiveWriter) AddFile(name string, size int64, modTime time.Time, data io.Reader) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := t.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	// tar 要求写入的长度与头部声明一致
+	_, err := io.CopyN(t.tw, data, size)
+	return err
+}
+
+func (t *tarGzArchiveWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	return t.gw.Close()
+}
@@ -12,9 +12,13 @@ package filesystem
 
 import (
 	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/logger"
+	"easy_dfs/pkg/metrics"
+	"go.uber.org/zap"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -27,30 +31,153 @@ type ResponseFileList struct {
 	FileList []string `json:"fileList"`
 }
 
-func (s *FileSystemStorage) initBaseDir() {
+// rootDir 返回存储根目录,不修改 BaseDir,避免影响之后以完整路径调用的 Save/Load 等方法
+func (s *FileSystemStorage) rootDir() string {
 	// 根据环境判断是否使用tmp目录
 	if config.Get("app.env") != "prod" {
-		s.BaseDir = filepath.Join("tmp", "storage")
-	} else {
-		s.BaseDir = "storage"
+		return filepath.Join(s.BaseDir, "tmp", "storage")
 	}
+	return filepath.Join(s.BaseDir, "storage")
 }
 
-// Save 保存文件
+// 写入中的临时文件名前缀,列出文件时忽略
+const TempFilePrefix = ".easydfs-tmp-"
+
+// IsTempFile 是否为写入中的临时文件
+func IsTempFile(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), TempFilePrefix)
+}
+
+// Save 保存文件:先写入同目录下的临时文件,写入完成后原子地重命名为目标文件,
+// 读取方只会看到旧文件或完整的新文件
 func (s *FileSystemStorage) Save(filename string, data io.Reader) error {
+	tmpPath, err := s.SaveTemp(filename, data)
+	if err != nil {
+		return err
+	}
+	return s.Commit(tmpPath, filename)
+}
+
+// SaveTemp 将数据写入目标文件所在目录下的临时文件并同步到磁盘,返回临时文件路径;
+// 写入失败时删除临时文件及因此创建的空目录,成功时由调用方通过 Commit 或 Abort 处理
+func (s *FileSystemStorage) SaveTemp(filename string, data io.Reader) (string, error) {
+	dir := filepath.Dir(filepath.Join(s.BaseDir, filename))
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(dir, TempFilePrefix+"*")
+	if err != nil {
+		s.removeEmptyDirs(dir)
+		return "", err
+	}
+	tmpPath := file.Name()
+	_, err = io.Copy(file, data)
+	if err == nil && fsyncEnabled() {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	// 临时文件默认权限为 0600,与直接创建的文件保持一致
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	// 写入中断(如客户端断开、服务退出)或校验失败时删除临时文件,目标文件保持不变
+	if err != nil {
+		s.Abort(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// Commit 将 SaveTemp 写入的临时文件原子地重命名为目标文件,覆盖已存在的文件,
+// 并同步所在目录以保证重命名持久化;重命名失败时删除临时文件并返回错误。
+// 重命名成功后文件已替换,同步目录失败只记录日志及指标,不返回错误,避免调用方回滚已生效的写入
+func (s *FileSystemStorage) Commit(tmpPath, filename string) error {
 	path := filepath.Join(s.BaseDir, filename)
-	dir := filepath.Dir(path)
+	if err := os.Rename(tmpPath, path); err != nil {
+		s.Abort(tmpPath)
+		return err
+	}
+	if fsyncEnabled() {
+		if err := syncDir(filepath.Dir(path)); err != nil {
+			metrics.FsyncFailure()
+			logger.Error("Storage", zap.String("sync", filepath.Dir(path)), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Abort 放弃 SaveTemp 写入的临时文件,同时删除因此创建的空目录
+func (s *FileSystemStorage) Abort(tmpPath string) {
+	os.Remove(tmpPath)
+	s.removeEmptyDirs(filepath.Dir(tmpPath))
+}
 
+// removeEmptyDirs 自 dir 向上删除空目录,直到存储根目录或非空目录为止
+func (s *FileSystemStorage) removeEmptyDirs(dir string) {
+	root := filepath.Clean(s.rootDir())
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		// 目录非空时删除失败,即可停止
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// WriteFileAtomic 以临时文件及原子重命名的方式写入小文件(如元数据),读取方不会读到写了一半的内容
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
 		return err
 	}
+	file, err := os.CreateTemp(dir, TempFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	_, err = file.Write(data)
+	if err == nil && fsyncEnabled() {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if fsyncEnabled() {
+		return syncDir(dir)
+	}
+	return nil
+}
+
+// fsyncEnabled 是否在写入后同步到磁盘
+func fsyncEnabled() bool {
+	return config.GetBool("storage.fsync", true)
+}
 
-	file, err := os.Create(path)
+// syncDir 同步目录,使其中文件的创建及重命名持久化;Windows 不支持同步目录,直接跳过
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	_, err = io.Copy(file, data)
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
 	return err
 }
 
@@ -60,12 +187,74 @@ func (s *FileSystemStorage) Load(filename string) (io.Reader, error) {
 	return os.Open(path)
 }
 
+// Open 打开文件并返回文件状态,调用方负责关闭文件
+func (s *FileSystemStorage) Open(filename string) (*os.File, os.FileInfo, error) {
+	path := filepath.Join(s.BaseDir, filename)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, nil, os.ErrNotExist
+	}
+	return file, info, nil
+}
+
+// ListFilesUnder 递归列出目录下的所有文件,返回相对该目录的路径(以 / 分隔),目录不存在时返回空列表
+func (s *FileSystemStorage) ListFilesUnder(dir string) ([]string, error) {
+	root := filepath.Join(s.BaseDir, dir)
+	var fileNames []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || IsTempFile(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		fileNames = append(fileNames, filepath.ToSlash(rel))
+		return nil
+	})
+	return fileNames, err
+}
+
 // Delete 删除文件
 func (s *FileSystemStorage) Delete(filename string) error {
 	path := filepath.Join(s.BaseDir, filename)
 	return os.Remove(path)
 }
 
+// Rename 重命名文件,目标文件已存在时返回 os.ErrExist
+func (s *FileSystemStorage) Rename(from, to string) error {
+	src := filepath.Join(s.BaseDir, from)
+	dst := filepath.Join(s.BaseDir, to)
+
+	if info, err := os.Stat(src); err != nil {
+		return err
+	} else if info.IsDir() {
+		return os.ErrNotExist
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
 // DeleteByPath 根据bucketName和filePath删除文件
 func (s *FileSystemStorage) DeleteByPath(bucketName, filePath string) error {
 	path := filepath.Join(s.BaseDir, bucketName, filePath)
@@ -74,14 +263,13 @@ func (s *FileSystemStorage) DeleteByPath(bucketName, filePath string) error {
 
 // ListFiles 根据bucketName列出文件
 func (s *FileSystemStorage) ListFiles(bucketName string) ([]string, error) {
-	s.initBaseDir()
-	return s.listFilesRecursive(bucketName, bucketName)
+	return s.listFilesRecursive(s.rootDir(), bucketName)
 }
 
 // ListAllFiles 列出所有文件
 func (s *FileSystemStorage) ListAllFiles() ([]ResponseFileList, error) {
-	s.initBaseDir()
-	buckets, err := os.ReadDir(s.BaseDir)
+	root := s.rootDir()
+	buckets, err := os.ReadDir(root)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +278,7 @@ func (s *FileSystemStorage) ListAllFiles() ([]ResponseFileList, error) {
 	for _, bucket := range buckets {
 		if bucket.IsDir() {
 			bucketName := bucket.Name()
-			files, err := s.listFilesRecursive(bucketName, bucketName)
+			files, err := s.listFilesRecursive(root, bucketName)
 			if err != nil {
 				return nil, err
 			}
@@ -104,24 +292,26 @@ func (s *FileSystemStorage) ListAllFiles() ([]ResponseFileList, error) {
 }
 
 // 列出文件
-func (s *FileSystemStorage) listFilesRecursive(basePath, bucketName string) ([]string, error) {
-	path := filepath.Join(s.BaseDir, basePath)
+func (s *FileSystemStorage) listFilesRecursive(root, basePath string) ([]string, error) {
+	path := filepath.Join(root, basePath)
 	files, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
 	var fileNames []string
 	for _, file := range files {
+		if IsTempFile(file.Name()) {
+			continue
+		}
 		fullPath := filepath.Join(basePath, file.Name())
 		if file.IsDir() {
-			subFiles, err := s.listFilesRecursive(fullPath, bucketName)
+			subFiles, err := s.listFilesRecursive(root, fullPath)
 			if err != nil {
 				return nil, err
 			}
 			fileNames = append(fileNames, subFiles...)
 		} else {
-			relativePath := strings.TrimPrefix(fullPath, s.BaseDir+"/")
-			fileNames = append(fileNames, relativePath)
+			fileNames = append(fileNames, filepath.ToSlash(fullPath))
 		}
 	}
 	return fileNames, nil
@@ -136,9 +326,12 @@ type FileInfo struct {
 
 // GetFileInfo 获取文件信息
 func (s *FileSystemStorage) GetFileInfo(path string) (FileInfo, error) {
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := os.Stat(filepath.Join(s.BaseDir, path))
 	if err != nil {
-		return FileInfo{}, nil
+		return FileInfo{}, err
+	}
+	if fileInfo.IsDir() {
+		return FileInfo{}, os.ErrNotExist
 	}
 	info := FileInfo{
 		FileName: filepath.Base(path),
@@ -15,6 +15,12 @@ import (
 	"net/http"
 )
 
+// 上下文中保存返回码及错误信息的键,供审计等中间件读取处理结果
+const (
+	CodeKey  = "response.code"
+	ErrorKey = "response.error"
+)
+
 // 返回数据结构体
 type ResponseData struct {
 	Code    string      `json:"code"`
@@ -26,11 +32,18 @@ type ResponseData struct {
 
 // 返回体封装
 func Response(ctx *gin.Context, code string, success bool, message string, data interface{}, errors error) {
+	ResponseWithStatus(ctx, http.StatusOK, code, success, message, data, errors)
+}
+
+// 返回体封装,可指定 HTTP 状态码,用于健康检查等需要状态码区分结果的场景
+func ResponseWithStatus(ctx *gin.Context, status int, code string, success bool, message string, data interface{}, errors error) {
 	myErrors := make([]string, 0)
 	if errors != nil {
 		myErrors = append(myErrors, errors.Error())
+		ctx.Set(ErrorKey, errors.Error())
 	}
-	ctx.JSON(http.StatusOK, ResponseData{
+	ctx.Set(CodeKey, code)
+	ctx.JSON(status, ResponseData{
 		Code:    code,
 		Success: success,
 		Data:    data,
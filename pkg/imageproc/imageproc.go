@@ -0,0 +1,301 @@
+/*
+ * @PackageName: imageproc
+ * @FileName: imageproc.go
+ * @Description: 图片处理(缩放、裁剪、旋转、格式转换)
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 13:05:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 13:05:12
+ */
+
+package imageproc
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	// 等比缩放,完整显示在宽高范围内
+	FitContain = "contain"
+	// 等比缩放后居中裁剪,铺满宽高
+	FitCover = "cover"
+	// 拉伸至指定宽高
+	FitFill = "fill"
+
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// Options 图片处理参数
+type Options struct {
+	// 输出宽度,0 表示按比例自动计算
+	Width int
+	// 输出高度,0 表示按比例自动计算
+	Height int
+	// 缩放模式:contain/cover/fill
+	Fit string
+	// 输出质量(1-100),仅 jpeg 有效
+	Quality int
+	// 输出格式:jpeg/png/webp,为空时沿用原图格式
+	Format string
+	// 顺时针旋转角度:0/90/180/270
+	Rotate int
+}
+
+// Limits 图片处理限制,防止滥用
+type Limits struct {
+	// 输出最大宽度
+	MaxWidth int
+	// 输出最大高度
+	MaxHeight int
+	// 原图最大像素数
+	MaxSourcePixels int
+	// 默认输出质量
+	DefaultQuality int
+}
+
+// queryKeys 图片处理使用的查询参数
+var queryKeys = []string{"width", "height", "fit", "quality", "format", "rotate"}
+
+// ParseOptions 从查询参数中解析图片处理参数,未携带任何处理参数时 ok 为 false
+func ParseOptions(query url.Values, limits Limits) (opts Options, ok bool, err error) {
+	for _, key := range queryKeys {
+		if query.Get(key) != "" {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return opts, false, nil
+	}
+
+	if opts.Width, err = parseInt(query, "width"); err != nil {
+		return opts, true, err
+	}
+	if opts.Height, err = parseInt(query, "height"); err != nil {
+		return opts, true, err
+	}
+	if opts.Quality, err = parseInt(query, "quality"); err != nil {
+		return opts, true, err
+	}
+	if opts.Rotate, err = parseInt(query, "rotate"); err != nil {
+		return opts, true, err
+	}
+	opts.Fit = strings.ToLower(query.Get("fit"))
+	opts.Format = strings.ToLower(query.Get("format"))
+	if opts.Format == "jpg" {
+		opts.Format = FormatJPEG
+	}
+
+	if opts.Width < 0 || opts.Height < 0 {
+		return opts, true, errors.New("width和height不能为负数")
+	}
+	if limits.MaxWidth > 0 && opts.Width > limits.MaxWidth {
+		return opts, true, fmt.Errorf("width不能超过%d", limits.MaxWidth)
+	}
+	if limits.MaxHeight > 0 && opts.Height > limits.MaxHeight {
+		return opts, true, fmt.Errorf("height不能超过%d", limits.MaxHeight)
+	}
+	switch opts.Fit {
+	case "":
+		opts.Fit = FitContain
+	case FitContain, FitCover, FitFill:
+	default:
+		return opts, true, errors.New("fit仅支持contain、cover、fill")
+	}
+	if opts.Quality == 0 {
+		opts.Quality = limits.DefaultQuality
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return opts, true, errors.New("quality取值范围为1-100")
+	}
+	switch opts.Format {
+	case "", FormatJPEG, FormatPNG, FormatWebP:
+	default:
+		return opts, true, errors.New("format仅支持jpeg、png、webp")
+	}
+	opts.Rotate = ((opts.Rotate % 360) + 360) % 360
+	if opts.Rotate%90 != 0 {
+		return opts, true, errors.New("rotate仅支持90的倍数")
+	}
+	return opts, true, nil
+}
+
+// CacheKey 返回处理参数的唯一标识,用于缓存文件命名
+func (o Options) CacheKey() string {
+	return fmt.Sprintf("w%d_h%d_%s_q%d_r%d_%s", o.Width, o.Height, o.Fit, o.Quality, o.Rotate, o.Format)
+}
+
+// ContentType 返回输出格式对应的 MIME 类型
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// IsSupported 判断 MIME 类型是否支持处理
+func IsSupported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Process 解码 r 中的图片,按参数处理后写入 w,返回实际输出格式
+func Process(r io.ReadSeeker, w io.Writer, opts Options, limits Limits) (string, error) {
+	cfg, srcFormat, err := image.DecodeConfig(r)
+	if err != nil {
+		return "", err
+	}
+	if limits.MaxSourcePixels > 0 && cfg.Width*cfg.Height > limits.MaxSourcePixels {
+		return "", errors.New("原图尺寸过大,无法处理")
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return "", err
+	}
+
+	img := rotate(src, opts.Rotate)
+	img = resize(img, opts, limits)
+
+	format := opts.Format
+	if format == "" {
+		format = srcFormat
+		// gif 等格式统一输出为 png
+		if format != FormatJPEG && format != FormatWebP {
+			format = FormatPNG
+		}
+	}
+
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: opts.Quality})
+	case FormatWebP:
+		// 纯 Go 实现的 webp 编码器仅支持无损压缩,quality 不生效
+		err = nativewebp.Encode(w, img, nil)
+	default:
+		err = png.Encode(w, img)
+	}
+	return format, err
+}
+
+// resize 按缩放模式调整图片尺寸
+func resize(src image.Image, opts Options, limits Limits) image.Image {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if (opts.Width == 0 && opts.Height == 0) || sw == 0 || sh == 0 {
+		return src
+	}
+
+	width, height := opts.Width, opts.Height
+	fit := opts.Fit
+	// 只指定一边时按原图比例计算另一边;比例悬殊的原图算出的一边可能远超限制,
+	// 此时限制为最大宽高并等比缩放至该范围内,避免分配过大的内存
+	if width == 0 {
+		width = max(1, sw*height/sh)
+		if limits.MaxWidth > 0 && width > limits.MaxWidth {
+			width, fit = limits.MaxWidth, FitContain
+		}
+	}
+	if height == 0 {
+		height = max(1, sh*width/sw)
+		if limits.MaxHeight > 0 && height > limits.MaxHeight {
+			height, fit = limits.MaxHeight, FitContain
+		}
+	}
+
+	srcRect := src.Bounds()
+	dstW, dstH := width, height
+	switch fit {
+	case FitContain:
+		if sw*height > sh*width {
+			dstH = max(1, sh*width/sw)
+		} else {
+			dstW = max(1, sw*height/sh)
+		}
+	case FitCover:
+		// 按目标比例从原图中心截取
+		if sw*height > sh*width {
+			cropW := max(1, sh*width/height)
+			x0 := srcRect.Min.X + (sw-cropW)/2
+			srcRect = image.Rect(x0, srcRect.Min.Y, x0+cropW, srcRect.Max.Y)
+		} else {
+			cropH := max(1, sw*height/width)
+			y0 := srcRect.Min.Y + (sh-cropH)/2
+			srcRect = image.Rect(srcRect.Min.X, y0, srcRect.Max.X, y0+cropH)
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+	return dst
+}
+
+// rotate 顺时针旋转图片
+func rotate(src image.Image, degree int) image.Image {
+	if degree == 0 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var dst *image.NRGBA
+	if degree == 180 {
+		dst = image.NewNRGBA(image.Rect(0, 0, w, h))
+	} else {
+		dst = image.NewNRGBA(image.Rect(0, 0, h, w))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := src.At(b.Min.X+x, b.Min.Y+y)
+			switch degree {
+			case 90:
+				dst.Set(h-1-y, x, c)
+			case 180:
+				dst.Set(w-1-x, h-1-y, c)
+			case 270:
+				dst.Set(y, w-1-x, c)
+			}
+		}
+	}
+	return dst
+}
+
+// parseInt 解析整型查询参数
+func parseInt(query url.Values, key string) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s参数有误", key)
+	}
+	return n, nil
+}
+
+// DefaultFormat 未指定输出格式时,根据原图 MIME 类型确定输出格式
+func DefaultFormat(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return FormatJPEG
+	case "image/webp":
+		return FormatWebP
+	}
+	return FormatPNG
+}
@@ -0,0 +1,81 @@
+/*
+ * @PackageName: jwt
+ * @FileName: jwt.go
+ * @Description: HS256 签名的 JWT 会话令牌
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 05:02:16
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 05:02:16
+ */
+
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("令牌无效")
+	ErrExpired      = errors.New("令牌已过期")
+)
+
+// 固定的令牌头,只支持 HS256
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims 令牌携带的声明
+type Claims struct {
+	// 用户名
+	Subject string `json:"sub"`
+	// 用户的令牌版本,修改密码或禁用用户后递增,使已签发的令牌失效
+	Version int `json:"ver"`
+	// 签发时间(Unix 秒)
+	IssuedAt int64 `json:"iat"`
+	// 过期时间(Unix 秒)
+	ExpiresAt int64 `json:"exp"`
+}
+
+// Sign 使用密钥签发令牌
+func Sign(secret []byte, claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signature(secret, unsigned), nil
+}
+
+// Parse 校验令牌的签名及有效期,返回其中的声明
+func Parse(secret []byte, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signature(secret, parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrExpired
+	}
+	return &claims, nil
+}
+
+// signature 计算 HMAC-SHA256 签名
+func signature(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,100 @@
+/*
+ * @PackageName: keylock
+ * @FileName: keylock.go
+ * @Description: 按键加锁的读写锁,不同的键互不阻塞,不再使用的锁自动释放
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 01:05:33
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 01:05:33
+ */
+
+package keylock
+
+import (
+	"sort"
+	"sync"
+)
+
+// Locker 按键加锁的读写锁
+type Locker struct {
+	mu    sync.Mutex
+	locks map[string]*entry
+}
+
+// entry 单个键的锁及其引用计数,引用计数为 0 时从 map 中删除
+type entry struct {
+	sync.RWMutex
+	refs int
+}
+
+// New 创建按键加锁的读写锁
+func New() *Locker {
+	return &Locker{locks: make(map[string]*entry)}
+}
+
+// Lock 对 key 加写锁,返回解锁函数
+func (l *Locker) Lock(key string) func() {
+	e := l.acquire(key)
+	e.Lock()
+	return func() {
+		e.Unlock()
+		l.release(key)
+	}
+}
+
+// RLock 对 key 加读锁,返回解锁函数
+func (l *Locker) RLock(key string) func() {
+	e := l.acquire(key)
+	e.RLock()
+	return func() {
+		e.RUnlock()
+		l.release(key)
+	}
+}
+
+// LockAll 对多个键加写锁,按键排序后依次加锁以避免死锁,重复的键只加一次
+func (l *Locker) LockAll(keys ...string) func() {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	unlocks := make([]func(), 0, len(sorted))
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		unlocks = append(unlocks, l.Lock(key))
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
+
+// Len 当前持有或等待中的键数量
+func (l *Locker) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
+
+func (l *Locker) acquire(key string) *entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.locks[key]
+	if !ok {
+		e = &entry{}
+		l.locks[key] = e
+	}
+	e.refs++
+	return e
+}
+
+func (l *Locker) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.locks[key]
+	e.refs--
+	if e.refs == 0 {
+		delete(l.locks, key)
+	}
+}
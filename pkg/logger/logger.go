@@ -23,8 +23,8 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// Logger 全局 Logger 对象
-var Logger *zap.Logger
+// Logger 全局 Logger 对象,初始化前为空实现,丢弃包初始化阶段写入的日志
+var Logger = zap.NewNop()
 
 // InitLogger 日志初始化
 func InitLogger(filename string, maxSize, maxBackup, maxAge int, compress bool, logType string, level string) {
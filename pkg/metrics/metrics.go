@@ -0,0 +1,239 @@
+/*
+ * @PackageName: metrics
+ * @FileName: metrics.go
+ * @Description: Prometheus 监控指标
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 15:40:18
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 15:40:18
+ */
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// 指标名称前缀
+const namespace = "easydfs"
+
+// Registry 指标注册表,不使用全局默认注册表,避免与第三方库的指标混杂
+var Registry = prometheus.NewRegistry()
+
+var (
+	// 请求次数
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP 请求次数",
+	}, []string{"method", "route", "status"})
+
+	// 请求耗时
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求耗时(秒)",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// 上传字节数
+	uploadedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_bytes_total",
+		Help:      "上传的字节数",
+	}, []string{"bucket"})
+
+	// 下载字节数
+	downloadedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloaded_bytes_total",
+		Help:      "下载的字节数",
+	}, []string{"bucket"})
+
+	// 正在进行的上传数
+	activeUploads = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_uploads",
+		Help:      "正在进行的上传数",
+	})
+
+	// 访问密钥校验失败次数
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "访问密钥校验失败次数",
+	}, []string{"reason"})
+
+	// 触发限流的请求次数
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "触发限流的请求次数",
+	}, []string{"scope", "dimension"})
+
+	// 触发带宽限速的传输次数
+	throttledTransfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "throttled_transfers_total",
+		Help:      "触发带宽限速的传输次数",
+	}, []string{"direction"})
+
+	// 因带宽限速等待的总时间
+	throttleWait = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "throttle_wait_seconds_total",
+		Help:      "因带宽限速等待的总时间(秒)",
+	}, []string{"direction"})
+
+	// 文件已替换但所在目录同步失败的次数,断电时该次重命名可能丢失
+	fsyncFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fsync_failures_total",
+		Help:      "文件替换后同步目录失败的次数",
+	})
+
+	// 最近一次数据完整性巡检发现的问题数
+	scrubIssues = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scrub_issues",
+		Help:      "最近一次数据完整性巡检发现的问题数",
+	}, []string{"type"})
+
+	// 最近一次数据完整性巡检完成的时间
+	scrubLastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scrub_last_run_timestamp_seconds",
+		Help:      "最近一次数据完整性巡检完成的时间(Unix 秒)",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		requestsTotal,
+		requestDuration,
+		uploadedBytes,
+		downloadedBytes,
+		activeUploads,
+		authFailures,
+		rateLimited,
+		throttledTransfers,
+		throttleWait,
+		fsyncFailures,
+		scrubIssues,
+		scrubLastRun,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveRequest 记录一次 HTTP 请求
+func ObserveRequest(method, route, status string, elapsed time.Duration) {
+	requestsTotal.WithLabelValues(method, route, status).Inc()
+	requestDuration.WithLabelValues(method, route, status).Observe(elapsed.Seconds())
+}
+
+// AddUploadedBytes 累加存储桶的上传字节数
+func AddUploadedBytes(bucket string, n int64) {
+	if n > 0 {
+		uploadedBytes.WithLabelValues(bucket).Add(float64(n))
+	}
+}
+
+// AddDownloadedBytes 累加存储桶的下载字节数
+func AddDownloadedBytes(bucket string, n int64) {
+	if n > 0 {
+		downloadedBytes.WithLabelValues(bucket).Add(float64(n))
+	}
+}
+
+// UploadStarted 上传开始,返回上传结束时需调用的函数
+func UploadStarted() func() {
+	activeUploads.Inc()
+	return activeUploads.Dec
+}
+
+// AuthFailure 记录一次访问密钥校验失败
+func AuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// RateLimited 记录一次被限流的请求,scope 为 api/storage,dimension 为 global/key/ip
+func RateLimited(scope, dimension string) {
+	rateLimited.WithLabelValues(scope, dimension).Inc()
+}
+
+// ThrottledTransfer 记录一次触发带宽限速的传输,direction 为 upload/download
+func ThrottledTransfer(direction string) {
+	throttledTransfers.WithLabelValues(direction).Inc()
+}
+
+// ThrottleWait 累加因带宽限速等待的时间
+func ThrottleWait(direction string, wait time.Duration) {
+	throttleWait.WithLabelValues(direction).Add(wait.Seconds())
+}
+
+// FsyncFailure 记录一次同步目录失败
+func FsyncFailure() {
+	fsyncFailures.Inc()
+}
+
+// ScrubFinished 记录一次数据完整性巡检的结果,issues 为各类问题的数量
+func ScrubFinished(issues map[string]int64) {
+	for issueType, n := range issues {
+		scrubIssues.WithLabelValues(issueType).Set(float64(n))
+	}
+	scrubLastRun.SetToCurrentTime()
+}
+
+// UsageFunc 统计各存储桶占用空间(字节)
+type UsageFunc func() (map[string]int64, error)
+
+// RegisterStorageUsage 注册存储桶占用空间指标,统计结果缓存 ttl 时长,避免每次采集都遍历磁盘
+func RegisterStorageUsage(fn UsageFunc, ttl time.Duration) error {
+	return Registry.Register(&storageUsageCollector{
+		fn:  fn,
+		ttl: ttl,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "bucket_storage_bytes"),
+			"存储桶占用空间(字节)",
+			[]string{"bucket"}, nil,
+		),
+	})
+}
+
+// storageUsageCollector 存储桶占用空间采集器
+type storageUsageCollector struct {
+	fn   UsageFunc
+	ttl  time.Duration
+	desc *prometheus.Desc
+
+	mu        sync.Mutex
+	usage     map[string]int64
+	updatedAt time.Time
+}
+
+func (s *storageUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.desc
+}
+
+func (s *storageUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.usage == nil || time.Since(s.updatedAt) > s.ttl {
+		usage, err := s.fn()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(s.desc, err)
+			return
+		}
+		s.usage = usage
+		s.updatedAt = time.Now()
+	}
+	for bucket, size := range s.usage {
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(size), bucket)
+	}
+}
@@ -0,0 +1,327 @@
+/*
+ * @PackageName: oidctest
+ * @FileName: oidctest.go
+ * @Description: 用于本地测试的模拟 OIDC 身份提供方,支持授权码及 PKCE,登录页直接选择预置的用户
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 07:06:44
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 07:06:44
+ */
+
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"easy_dfs/pkg/oidc"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 授权码及 ID 令牌的有效期
+const (
+	codeTTL    = time.Minute
+	idTokenTTL = 5 * time.Minute
+)
+
+// 选择用户的登录页
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Mock OIDC</title></head>
+<body><h3>选择登录的用户</h3><ul>
+{{range .}}<li><a href="{{.URL}}">{{.Name}}</a> {{.Groups}}</li>{{end}}
+</ul></body></html>`))
+
+// User 预置的用户
+type User struct {
+	Username string
+	// sub 声明,为空时预置用户时生成随机值,与真实的身份提供方一样不等于用户名
+	Subject string
+	Groups  []string
+	// 额外的 ID 令牌声明,可覆盖默认声明
+	Claims map[string]interface{}
+}
+
+// authCode 已签发的授权码
+type authCode struct {
+	user          string
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	expiresAt     time.Time
+}
+
+// Provider 模拟的身份提供方,实现 http.Handler;Issuer 须设置为其对外地址
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	mu    sync.Mutex
+	key   *rsa.PrivateKey
+	kid   string
+	users map[string]User
+	codes map[string]authCode
+}
+
+// New 创建模拟的身份提供方,clientSecret 为空时不校验客户端密钥
+func New(issuer, clientID, clientSecret string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{
+		Issuer:       issuer,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		kid:          kid[:16],
+		users:        make(map[string]User),
+		codes:        make(map[string]authCode),
+	}, nil
+}
+
+// AddUser 预置用户,同名的用户会被替换
+func (p *Provider) AddUser(user User) error {
+	if user.Subject == "" {
+		subject, err := oidc.RandomString()
+		if err != nil {
+			return err
+		}
+		user.Subject = subject[:16]
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.users[user.Username] = user
+	return nil
+}
+
+// ServeHTTP 处理配置、公钥、登录及换取令牌请求
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                p.Issuer,
+			"authorization_endpoint":                p.Issuer + "/authorize",
+			"token_endpoint":                        p.Issuer + "/token",
+			"jwks_uri":                              p.Issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	case "/jwks":
+		writeJSON(w, http.StatusOK, oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{{
+			Kty: "RSA",
+			Kid: p.kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	case "/authorize":
+		p.authorize(w, r)
+	case "/token":
+		p.token(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorize 登录页:指定 login_hint 或只有一个用户时直接登录,否则列出用户供选择
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != p.ClientID || redirectURI == "" {
+		http.Error(w, "client_id 或 redirect_uri 无效", http.StatusBadRequest)
+		return
+	}
+	back, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "redirect_uri 无效", http.StatusBadRequest)
+		return
+	}
+	values := url.Values{"state": {query.Get("state")}}
+	if query.Get("response_type") != "code" || query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		values.Set("error", "invalid_request")
+		values.Set("error_description", "需要授权码模式及 S256 PKCE")
+		redirectTo(w, r, back, values)
+		return
+	}
+
+	p.mu.Lock()
+	username := query.Get("login_hint")
+	if username == "" && len(p.users) == 1 {
+		for name := range p.users {
+			username = name
+		}
+	}
+	if username == "" {
+		page := p.userLinks(r.URL)
+		p.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = loginPage.Execute(w, page)
+		return
+	}
+	if _, ok := p.users[username]; !ok {
+		p.mu.Unlock()
+		values.Set("error", "access_denied")
+		values.Set("error_description", "用户不存在")
+		redirectTo(w, r, back, values)
+		return
+	}
+	code, err := oidc.RandomString()
+	if err != nil {
+		p.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p.codes[code] = authCode{
+		user:          username,
+		clientID:      p.ClientID,
+		redirectURI:   redirectURI,
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	values.Set("code", code)
+	redirectTo(w, r, back, values)
+}
+
+// userLinks 返回登录页中每个用户的登录链接,调用时需持有锁
+func (p *Provider) userLinks(current *url.URL) []map[string]interface{} {
+	names := make([]string, 0, len(p.users))
+	for name := range p.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	links := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		query := current.Query()
+		query.Set("login_hint", name)
+		links = append(links, map[string]interface{}{
+			"Name":   name,
+			"Groups": p.users[name].Groups,
+			"URL":    current.Path + "?" + query.Encode(),
+		})
+	}
+	return links
+}
+
+// token 使用授权码换取 ID 令牌,校验客户端、回调地址及 PKCE 校验码,授权码只能使用一次
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "invalid_request", "需要使用 POST 表单提交授权码")
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.ClientID || (p.ClientSecret != "" && clientSecret != p.ClientSecret) {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "客户端 ID 或密钥错误")
+		return
+	}
+
+	p.mu.Lock()
+	code, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	user := p.users[code.user]
+	p.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(code.expiresAt) || code.clientID != clientID:
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "授权码无效或已过期")
+	case code.redirectURI != r.PostForm.Get("redirect_uri"):
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri 不一致")
+	case oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != code.codeChallenge:
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "PKCE 校验失败")
+	default:
+		idToken, err := p.SignIDToken(user, code.nonce, time.Now())
+		if err != nil {
+			tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": idToken,
+			"token_type":   "Bearer",
+			"expires_in":   int(idTokenTTL.Seconds()),
+			"id_token":     idToken,
+		})
+	}
+}
+
+// SignIDToken 为用户签发 ID 令牌
+func (p *Provider) SignIDToken(user User, nonce string, now time.Time) (string, error) {
+	if user.Username == "" {
+		return "", errors.New("用户不存在")
+	}
+	claims := map[string]interface{}{
+		"iss":                p.Issuer,
+		"sub":                user.Subject,
+		"aud":                p.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(idTokenTTL).Unix(),
+		"nonce":              nonce,
+		"preferred_username": user.Username,
+		"groups":             user.Groups,
+	}
+	for k, v := range user.Claims {
+		claims[k] = v
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": p.kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// redirectTo 带上参数跳转回客户端的回调地址
+func redirectTo(w http.ResponseWriter, r *http.Request, back *url.URL, values url.Values) {
+	query := back.Query()
+	for k, v := range values {
+		query[k] = v
+	}
+	target := *back
+	target.RawQuery = query.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// tokenError 返回换取令牌失败的错误
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+// writeJSON 返回 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
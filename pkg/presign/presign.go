@@ -0,0 +1,90 @@
+/*
+ * @PackageName: presign
+ * @FileName: presign.go
+ * @Description: 签名URL
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 17:10:36
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 17:10:36
+ */
+
+package presign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 访问密钥参数
+	QueryAccessKey = "accessKey"
+	// 过期时间参数(Unix 秒)
+	QueryExpires = "expires"
+	// 签名参数
+	QuerySignature = "signature"
+)
+
+// Sign 计算签名:HMAC-SHA256(secretKey, method + "\n" + bucket + "\n" + key + "\n" + expires)
+func Sign(secretKey, method, bucket, key string, expires int64) string {
+	stringToSign := strings.Join([]string{normalizeMethod(method), bucket, strings.TrimPrefix(key, "/"), strconv.FormatInt(expires, 10)}, "\n")
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL 生成文件的签名访问地址,endpoint 如 http://localhost:18088
+func URL(endpoint, bucket, key, accessKey, secretKey string, expires time.Time) string {
+	key = strings.TrimPrefix(key, "/")
+	query := url.Values{}
+	query.Set(QueryAccessKey, accessKey)
+	query.Set(QueryExpires, strconv.FormatInt(expires.Unix(), 10))
+	query.Set(QuerySignature, Sign(secretKey, "GET", bucket, key, expires.Unix()))
+
+	u := strings.TrimSuffix(endpoint, "/") + "/storage/" + url.PathEscape(bucket) + "/" + escapeKey(key)
+	return u + "?" + query.Encode()
+}
+
+// HasSignature 请求是否携带签名
+func HasSignature(query url.Values) bool {
+	return query.Get(QuerySignature) != ""
+}
+
+// Verify 校验签名及有效期
+func Verify(secretKey, method, bucket, key string, query url.Values, now time.Time) error {
+	expires, err := strconv.ParseInt(query.Get(QueryExpires), 10, 64)
+	if err != nil {
+		return errors.New("签名过期时间有误")
+	}
+	if now.Unix() > expires {
+		return errors.New("签名已过期")
+	}
+	expected := Sign(secretKey, method, bucket, key, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get(QuerySignature))) {
+		return errors.New("签名无效")
+	}
+	return nil
+}
+
+// normalizeMethod HEAD 与 GET 使用同一签名
+func normalizeMethod(method string) string {
+	method = strings.ToUpper(method)
+	if method == "HEAD" {
+		return "GET"
+	}
+	return method
+}
+
+// escapeKey 按路径段转义文件名,保留 /
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
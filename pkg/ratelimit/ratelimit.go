@@ -0,0 +1,94 @@
+/*
+ * @PackageName: ratelimit
+ * @FileName: ratelimit.go
+ * @Description: 令牌桶限流器(单机内存实现)
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:42:10
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:42:10
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// 空闲多久的令牌桶会被清理
+const idleTimeout = 10 * time.Minute
+
+// bucket 单个限流对象的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter 按 key 区分的令牌桶限流器,每个 key 每秒补充 rate 个令牌,最多累积 burst 个
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// New 创建限流器,rate <= 0 时返回 nil,表示不限流
+func New(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = int(math.Ceil(rate))
+	}
+	return &Limiter{
+		rate:        rate,
+		burst:       float64(burst),
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Allow 消耗 key 对应令牌桶中的一个令牌,令牌不足时返回 false 及需要等待的时间
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cleanup(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// cleanup 定期清理长时间未使用的令牌桶,避免 key 数量无限增长,调用方需持有锁
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < idleTimeout {
+		return
+	}
+	l.lastCleanup = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
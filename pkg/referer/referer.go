@@ -0,0 +1,81 @@
+/*
+ * @PackageName: referer
+ * @FileName: referer.go
+ * @Description: 防盗链规则,按请求来源(Referer)的域名判断是否允许访问
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 21:31:25
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 21:31:25
+ */
+
+package referer
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// 规则模式
+const (
+	// ModeAllowlist 仅允许匹配的来源
+	ModeAllowlist = "allowlist"
+	// ModeDenylist 拒绝匹配的来源
+	ModeDenylist = "denylist"
+)
+
+// Rule 防盗链规则
+type Rule struct {
+	// 模式:allowlist=白名单,denylist=黑名单
+	Mode string `json:"mode"`
+	// 来源域名,支持通配符,如 example.com、*.example.com
+	Referers []string `json:"referers"`
+	// 是否允许不带 Referer 的请求(如直接在浏览器打开)
+	AllowEmpty bool `json:"allowEmpty"`
+	// 被拒绝时返回的存储桶内文件,为空时返回 403
+	Placeholder string `json:"placeholder,omitempty"`
+}
+
+// Validate 校验规则
+func (r Rule) Validate() error {
+	if r.Mode != ModeAllowlist && r.Mode != ModeDenylist {
+		return errors.New("mode只能为allowlist或denylist")
+	}
+	for _, pattern := range r.Referers {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return errors.New("来源格式非法: " + pattern)
+		}
+	}
+	return nil
+}
+
+// Allowed 判断 Referer 请求头的值是否允许访问
+func (r Rule) Allowed(referer string) bool {
+	if referer == "" {
+		return r.AllowEmpty
+	}
+	matched := r.matches(referer)
+	if r.Mode == ModeDenylist {
+		return !matched
+	}
+	return matched
+}
+
+// matches 来源域名是否匹配规则中的任一域名
+func (r Rule) matches(referer string) bool {
+	u, err := url.Parse(referer)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return false
+	}
+	for _, pattern := range r.Referers {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+/*
+ * @PackageName: website
+ * @FileName: website.go
+ * @Description: 静态网站托管,根据配置将请求路径解析为存储桶内的文件或重定向
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 23:24:50
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 23:24:50
+ */
+
+package website
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// 未配置时的默认值
+const (
+	DefaultIndexDocument = "index.html"
+	DefaultCacheMaxAge   = 3600
+)
+
+// Config 存储桶的静态网站配置
+type Config struct {
+	// 首页文件,访问 / 及目录时返回,默认 index.html
+	IndexDocument string `json:"indexDocument"`
+	// 错误页文件,文件不存在时以 404 返回,为空时返回默认的 404
+	ErrorDocument string `json:"errorDocument,omitempty"`
+	// 单页应用模式:文件不存在时返回根目录的首页文件,由前端路由处理
+	SpaFallback bool `json:"spaFallback"`
+	// 重定向规则,按顺序匹配第一条
+	Redirects []Redirect `json:"redirects,omitempty"`
+	// 非 HTML 文件的缓存时间,单位:秒,默认 3600;HTML 文件始终需向服务端确认
+	CacheMaxAge int `json:"cacheMaxAge,omitempty"`
+}
+
+// Redirect 重定向规则:文件名以 Prefix 开头时,将该前缀替换为 Target 后重定向
+type Redirect struct {
+	// 匹配的文件名前缀,如 docs/
+	Prefix string `json:"prefix"`
+	// 替换后的地址,可以是完整URL(如 https://example.com/)或站点内的路径(如 /manual/)
+	Target string `json:"target"`
+	// 重定向状态码:301、302、307、308,默认 301
+	Status int `json:"status,omitempty"`
+}
+
+// Result 解析结果
+type Result struct {
+	// 返回的文件名,为空表示没有可返回的文件
+	Key string
+	// HTTP 状态码
+	Status int
+	// 重定向地址,状态码为 3xx 时有效
+	Location string
+}
+
+// Validate 校验配置并补全默认值
+func (c *Config) Validate() error {
+	if c.IndexDocument == "" {
+		c.IndexDocument = DefaultIndexDocument
+	}
+	if strings.Contains(c.IndexDocument, "/") {
+		return errors.New("indexDocument不能包含 /")
+	}
+	if c.CacheMaxAge < 0 {
+		return errors.New("cacheMaxAge不能小于0")
+	}
+	for i, r := range c.Redirects {
+		if r.Prefix == "" || r.Target == "" {
+			return errors.New("重定向规则的prefix和target不能为空")
+		}
+		switch r.Status {
+		case 0:
+			c.Redirects[i].Status = http.StatusMovedPermanently
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return errors.New("重定向状态码只能为301、302、307、308")
+		}
+	}
+	return nil
+}
+
+// Resolve 将请求的文件名解析为要返回的文件,key 以 / 结尾或为空时表示目录,exists 判断文件是否存在
+func (c Config) Resolve(key string, exists func(key string) bool) Result {
+	for _, r := range c.Redirects {
+		if strings.HasPrefix(key, r.Prefix) {
+			location := r.Target + strings.TrimPrefix(key, r.Prefix)
+			return Result{Status: r.Status, Location: location}
+		}
+	}
+
+	index := c.IndexDocument
+	if index == "" {
+		index = DefaultIndexDocument
+	}
+
+	if key == "" || strings.HasSuffix(key, "/") {
+		if exists(key + index) {
+			return Result{Key: key + index, Status: http.StatusOK}
+		}
+	} else {
+		if exists(key) {
+			return Result{Key: key, Status: http.StatusOK}
+		}
+		// 访问目录但未以 / 结尾时重定向,保证页面内的相对地址正确
+		if exists(key + "/" + index) {
+			return Result{Status: http.StatusFound, Location: path.Base(key) + "/"}
+		}
+	}
+
+	if c.SpaFallback && exists(index) {
+		return Result{Key: index, Status: http.StatusOK}
+	}
+	if c.ErrorDocument != "" && exists(c.ErrorDocument) {
+		return Result{Key: c.ErrorDocument, Status: http.StatusNotFound}
+	}
+	return Result{Status: http.StatusNotFound}
+}
+
+// IsHTML 是否为 HTML 文件
+func IsHTML(key string) bool {
+	ext := strings.ToLower(path.Ext(key))
+	return ext == ".html" || ext == ".htm"
+}
@@ -14,58 +14,170 @@ import (
 	c "easy_dfs/app/controllers"
 	"easy_dfs/app/enum/response_code"
 	"easy_dfs/app/middlewares"
+	"easy_dfs/pkg/config"
 	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/web"
 	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
 )
 
 // 路由注册
 func RegisterRoutes(r *gin.Engine) {
-	// 默认路由
+	consoleEnabled := config.GetBool("console.enabled", true)
+
+	// 默认路由,浏览器访问时跳转到管理控制台
 	r.GET("/", func(ctx *gin.Context) {
+		if consoleEnabled && strings.Contains(ctx.GetHeader("Accept"), "text/html") {
+			ctx.Redirect(http.StatusFound, "/console/")
+			return
+		}
 		http_response.Response(ctx, response_code.REQUEST_SUCCESS, true, "操作成功", "欢迎使用本系统", nil)
 	})
 
+	// 管理控制台(内嵌的单页应用)
+	if consoleEnabled {
+		r.StaticFS("/console", http.FS(web.Console()))
+	}
+
 	// 忽略/favicon.ico
 	r.Any("/favicon.ico", func(ctx *gin.Context) {
 		ctx.Next()
 	})
 
+	// 健康检查
+	hc := new(c.HealthController)
+	r.GET("/healthz", hc.Healthz)
+	r.GET("/readyz", hc.Readyz)
+
+	// 监控指标
+	mc := new(c.MetricsController)
+	r.GET("/metrics", middlewares.MetricsAuth(), mc.Metrics)
+
+	// 跨域中间件:管理接口使用全局配置,文件相关接口使用存储桶配置
+	adminCors := middlewares.Cors()
+	bucketCors := middlewares.BucketCors()
+	// 预检请求由跨域中间件处理,此处仅用于注册 OPTIONS 路由
+	preflight := func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	}
+
+	// 限流中间件,同一范围的路由组共用以共享额度
+	apiLimit := middlewares.RateLimit(middlewares.RateLimitAPI)
+	storageLimit := middlewares.RateLimit(middlewares.RateLimitStorage)
+	// 按访问密钥限流,放在密钥校验之后
+	keyLimit := middlewares.KeyRateLimit()
+
+	// 审计日志中间件,放在密钥校验之前以记录校验失败的变更请求
+	auditLog := middlewares.Audit()
+
 	// storage路由组，并应用中间件
-	sg := r.Group("/storage").Use(middlewares.FileUrlCheck())
+	sg := r.Group("/storage").Use(bucketCors, storageLimit, middlewares.FileUrlCheck())
 	{
 		sc := new(c.StorageController)
 		sg.Any("/*path", sc.GetFile)
 	}
 
 	// 存储桶路由
-	br := r.Group("/bucket").Use(middlewares.AccessKeyCheck())
+	br := r.Group("/bucket").Use(adminCors, apiLimit, auditLog, middlewares.AccessKeyCheck(), keyLimit, middlewares.BucketPermission())
 	{
 		bc := new(c.BucketController)
 		br.POST("/create", bc.CreateBucket)
 		br.GET("/list", bc.ListBuckets)
 		br.GET("/info", bc.GetBucketInfo)
 		br.DELETE("/delete", bc.DeleteBucket)
+		br.GET("/delete/job", bc.GetDeleteJob)
+		br.PUT("/policy", bc.SetBucketPolicy)
+		br.GET("/usage", bc.GetBucketUsage)
+		br.PUT("/webhook", bc.SetBucketWebhooks)
+		br.GET("/webhook/deliveries", bc.ListWebhookDeliveries)
+		br.PUT("/cors", bc.SetBucketCors)
+		br.PUT("/referer", bc.SetBucketReferer)
+		br.PUT("/domain", bc.SetBucketDomains)
+		br.PUT("/website", bc.SetBucketWebsite)
+		br.PUT("/update", bc.UpdateBucket)
+		br.PUT("/rename", bc.RenameBucket)
+		br.OPTIONS("/*path", preflight)
+	}
+
+	// 登录路由,登录及单点登录接口不校验密钥
+	aur := r.Group("/auth").Use(adminCors, apiLimit, auditLog)
+	{
+		auc := new(c.AuthController)
+		aur.POST("/login", auc.Login)
+		aur.GET("/providers", auc.Providers)
+		aur.GET("/oidc/login", auc.OidcLogin)
+		aur.GET("/oidc/callback", auc.OidcCallback)
+		aur.GET("/me", middlewares.AccessKeyCheck(), keyLimit, auc.Me)
+		aur.POST("/password", middlewares.AccessKeyCheck(), keyLimit, auc.ChangePassword)
+		aur.OPTIONS("/*path", preflight)
 	}
 
-	// 访问密钥路由
-	akr := r.Group("/access_key")
+	// 用户管理路由
+	ur := r.Group("/user").Use(adminCors, apiLimit, auditLog, middlewares.AccessKeyCheck(), keyLimit, middlewares.RequireAdmin())
+	{
+		uc := new(c.UserController)
+		ur.POST("/create", uc.CreateUser)
+		ur.GET("/list", uc.ListUsers)
+		ur.GET("/info", uc.GetUserInfo)
+		ur.PUT("/update", uc.UpdateUser)
+		ur.POST("/revoke", uc.RevokeUser)
+		ur.DELETE("/delete", uc.DeleteUser)
+		ur.OPTIONS("/*path", preflight)
+	}
+
+	// 访问密钥路由,非管理员只能管理自己的密钥
+	akr := r.Group("/access_key").Use(adminCors, apiLimit, auditLog, middlewares.AccessKeyCheck(), keyLimit)
 	{
 		akc := new(c.AccessKeyController)
 		akr.POST("/create", akc.CreateAccessKey)
 		akr.GET("/list", akc.ListAccessKeys)
 		akr.GET("/info", akc.GetAccessKeyInfo)
 		akr.DELETE("/delete", akc.DeleteAccessKey)
+		akr.OPTIONS("/*path", preflight)
 	}
 
 	// 文件路由
-	fr := r.Group("/file").Use(middlewares.AccessKeyCheck())
+	fr := r.Group("/file").Use(bucketCors, apiLimit, auditLog, middlewares.AccessKeyCheck(), keyLimit, middlewares.BucketPermission())
 	{
 		fc := new(c.FileController)
 		fr.POST("/upload", fc.UploadFile)
+		fr.PUT("/object/:bucket/*key", fc.PutObject)
 		fr.GET("/download", fc.DownloadFile)
+		fr.GET("/archive", fc.DownloadArchive)
+		fr.POST("/archive", fc.DownloadArchive)
 		fr.GET("/list", fc.ListFiles)
 		fr.GET("/list-all", fc.ListAllFiles)
 		fr.GET("/info", fc.GetFileInfo)
+		fr.POST("/rename", fc.RenameFile)
 		fr.DELETE("/delete", fc.DeleteFile)
+		fr.OPTIONS("/*path", preflight)
+	}
+
+	// 数据完整性巡检路由
+	scr := r.Group("/scrub").Use(adminCors, apiLimit, auditLog, middlewares.AccessKeyCheck(), keyLimit, middlewares.RequireAdmin())
+	{
+		scc := new(c.ScrubController)
+		scr.POST("/run", scc.RunScrub)
+		scr.GET("/report", scc.GetScrubReport)
+		scr.OPTIONS("/*path", preflight)
+	}
+
+	// 数据一致性检查路由
+	fsr := r.Group("/fsck").Use(adminCors, apiLimit, auditLog, middlewares.AccessKeyCheck(), keyLimit, middlewares.RequireAdmin())
+	{
+		fsc := new(c.FsckController)
+		fsr.GET("/check", fsc.Check)
+		fsr.POST("/reconcile", fsc.Reconcile)
+		fsr.OPTIONS("/*path", preflight)
+	}
+
+	// 审计日志路由
+	ar := r.Group("/audit").Use(adminCors, apiLimit, middlewares.AccessKeyCheck(), keyLimit, middlewares.RequireAdmin())
+	{
+		ac := new(c.AuditController)
+		ar.GET("/list", ac.ListAuditRecords)
+		ar.GET("/export", ac.ExportAuditRecords)
+		ar.OPTIONS("/*path", preflight)
 	}
 }
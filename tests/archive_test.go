@@ -0,0 +1,81 @@
+/*
+ * @PackageName: tests
+ * @Description:
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 10:40:21
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 10:40:21
+ */
+
+package tests
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"easy_dfs/pkg/filesystem"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZipArchiveWriter(t *testing.T) {
+	var buf bytes.Buffer
+	aw, err := filesystem.NewArchiveWriter(&buf, filesystem.ArchiveFormatZip)
+	if err != nil {
+		t.Fatalf("Failed to create archive writer: %v", err)
+	}
+	if err := aw.AddFile("invoices/a.txt", 5, time.Now(), strings.NewReader("hello")); err != nil {
+		t.Fatalf("Failed to add file: %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read zip: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "invoices/a.txt" {
+		t.Fatalf("Unexpected zip entries: %v", zr.File)
+	}
+	rc, _ := zr.File[0].Open()
+	content, _ := io.ReadAll(rc)
+	if string(content) != "hello" {
+		t.Fatalf("Expected hello but got %s", content)
+	}
+}
+
+func TestTarGzArchiveWriter(t *testing.T) {
+	var buf bytes.Buffer
+	aw, err := filesystem.NewArchiveWriter(&buf, filesystem.ArchiveFormatTarGz)
+	if err != nil {
+		t.Fatalf("Failed to create archive writer: %v", err)
+	}
+	if err := aw.AddFile("a.txt", 5, time.Now(), strings.NewReader("hello")); err != nil {
+		t.Fatalf("Failed to add file: %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read gzip: %v", err)
+	}
+	tr := tar.NewReader(gr)
+	header, err := tr.Next()
+	if err != nil || header.Name != "a.txt" {
+		t.Fatalf("Unexpected tar entry: %v %v", header, err)
+	}
+	content, _ := io.ReadAll(tr)
+	if string(content) != "hello" {
+		t.Fatalf("Expected hello but got %s", content)
+	}
+
+	if _, err := filesystem.NewArchiveWriter(&buf, "rar"); err == nil {
+		t.Fatalf("Expected error for unsupported format")
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * @PackageName: tests
+ * @Description: 审计日志的查询条件、返回条数、中间件记录、导出及关闭审计测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 09:31:06
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 09:31:06
+ */
+
+package tests
+
+import (
+	"bufio"
+	"easy_dfs/app/enum/audit_action"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAuditListFiltersAndLimit(t *testing.T) {
+	setupTestDir(t, nil)
+	as := new(services.AuditService)
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		bucket := "photos"
+		if i%2 == 1 {
+			bucket = "docs"
+		}
+		record := model.AuditRecord{
+			ID:        fmt.Sprintf("r%d", i),
+			Time:      base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+			Action:    audit_action.FILE_UPLOAD,
+			Bucket:    bucket,
+			AccessKey: "AK1",
+			Actor:     "ci",
+		}
+		if i == 9 {
+			record.Action = audit_action.FILE_DELETE
+			record.AccessKey = "AK2"
+			record.Actor = "alice"
+		}
+		if err := as.Record(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(records []model.AuditRecord) string {
+		var s string
+		for _, r := range records {
+			s += r.ID + " "
+		}
+		return s
+	}
+	cases := []struct {
+		name  string
+		query services.AuditQuery
+		want  string
+	}{
+		{"latest first", services.AuditQuery{Limit: 3}, "r9 r8 r7 "},
+		{"limit larger than records", services.AuditQuery{Limit: 100}, "r9 r8 r7 r6 r5 r4 r3 r2 r1 r0 "},
+		{"bucket", services.AuditQuery{Bucket: "docs", Limit: 2}, "r9 r7 "},
+		{"action", services.AuditQuery{Action: audit_action.FILE_DELETE}, "r9 "},
+		{"access key", services.AuditQuery{Key: "AK2"}, "r9 "},
+		{"actor", services.AuditQuery{Key: "ci", Limit: 4}, "r8 r7 r6 r5 "},
+		{"start inclusive end exclusive", services.AuditQuery{Start: base.Add(2 * time.Hour), End: base.Add(5 * time.Hour)}, "r4 r3 r2 "},
+		{"no match", services.AuditQuery{Bucket: "missing"}, ""},
+	}
+	for _, c := range cases {
+		records, err := as.List(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(records); got != c.want {
+			t.Errorf("%s: expected %q, got %q", c.name, c.want, got)
+		}
+	}
+}
+
+func TestAuditMiddlewareAndExport(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	if result := server.call(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil); result.Success {
+		t.Fatal("Expected duplicate bucket to fail")
+	}
+	// 伪造的访问密钥不能作为操作人
+	resp := server.request(http.MethodDelete, "/bucket/delete?bucket=photos", "", nil, map[string]string{"X-Access-Key": "forged", "X-Secret-Key": "forged"})
+	resp.Body.Close()
+
+	var records []model.AuditRecord
+	server.mustCall(http.MethodGet, "/audit/list?limit=10", server.AdminToken, nil, &records)
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %+v", records)
+	}
+	deleted, failed, created := records[0], records[1], records[2]
+	if created.Action != audit_action.BUCKET_CREATE || !created.Success || created.Bucket != "photos" || created.Actor != "admin" || created.Code != response_code.REQUEST_SUCCESS {
+		t.Errorf("Unexpected create record: %+v", created)
+	}
+	if failed.Success || failed.Error == "" {
+		t.Errorf("Expected failed create record with error, got %+v", failed)
+	}
+	if deleted.Action != audit_action.BUCKET_DELETE || deleted.Success || deleted.Actor != "" || deleted.AccessKey != "forged" || deleted.Code != response_code.TOKEN_INVALID || deleted.Bucket != "photos" {
+		t.Errorf("Unexpected forged delete record: %+v", deleted)
+	}
+
+	server.mustCall(http.MethodGet, "/audit/list?limit=1&action="+audit_action.BUCKET_CREATE, server.AdminToken, nil, &records)
+	if len(records) != 1 || records[0].ID != failed.ID {
+		t.Errorf("Expected latest create record, got %+v", records)
+	}
+	if result := server.call(http.MethodGet, "/audit/list?start=yesterday", server.AdminToken, nil, nil); result.Code != response_code.PARAM_ERROR {
+		t.Errorf("Expected invalid start to be rejected, got %s", result.Code)
+	}
+
+	// 导出按时间顺序输出 JSON Lines
+	resp = server.request(http.MethodGet, "/audit/export?bucket=photos", server.AdminToken, nil, nil)
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Unexpected content type %s", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != "attachment; filename=audit.jsonl" {
+		t.Errorf("Unexpected content disposition %s", cd)
+	}
+	var exported []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var record model.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Invalid line %q: %v", scanner.Text(), err)
+		}
+		exported = append(exported, record.ID)
+	}
+	if len(exported) != 3 || exported[0] != created.ID || exported[2] != deleted.ID {
+		t.Errorf("Unexpected export order %v", exported)
+	}
+
+	// 非管理员不能查询审计日志
+	reader := server.createUser("reader", user_role.READ_ONLY)
+	if result := server.call(http.MethodGet, "/audit/list", reader, nil, nil); result.Success {
+		t.Error("Expected non-admin to be denied")
+	}
+}
+
+// 配置为 false 时关闭审计日志,不使用默认值
+func TestAuditDisabled(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"audit.enabled": false})
+	if new(services.AuditService).Enabled() {
+		t.Fatal("Expected audit to be disabled")
+	}
+
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	var records []model.AuditRecord
+	server.mustCall(http.MethodGet, "/audit/list", server.AdminToken, nil, &records)
+	if len(records) != 0 {
+		t.Errorf("Expected no records, got %+v", records)
+	}
+	if _, err := os.Stat("tmp/config/audit.jsonl"); !os.IsNotExist(err) {
+		t.Errorf("Expected no audit log, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * @PackageName: tests
+ * @Description: 带宽限速测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 20:36:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 20:36:52
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"easy_dfs/pkg/bandwidth"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestThrottleWriterLimitsRate(t *testing.T) {
+	// 1MB/s,初始可突发 1MB,写入 1.5MB 约需等待 0.5 秒
+	throttle := bandwidth.NewThrottle(context.Background(), bandwidth.NewLimiter(1<<20))
+	var waited time.Duration
+	throttle.OnWait = func(d time.Duration) { waited += d }
+
+	data := bytes.Repeat([]byte("x"), 3<<19)
+	var buf bytes.Buffer
+	start := time.Now()
+	n, err := io.Copy(throttle.Writer(&buf), bytes.NewReader(data))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("copy: n=%d err=%v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("transfer was not throttled, took %v", elapsed)
+	}
+	if waited == 0 {
+		t.Fatal("OnWait was not called")
+	}
+}
+
+func TestThrottleReaderStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	throttle := bandwidth.NewThrottle(ctx, bandwidth.NewLimiter(32<<10))
+
+	_, err := io.Copy(io.Discard, throttle.Reader(bytes.NewReader(make([]byte, 1<<20))))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestThrottleUnlimited(t *testing.T) {
+	if bandwidth.NewThrottle(context.Background(), bandwidth.NewLimiter(0), nil) != nil {
+		t.Fatal("throttle without limiters should be nil")
+	}
+	var throttle *bandwidth.Throttle
+	var buf bytes.Buffer
+	if throttle.Writer(&buf) != &buf {
+		t.Fatal("nil throttle should return the writer unchanged")
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * @PackageName: tests
+ * @Description: 删除存储桶测试,覆盖非空、不存在、强制删除任务及与上传并发的情况
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 11:38:05
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 11:38:05
+ */
+
+package tests
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeleteBucket(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/photos/a.txt", server.AdminToken, strings.NewReader("data"), nil)
+
+	if result := server.call(http.MethodDelete, "/bucket/delete?bucket=missing", server.AdminToken, nil, nil); result.Code != response_code.QUERY_EMPTY {
+		t.Errorf("Expected not found, got %s %v", result.Code, result.Errors)
+	}
+	resp := server.request(http.MethodDelete, "/bucket/delete?bucket=photos", server.AdminToken, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected non-empty bucket to be kept, got %d", resp.StatusCode)
+	}
+
+	server.mustCall(http.MethodDelete, "/file/delete?bucket=photos&filename=a.txt", server.AdminToken, nil, nil)
+	server.mustCall(http.MethodDelete, "/bucket/delete?bucket=photos", server.AdminToken, nil, nil)
+	if _, err := os.Stat("tmp/storage/photos"); !os.IsNotExist(err) {
+		t.Errorf("Expected bucket dir to be removed, got %v", err)
+	}
+	if _, err := new(services.BucketService).FindBucketInfo("photos"); !errors.Is(err, services.ErrBucketNotFound) {
+		t.Errorf("Expected bucket config to be removed, got %v", err)
+	}
+}
+
+func TestForceDeleteBucketJob(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	for _, key := range []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"} {
+		server.mustCall(http.MethodPut, "/file/object/photos/"+key, server.AdminToken, strings.NewReader("data"), nil)
+	}
+
+	var job model.BucketDeleteJob
+	server.mustCall(http.MethodDelete, "/bucket/delete?bucket=photos&force=true", server.AdminToken, nil, &job)
+	if job.ID == "" || job.Bucket != "photos" || job.Total != 3 {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	// 配置已删除,上传立即失败
+	if result := server.call(http.MethodPut, "/file/object/photos/d.txt", server.AdminToken, strings.NewReader("data"), nil); result.Success {
+		t.Error("Expected upload to deleted bucket to fail")
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for job.Status == services.BucketDeleteRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("Delete job did not finish in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+		server.mustCall(http.MethodGet, "/bucket/delete/job?id="+job.ID, server.AdminToken, nil, &job)
+	}
+	if job.Status != services.BucketDeleteCompleted || job.Deleted != 3 || job.Bytes != 12 || job.FinishedAt == "" {
+		t.Fatalf("Unexpected finished job: %+v", job)
+	}
+	for _, dir := range []string{"tmp/storage/photos", "tmp/meta/photos"} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", dir, err)
+		}
+	}
+
+	var jobs []model.BucketDeleteJob
+	server.mustCall(http.MethodGet, "/bucket/delete/job", server.AdminToken, nil, &jobs)
+	if len(jobs) == 0 || jobs[0].ID != job.ID {
+		t.Errorf("Expected job in list, got %+v", jobs)
+	}
+	if result := server.call(http.MethodDelete, "/bucket/delete?bucket=photos&force=true", server.AdminToken, nil, nil); result.Code != response_code.QUERY_EMPTY {
+		t.Errorf("Expected deleted bucket to be not found, got %s", result.Code)
+	}
+}
+
+// 删除存储桶与上传并发时,要么删除因存储桶非空失败,要么上传失败,不会留下没有配置的文件
+func TestDeleteBucketRacesWithUpload(t *testing.T) {
+	setupTestDir(t, nil)
+	bs := new(services.BucketService)
+	fs := new(services.FileService)
+
+	for i := 0; i < 50; i++ {
+		bucket := fmt.Sprintf("race-%d", i)
+		if err := bs.CreateBucket(model.BucketInfo{Name: bucket}); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		uploaded := make([]bool, 8)
+		for j := range uploaded {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				_, err := fs.PutObject(bucket, fmt.Sprintf("f%d.txt", j), strings.NewReader("data"), services.PutObjectOptions{})
+				uploaded[j] = err == nil
+			}(j)
+		}
+		deleteErr := bs.DeleteBucket(bucket)
+		wg.Wait()
+
+		if deleteErr == nil {
+			for j, ok := range uploaded {
+				if ok {
+					t.Errorf("%s: upload %d succeeded although bucket was deleted", bucket, j)
+				}
+			}
+			if _, err := os.Stat("tmp/storage/" + bucket); !os.IsNotExist(err) {
+				t.Fatalf("%s: bucket dir left behind after delete: %v", bucket, err)
+			}
+		} else if !errors.Is(deleteErr, services.ErrBucketNotEmpty) {
+			t.Fatalf("%s: unexpected delete error %v", bucket, deleteErr)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+/*
+ * @PackageName: tests
+ * @Description: 修改及重命名存储桶测试,覆盖配额校验及并发修改配置
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 12:05:31
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 12:05:31
+ */
+
+package tests
+
+import (
+	"easy_dfs/app/enum/access_policy"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/cors"
+	"easy_dfs/pkg/website"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdateBucket(t *testing.T) {
+	server := newTestServer(t, nil)
+	ownerToken := server.createUser("alice", user_role.BUCKET_OWNER)
+	server.createUser("bob", user_role.BUCKET_OWNER)
+	server.mustCall(http.MethodPost, "/bucket/create", ownerToken, map[string]string{"name": "photos"}, nil)
+
+	var info model.BucketInfo
+	server.mustCall(http.MethodPut, "/bucket/update", ownerToken, map[string]interface{}{
+		"bucket":       "photos",
+		"accessPolicy": access_policy.PUBLIC,
+		"description":  "holiday",
+		"tags":         map[string]string{"team": "web"},
+		"quota":        map[string]int64{"maxSize": 1024},
+	}, &info)
+	if info.AccessPolicy != access_policy.PUBLIC || info.Description != "holiday" || info.Tags["team"] != "web" || info.Quota == nil || info.Quota.MaxSize != 1024 || info.Owner != "alice" {
+		t.Fatalf("Unexpected updated bucket: %+v", info)
+	}
+
+	// 未传的项不修改,空标签及全 0 配额表示清除
+	var cleared model.BucketInfo
+	server.mustCall(http.MethodPut, "/bucket/update", ownerToken, map[string]interface{}{
+		"bucket": "photos",
+		"tags":   map[string]string{},
+		"quota":  map[string]int64{},
+	}, &cleared)
+	if cleared.AccessPolicy != access_policy.PUBLIC || cleared.Description != "holiday" || cleared.Tags != nil || cleared.Quota != nil {
+		t.Fatalf("Unexpected updated bucket: %+v", cleared)
+	}
+
+	// 只有管理员可以修改所有者,且所有者须为已有用户
+	resp := server.request(http.MethodPut, "/bucket/update", ownerToken, map[string]interface{}{"bucket": "photos", "owner": "bob"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected owner change by non-admin to be forbidden, got %d", resp.StatusCode)
+	}
+	if result := server.call(http.MethodPut, "/bucket/update", server.AdminToken, map[string]interface{}{"bucket": "photos", "owner": "nobody"}, nil); result.Success {
+		t.Error("Expected unknown owner to be rejected")
+	}
+	server.mustCall(http.MethodPut, "/bucket/update", server.AdminToken, map[string]interface{}{"bucket": "photos", "owner": "bob"}, &info)
+	if info.Owner != "bob" {
+		t.Errorf("Expected owner bob, got %q", info.Owner)
+	}
+
+	for _, body := range []map[string]interface{}{
+		{"bucket": "photos", "accessPolicy": "secret"},
+		{"bucket": "photos", "quota": map[string]int64{"maxSize": -1}},
+	} {
+		if result := server.call(http.MethodPut, "/bucket/update", server.AdminToken, body, nil); result.Code != response_code.PARAM_ERROR {
+			t.Errorf("Expected %v to be rejected, got %s", body, result.Code)
+		}
+	}
+	if result := server.call(http.MethodPut, "/bucket/update", server.AdminToken, map[string]interface{}{"bucket": "missing", "description": "x"}, nil); result.Code != response_code.QUERY_EMPTY {
+		t.Errorf("Expected missing bucket to be not found, got %s", result.Code)
+	}
+}
+
+func TestRenameBucket(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "videos"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/photos/dir/a.txt", server.AdminToken, strings.NewReader("data"), nil)
+
+	for _, body := range []map[string]string{
+		{"bucket": "photos", "newName": "videos"},
+		{"bucket": "missing", "newName": "other"},
+		{"bucket": "photos", "newName": "Bad_Name"},
+	} {
+		if result := server.call(http.MethodPut, "/bucket/rename", server.AdminToken, body, nil); result.Success {
+			t.Errorf("Expected rename %v to fail", body)
+		}
+	}
+
+	server.mustCall(http.MethodPut, "/bucket/rename", server.AdminToken, map[string]string{"bucket": "photos", "newName": "pictures"}, nil)
+	bs := new(services.BucketService)
+	if _, err := bs.FindBucketInfo("photos"); !errors.Is(err, services.ErrBucketNotFound) {
+		t.Errorf("Expected old name to be removed, got %v", err)
+	}
+	if _, err := bs.FindBucketInfo("pictures"); err != nil {
+		t.Fatalf("Expected new name in config: %v", err)
+	}
+	for _, path := range []string{"tmp/storage/pictures/dir/a.txt", "tmp/meta/pictures/dir/a.txt.json"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be moved: %v", path, err)
+		}
+	}
+	var meta model.ObjectMeta
+	server.mustCall(http.MethodGet, "/file/info?bucket=pictures&filename=dir/a.txt", server.AdminToken, nil, &meta)
+
+	// 目标目录已存在时不覆盖,配置保持不变
+	if err := os.MkdirAll("tmp/storage/leftover", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := bs.RenameBucket("pictures", "leftover"); err == nil {
+		t.Fatal("Expected rename onto existing dir to fail")
+	}
+	if _, err := bs.FindBucketInfo("pictures"); err != nil {
+		t.Errorf("Expected bucket to keep its name: %v", err)
+	}
+}
+
+func TestBucketQuota(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	server.mustCall(http.MethodPut, "/bucket/update", server.AdminToken, map[string]interface{}{
+		"bucket": "photos",
+		"quota":  map[string]int64{"maxSize": 10, "maxObjects": 2},
+	}, nil)
+
+	put := func(key, data string) string {
+		return server.call(http.MethodPut, "/file/object/photos/"+key, server.AdminToken, strings.NewReader(data), nil).Code
+	}
+	if code := put("a.txt", "12345"); code != response_code.REQUEST_SUCCESS {
+		t.Fatalf("Expected first upload to succeed, got %s", code)
+	}
+	if code := put("b.txt", "1234567"); code != response_code.REQUEST_DENIED {
+		t.Errorf("Expected size quota to be exceeded, got %s", code)
+	}
+	// 覆盖已有文件时不计原文件
+	if code := put("a.txt", "1234567890"); code != response_code.REQUEST_SUCCESS {
+		t.Errorf("Expected overwrite within quota to succeed, got %s", code)
+	}
+	if code := put("a.txt", "123"); code != response_code.REQUEST_SUCCESS {
+		t.Fatalf("Expected overwrite to succeed, got %s", code)
+	}
+	if code := put("b.txt", "123"); code != response_code.REQUEST_SUCCESS {
+		t.Fatalf("Expected second upload to succeed, got %s", code)
+	}
+	if code := put("c.txt", "1"); code != response_code.REQUEST_DENIED {
+		t.Errorf("Expected object quota to be exceeded, got %s", code)
+	}
+
+	// 删除及重命名后用量随之调整
+	server.mustCall(http.MethodDelete, "/file/delete?bucket=photos&filename=b.txt", server.AdminToken, nil, nil)
+	if code := put("c.txt", "1234567"); code != response_code.REQUEST_SUCCESS {
+		t.Errorf("Expected upload after delete to succeed, got %s", code)
+	}
+	server.mustCall(http.MethodPost, "/file/rename", server.AdminToken, map[string]string{"bucket": "photos", "from": "c.txt", "to": "d.txt"}, nil)
+	if code := put("e.txt", "1"); code != response_code.REQUEST_DENIED {
+		t.Errorf("Expected object quota to be exceeded after rename, got %s", code)
+	}
+	server.mustCall(http.MethodPut, "/bucket/rename", server.AdminToken, map[string]string{"bucket": "photos", "newName": "pictures"}, nil)
+	if result := server.call(http.MethodPut, "/file/object/pictures/e.txt", server.AdminToken, strings.NewReader("1"), nil); result.Code != response_code.REQUEST_DENIED {
+		t.Errorf("Expected quota to apply to renamed bucket, got %s", result.Code)
+	}
+}
+
+// 并发修改同一存储桶的不同配置项时,各项修改均不会被覆盖
+func TestConcurrentBucketConfigUpdates(t *testing.T) {
+	setupTestDir(t, nil)
+	bs := new(services.BucketService)
+	if err := bs.CreateBucket(model.BucketInfo{Name: "photos"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		policy := []string{access_policy.PRIVATE, access_policy.PUBLIC}[i%2]
+		origin := fmt.Sprintf("https://%d.example.com", i)
+		domain := fmt.Sprintf("cdn%d.example.com", i)
+		index := fmt.Sprintf("index%d.html", i)
+		description := fmt.Sprintf("update %d", i)
+		updates := []func() error{
+			func() error { return bs.UpdateBucketPolicy("photos", policy) },
+			func() error {
+				return bs.UpdateBucketCors("photos", []cors.Rule{{AllowedOrigins: []string{origin}, AllowedMethods: []string{http.MethodGet}}})
+			},
+			func() error { return bs.UpdateBucketDomains("photos", []string{domain}) },
+			func() error { return bs.UpdateBucketWebsite("photos", &website.Config{IndexDocument: index}) },
+			func() error {
+				_, err := bs.UpdateBucket("photos", services.BucketUpdate{Description: &description})
+				return err
+			},
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, len(updates))
+		for k, update := range updates {
+			wg.Add(1)
+			go func(k int, update func() error) {
+				defer wg.Done()
+				errs[k] = update()
+			}(k, update)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := bs.FindBucketInfo("photos")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.AccessPolicy != policy || len(info.Cors) != 1 || info.Cors[0].AllowedOrigins[0] != origin ||
+			len(info.Domains) != 1 || info.Domains[0] != domain || info.Website == nil || info.Website.IndexDocument != index || info.Description != description {
+			t.Fatalf("Round %d: expected all updates to be kept, got %+v", i, info)
+		}
+	}
+}
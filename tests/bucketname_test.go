@@ -0,0 +1,33 @@
+/*
+ * @PackageName: tests
+ * @Description: 存储桶名称校验测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 04:58:12
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 04:58:12
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/bucketname"
+	"strings"
+	"testing"
+)
+
+func TestBucketNameValidate(t *testing.T) {
+	valid := []string{"abc", "my-bucket", "logs.2026", "a1b2c3", "storage", strings.Repeat("a", 63)}
+	for _, name := range valid {
+		if err := bucketname.Validate(name); err != nil {
+			t.Errorf("Expected %q to be valid but got %v", name, err)
+		}
+	}
+
+	invalid := []string{"ab", strings.Repeat("a", 64), "My-Bucket", "my_bucket", "-bucket", "bucket-", ".bucket",
+		"my..bucket", "my.-bucket", "my-.bucket", "192.168.1.1", "a/b", "../x"}
+	for _, name := range invalid {
+		if err := bucketname.Validate(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * @PackageName: tests
+ * @Description: 上传摘要校验测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 00:48:27
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 00:48:27
+ */
+
+package tests
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/pkg/checksum"
+	"easy_dfs/pkg/http/http_response"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChecksumReader(t *testing.T) {
+	data := "hello easy_dfs"
+	md5Sum := md5.Sum([]byte(data))
+	shaSum := sha256.Sum256([]byte(data))
+	contentMD5 := base64.StdEncoding.EncodeToString(md5Sum[:])
+	shaHex := hex.EncodeToString(shaSum[:])
+
+	r, err := checksum.NewReader(strings.NewReader(data), contentMD5, shaHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatalf("Expected digests to match but got %v", err)
+	}
+	if r.N() != int64(len(data)) || r.MD5() != hex.EncodeToString(md5Sum[:]) || r.SHA256() != shaHex {
+		t.Fatalf("Unexpected digests: %d %s %s", r.N(), r.MD5(), r.SHA256())
+	}
+
+	r, _ = checksum.NewReader(strings.NewReader(data+"!"), contentMD5, "")
+	if _, err := io.Copy(io.Discard, r); !errors.Is(err, checksum.ErrMD5Mismatch) {
+		t.Fatalf("Expected MD5 mismatch but got %v", err)
+	}
+	r, _ = checksum.NewReader(strings.NewReader(data+"!"), "", shaHex)
+	if _, err := io.Copy(io.Discard, r); !errors.Is(err, checksum.ErrSHA256Mismatch) {
+		t.Fatalf("Expected SHA-256 mismatch but got %v", err)
+	}
+
+	if _, err := checksum.NewReader(strings.NewReader(data), "not-base64", ""); err == nil {
+		t.Fatal("Expected malformed Content-MD5 to be rejected")
+	}
+}
+
+// multipart 上传的 Content-MD5 请求头是整个请求体的摘要,文件摘要由表单字段提供
+func TestMultipartUploadChecksumFields(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+
+	data := "hello easy_dfs"
+	md5Sum := md5.Sum([]byte(data))
+	sha256Sum := sha256.Sum256([]byte(data))
+	upload := func(fields map[string]string) http_response.ResponseData {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		_ = writer.WriteField("bucket", "photos")
+		for k, v := range fields {
+			_ = writer.WriteField(k, v)
+		}
+		part, _ := writer.CreateFormFile("file", "a.txt")
+		_, _ = io.WriteString(part, data)
+		_ = writer.Close()
+		bodyMD5 := md5.Sum(body.Bytes())
+		resp := server.request(http.MethodPost, "/file/upload", server.AdminToken, body, map[string]string{
+			"Content-Type": writer.FormDataContentType(),
+			"Content-MD5":  base64.StdEncoding.EncodeToString(bodyMD5[:]),
+		})
+		defer resp.Body.Close()
+		var result http_response.ResponseData
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := upload(nil); !result.Success {
+		t.Fatalf("Expected body Content-MD5 to be ignored, got %v", result.Errors)
+	}
+	if result := upload(map[string]string{"contentMD5": base64.StdEncoding.EncodeToString(md5Sum[:]), "sha256": hex.EncodeToString(sha256Sum[:])}); !result.Success {
+		t.Fatalf("Expected matching file digests to pass, got %v", result.Errors)
+	}
+	wrong := md5.Sum([]byte("other"))
+	if result := upload(map[string]string{"contentMD5": base64.StdEncoding.EncodeToString(wrong[:])}); result.Success || result.Code != response_code.PARAM_ERROR {
+		t.Fatalf("Expected mismatched contentMD5 to be rejected, got %s", result.Code)
+	}
+	if result := upload(map[string]string{"sha256": strings.Repeat("0", 64)}); result.Success {
+		t.Fatal("Expected mismatched sha256 to be rejected")
+	}
+}
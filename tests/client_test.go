@@ -0,0 +1,90 @@
+/*
+ * @PackageName: tests
+ * @Description:
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:36:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:36:52
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"easy_dfs/pkg/client"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientRetryAndErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bucket/list":
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"code":"20000","success":true,"data":[{"name":"docs","accessPolicy":"private"}],"message":"获取成功","errors":[]}`))
+		case "/bucket/info":
+			w.Write([]byte(`{"code":"40004","success":false,"data":null,"message":"操作失败","errors":["bucket 不存在"]}`))
+		}
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, "ak", "sk", client.WithRetry(3, time.Millisecond, 10*time.Millisecond))
+	buckets, err := c.ListBuckets(context.Background())
+	if err != nil || len(buckets) != 1 || buckets[0].Name != "docs" {
+		t.Fatalf("Unexpected result after retries: %v %v", buckets, err)
+	}
+	if attempts != 3 {
+		t.Fatalf("Expected 3 attempts but got %d", attempts)
+	}
+
+	_, err = c.GetBucket(context.Background(), "missing")
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Code != "40004" {
+		t.Fatalf("Expected not found error but got %v", err)
+	}
+}
+
+func TestClientUpload(t *testing.T) {
+	var contentLength int64
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Failed to read form file: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		received = r.FormValue("bucket") + "|" + r.FormValue("savePath") + "|" + r.FormValue("saveName") + "|" + string(data)
+		w.Write([]byte(`{"code":"20000","success":true,"data":{"fileName":"a/b.txt"},"message":"上传成功","errors":[]}`))
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, "ak", "sk", client.WithLargeFileThreshold(4))
+	result, err := c.Upload(context.Background(), "docs", "a/b.txt", bytes.NewReader([]byte("hello")), 5)
+	if err != nil || result.FileName != "a/b.txt" {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+	if received != "docs|a/|b|hello" || contentLength <= 5 {
+		t.Fatalf("Unexpected upload request: %q, content length %d", received, contentLength)
+	}
+
+	// 大小未知且不可重放的流使用分块传输编码
+	if _, err := c.Upload(context.Background(), "docs", "a/b.txt", io.MultiReader(strings.NewReader("hello")), -1); err != nil {
+		t.Fatalf("Failed to upload stream: %v", err)
+	}
+	if received != "docs|a/|b|hello" || contentLength != -1 {
+		t.Fatalf("Expected chunked upload but got %q, content length %d", received, contentLength)
+	}
+}
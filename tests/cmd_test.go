@@ -0,0 +1,162 @@
+/*
+ * @PackageName: tests
+ * @Description: 服务端子命令测试,覆盖命令分发、--env 加载的配置文件及 fsck 默认试运行
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 15:48:16
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 15:48:16
+ */
+
+package tests
+
+import (
+	"bytes"
+	"easy_dfs/app/cmd"
+	btsConfig "easy_dfs/config"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCommandHelper 在子进程中执行命令,命令会重新加载配置并初始化日志,不能在测试进程中执行
+func TestCommandHelper(t *testing.T) {
+	if os.Getenv("EASYDFS_COMMAND_HELPER") != "1" {
+		t.Skip("only runs as a subprocess")
+	}
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	btsConfig.Initialize()
+	os.Exit(cmd.Execute(args))
+}
+
+// commandResult 命令的退出码及输出
+type commandResult struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// newCommandDir 创建执行命令的工作目录,包含测试用的 app.yml
+func newCommandDir(t *testing.T) string {
+	data, err := os.ReadFile("app.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.yml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// runCommand 在 dir 中执行 easy_dfs 命令
+func runCommand(t *testing.T, dir string, args ...string) commandResult {
+	command := exec.Command(os.Args[0], append([]string{"-test.run=^TestCommandHelper$", "--"}, args...)...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), "EASYDFS_COMMAND_HELPER=1")
+	var stdout, stderr bytes.Buffer
+	command.Stdout, command.Stderr = &stdout, &stderr
+	var result commandResult
+	if err := command.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatal(err)
+		}
+		result.code = exitErr.ExitCode()
+	}
+	result.stdout, result.stderr = stdout.String(), stderr.String()
+	return result
+}
+
+func TestCommandDispatch(t *testing.T) {
+	dir := newCommandDir(t)
+	for _, tc := range []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"nope"}, 2, "未知命令: nope"},
+		{[]string{"--nope"}, 2, "用法: easy_dfs"},
+		{[]string{"bucket"}, 1, "用法: easy_dfs bucket"},
+		{[]string{"bucket", "frob"}, 1, "未知命令: bucket frob"},
+		{[]string{"bucket", "create", "--policy", "public", "photos"}, 0, "已创建存储桶 photos"},
+		{[]string{"bucket", "list"}, 0, "photos\tpublic"},
+	} {
+		result := runCommand(t, dir, tc.args...)
+		if result.code != tc.code || !strings.Contains(result.stdout+result.stderr, tc.output) {
+			t.Errorf("%v: expected exit code %d with %q, got %+v", tc.args, tc.code, tc.output, result)
+		}
+	}
+	// 未知命令时列出可用的命令
+	if result := runCommand(t, dir, "nope"); !strings.Contains(result.stderr, "fsck") || !strings.Contains(result.stderr, "serve") {
+		t.Errorf("Expected usage to list commands, got %q", result.stderr)
+	}
+}
+
+// --env 加载 app.yml.{env},文件不存在时使用 app.yml
+func TestCommandEnv(t *testing.T) {
+	dir := newCommandDir(t)
+	data, err := os.ReadFile(filepath.Join(dir, "app.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testingYml := strings.Replace(string(data), "env: local", "env: testing", 1)
+	if err := os.WriteFile(filepath.Join(dir, "app.yml.testing"), []byte(testingYml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for args, env := range map[string]string{
+		"":              "local",
+		"--env=testing": "testing",
+		"--env=missing": "local",
+	} {
+		result := runCommand(t, dir, strings.Fields(args+" config validate")...)
+		if result.code != 0 || !strings.Contains(result.stdout, "env="+env) {
+			t.Errorf("%q: expected env=%s, got %+v", args, env, result)
+		}
+	}
+}
+
+// fsck 指定修复方式时默认只列出将执行的操作,加上 --apply 才修改
+func TestFsckCommandDryRun(t *testing.T) {
+	dir := newCommandDir(t)
+	if result := runCommand(t, dir, "bucket", "create", "photos"); result.code != 0 {
+		t.Fatalf("Failed to create bucket: %+v", result)
+	}
+	stray := filepath.Join(dir, "tmp", "storage", "stray.txt")
+	if err := os.WriteFile(stray, []byte("stray"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := runCommand(t, dir, "fsck")
+	if result.code != 1 || !strings.Contains(result.stdout, "[stray_file]") || !strings.Contains(result.stderr, "发现 1 个问题") {
+		t.Errorf("Expected fsck to report the stray file, got %+v", result)
+	}
+
+	result = runCommand(t, dir, "fsck", "--fix", "stray_file=delete")
+	if result.code != 0 || !strings.Contains(result.stdout, "将执行 delete") || !strings.Contains(result.stdout, "试运行") {
+		t.Errorf("Expected a dry run, got %+v", result)
+	}
+	if _, err := os.Stat(stray); err != nil {
+		t.Errorf("Expected dry run to keep the file, got %v", err)
+	}
+
+	result = runCommand(t, dir, "fsck", "--fix", "stray_file=delete", "--apply")
+	if result.code != 0 || !strings.Contains(result.stdout, "已执行 delete") {
+		t.Errorf("Expected the fix to be applied, got %+v", result)
+	}
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Errorf("Expected the stray file to be deleted, got %v", err)
+	}
+	if result := runCommand(t, dir, "fsck"); result.code != 0 || !strings.Contains(result.stdout, "未发现问题") {
+		t.Errorf("Expected no issues after the fix, got %+v", result)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * @PackageName: tests
+ * @Description: 配置读取测试,覆盖布尔配置显式配置为 false 时不使用默认值
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 14:02:37
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 14:02:37
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/filesystem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetBoolHonoursFalse(t *testing.T) {
+	setupTestDir(t, map[string]interface{}{"storage.fsync": true})
+	if !config.GetBool("storage.fsync", true) {
+		t.Fatal("Expected storage.fsync to default to true")
+	}
+
+	cases := []struct {
+		value interface{}
+		want  bool
+	}{
+		{false, false},
+		{"false", false},
+		{0, false},
+		{true, true},
+		{"", true}, // 空字符串视为未配置
+	}
+	for _, c := range cases {
+		config.Set("storage.fsync", c.value)
+		if got := config.GetBool("storage.fsync", true); got != c.want {
+			t.Errorf("storage.fsync=%#v: expected %v, got %v", c.value, c.want, got)
+		}
+	}
+	if !config.GetBool("storage.missing_flag", true) || config.GetBool("storage.missing_flag") {
+		t.Error("Expected unset flag to use the default")
+	}
+
+	// 关闭同步后写入仍然正常
+	config.Set("storage.fsync", false)
+	storage := filesystem.FileSystemStorage{BaseDir: t.TempDir()}
+	if err := storage.Save("a/b.txt", strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(storage.BaseDir, "a/b.txt")); err != nil || string(data) != "data" {
+		t.Errorf("Expected file to be written, got %q %v", data, err)
+	}
+}
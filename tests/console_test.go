@@ -0,0 +1,37 @@
+/*
+ * @PackageName: tests
+ * @Description: 管理控制台测试,覆盖首页跳转及关闭控制台
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 14:10:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 14:10:52
+ */
+
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConsole(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		server := newEmptyTestServer(t, map[string]interface{}{"console.enabled": enabled})
+
+		resp := server.request(http.MethodGet, "/", "", nil, map[string]string{"Accept": "text/html"})
+		resp.Body.Close()
+		if redirected := resp.StatusCode == http.StatusFound && resp.Header.Get("Location") == "/console/"; redirected != enabled {
+			t.Errorf("console.enabled=%v: unexpected index response %d %q", enabled, resp.StatusCode, resp.Header.Get("Location"))
+		}
+
+		resp = server.request(http.MethodGet, "/console/", "", nil, nil)
+		resp.Body.Close()
+		want := http.StatusOK
+		if !enabled {
+			want = http.StatusNotFound
+		}
+		if resp.StatusCode != want {
+			t.Errorf("console.enabled=%v: expected /console/ to return %d, got %d", enabled, want, resp.StatusCode)
+		}
+	}
+}
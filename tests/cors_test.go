@@ -0,0 +1,75 @@
+/*
+ * @PackageName: tests
+ * @Description: 跨域规则测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 21:15:07
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 21:15:07
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/cors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var testCorsRules = []cors.Rule{{
+	AllowedOrigins: []string{"https://*.example.com"},
+	AllowedMethods: []string{"GET", "PUT"},
+	AllowedHeaders: []string{"Content-Type"},
+	ExposeHeaders:  []string{"Content-Length"},
+	MaxAgeSeconds:  300,
+}}
+
+func TestCorsPreflight(t *testing.T) {
+	req := httptest.NewRequest(http.MethodOptions, "/storage/b/a.txt", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+
+	header := http.Header{}
+	preflight, allowed := cors.Apply(header, req, testCorsRules)
+	if !preflight || !allowed {
+		t.Fatalf("preflight=%v allowed=%v", preflight, allowed)
+	}
+	if got := header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("unexpected allow origin %q", got)
+	}
+	if header.Get("Access-Control-Max-Age") != "300" || header.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("unexpected headers %v", header)
+	}
+
+	// 请求头、请求方法、来源不匹配时拒绝
+	for _, tc := range []struct{ origin, method, headers string }{
+		{"https://app.example.com", "PUT", "X-Secret-Key"},
+		{"https://app.example.com", "DELETE", ""},
+		{"https://example.org", "GET", ""},
+	} {
+		req.Header.Set("Origin", tc.origin)
+		req.Header.Set("Access-Control-Request-Method", tc.method)
+		req.Header.Set("Access-Control-Request-Headers", tc.headers)
+		header = http.Header{}
+		if _, allowed := cors.Apply(header, req, testCorsRules); allowed || header.Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("%+v should be rejected", tc)
+		}
+	}
+}
+
+func TestCorsSimpleRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/storage/b/a.txt", nil)
+	header := http.Header{}
+	if preflight, allowed := cors.Apply(header, req, nil); preflight || !allowed || len(header) != 0 {
+		t.Fatal("same-origin request should pass untouched")
+	}
+
+	req.Header.Set("Origin", "https://cdn.example.com")
+	if _, allowed := cors.Apply(header, req, testCorsRules); !allowed {
+		t.Fatal("matching origin should be allowed")
+	}
+	if header.Get("Access-Control-Expose-Headers") != "Content-Length" {
+		t.Fatalf("unexpected headers %v", header)
+	}
+}
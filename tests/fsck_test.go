@@ -0,0 +1,213 @@
+/*
+ * @PackageName: tests
+ * @Description: 数据一致性检查及修复测试,覆盖试运行、隔离、删除及补全
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 11:06:52
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 11:06:52
+ */
+
+package tests
+
+import (
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/filesystem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupFsckFixtures 构造各类不一致:没有配置的存储桶目录、缺失的存储桶目录、孤立的元数据、遗留的临时文件及存储目录下的散落文件
+func setupFsckFixtures(t *testing.T) {
+	fs := new(services.FileService)
+	bs := new(services.BucketService)
+	for _, name := range []string{"photos", "ghost", "phantom"} {
+		if err := bs.CreateBucket(model.BucketInfo{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range []string{"keep.txt", "lost.txt"} {
+		if _, err := fs.PutObject("photos", key, strings.NewReader("data"), services.PutObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite := func(path, data string) {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite("tmp/storage/orphan/a/b.txt", "orphan")
+	mustWrite("tmp/storage/adopted/c.txt", "adopted")
+	mustWrite("tmp/storage/stray.txt", "stray")
+	if err := os.Remove("tmp/storage/photos/lost.txt"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ghost", "phantom"} {
+		if err := os.RemoveAll(filepath.Join("tmp/storage", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 只有超过一定时间未修改的临时文件才视为遗留
+	stale := filepath.Join("tmp/storage/photos", filesystem.TempFilePrefix+"stale")
+	mustWrite(stale, "partial")
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(filepath.Join("tmp/storage/photos", filesystem.TempFilePrefix+"writing"), "partial")
+}
+
+// fsckIssueTypes 按问题对象返回问题类型
+func fsckIssueTypes(t *testing.T) map[string]string {
+	issues, err := new(services.FsckService).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]string)
+	for _, issue := range issues {
+		types[issue.Target] = issue.Type
+	}
+	return types
+}
+
+func TestFsckCheckAndDryRun(t *testing.T) {
+	setupTestDir(t, nil)
+	setupFsckFixtures(t)
+
+	want := map[string]string{
+		filepath.Join("tmp/storage", "orphan"):                                 services.FsckOrphanBucketDir,
+		filepath.Join("tmp/storage", "adopted"):                                services.FsckOrphanBucketDir,
+		filepath.Join("tmp/storage", "stray.txt"):                              services.FsckStrayFile,
+		filepath.Join("tmp/storage", "ghost"):                                  services.FsckMissingBucketDir,
+		filepath.Join("tmp/storage", "phantom"):                                services.FsckMissingBucketDir,
+		filepath.Join("tmp/storage/photos", filesystem.TempFilePrefix+"stale"): services.FsckStaleTempFile,
+		filepath.Join("tmp/meta/photos", "lost.txt.json"):                      services.FsckOrphanMetadata,
+	}
+	got := fsckIssueTypes(t)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d issues, got %v", len(want), got)
+	}
+	for target, issueType := range want {
+		if got[target] != issueType {
+			t.Errorf("Expected %s for %s, got %q", issueType, target, got[target])
+		}
+	}
+
+	// 试运行只列出操作,不做修改
+	results, err := new(services.FsckService).Reconcile(services.ReconcileOptions{
+		Actions: map[string]string{services.FsckStrayFile: services.FsckActionDelete, services.FsckStaleTempFile: services.FsckActionDelete},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Applied || results[1].Applied {
+		t.Fatalf("Unexpected dry run results: %+v", results)
+	}
+	if len(fsckIssueTypes(t)) != len(want) {
+		t.Fatal("Expected dry run to leave issues untouched")
+	}
+
+	// 不支持的修复方式直接拒绝
+	if _, err := new(services.FsckService).Reconcile(services.ReconcileOptions{Actions: map[string]string{services.FsckStaleTempFile: services.FsckActionQuarantine}}); err == nil {
+		t.Fatal("Expected unsupported action to be rejected")
+	}
+}
+
+func TestFsckReconcileApply(t *testing.T) {
+	setupTestDir(t, nil)
+	setupFsckFixtures(t)
+	fcs := new(services.FsckService)
+
+	// 指定问题对象时只处理该对象
+	results, err := fcs.Reconcile(services.ReconcileOptions{
+		Actions: map[string]string{services.FsckOrphanBucketDir: services.FsckActionAdopt},
+		Targets: []string{filepath.Join("tmp/storage", "adopted")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Applied || results[0].Error != "" {
+		t.Fatalf("Unexpected adopt results: %+v", results)
+	}
+	if _, err := new(services.BucketService).FindBucketInfo("adopted"); err != nil {
+		t.Fatalf("Expected adopted bucket config: %v", err)
+	}
+
+	results, err = fcs.Reconcile(services.ReconcileOptions{Actions: map[string]string{
+		services.FsckOrphanBucketDir:  services.FsckActionQuarantine,
+		services.FsckStrayFile:        services.FsckActionQuarantine,
+		services.FsckMissingBucketDir: services.FsckActionAdopt,
+		services.FsckOrphanMetadata:   services.FsckActionDelete,
+		services.FsckStaleTempFile:    services.FsckActionDelete,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destinations := make(map[string]string)
+	for _, result := range results {
+		if !result.Applied || result.Error != "" {
+			t.Errorf("Expected %s to be applied, got %+v", result.Target, result)
+		}
+		destinations[result.Target] = result.Destination
+	}
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 results, got %+v", results)
+	}
+
+	// 隔离保留原相对路径
+	orphan := destinations[filepath.Join("tmp/storage", "orphan")]
+	if data, err := os.ReadFile(filepath.Join(orphan, "a", "b.txt")); err != nil || string(data) != "orphan" {
+		t.Errorf("Expected orphan dir in quarantine at %s: %v", orphan, err)
+	}
+	if !strings.HasPrefix(orphan, filepath.Join("tmp", "quarantine")) {
+		t.Errorf("Unexpected quarantine destination %s", orphan)
+	}
+	stray := destinations[filepath.Join("tmp/storage", "stray.txt")]
+	if data, err := os.ReadFile(stray); err != nil || string(data) != "stray" {
+		t.Errorf("Expected stray file in quarantine at %s: %v", stray, err)
+	}
+	for _, path := range []string{"tmp/storage/orphan", "tmp/storage/stray.txt", "tmp/meta/photos/lost.txt.json", filepath.Join("tmp/storage/photos", filesystem.TempFilePrefix+"stale")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", path, err)
+		}
+	}
+	for _, path := range []string{"tmp/storage/ghost", "tmp/storage/phantom", "tmp/storage/photos/keep.txt", "tmp/meta/photos/keep.txt.json", filepath.Join("tmp/storage/photos", filesystem.TempFilePrefix+"writing")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept: %v", path, err)
+		}
+	}
+	if got := fsckIssueTypes(t); len(got) != 0 {
+		t.Fatalf("Expected no issues after reconcile, got %v", got)
+	}
+}
+
+// 缺失存储目录的存储桶可以直接删除配置
+func TestFsckReconcileDeleteMissingBucket(t *testing.T) {
+	setupTestDir(t, nil)
+	setupFsckFixtures(t)
+
+	results, err := new(services.FsckService).Reconcile(services.ReconcileOptions{
+		Actions: map[string]string{services.FsckMissingBucketDir: services.FsckActionDelete},
+		Targets: []string{filepath.Join("tmp/storage", "ghost")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Applied {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	bs := new(services.BucketService)
+	if _, err := bs.FindBucketInfo("ghost"); err == nil {
+		t.Error("Expected ghost bucket config to be deleted")
+	}
+	if _, err := bs.FindBucketInfo("phantom"); err != nil {
+		t.Errorf("Expected other bucket to be kept: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * @PackageName: tests
+ * @Description: 健康检查及优雅退出测试,覆盖退出期间就绪检查失败、等待时间及超时
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 15:24:51
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 15:24:51
+ */
+
+package tests
+
+import (
+	"easy_dfs/app/services"
+	"easy_dfs/bootstrap"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startSlowUpload 开始一个请求体未发送完的上传,返回继续发送的写入端及上传结果
+func startSlowUpload(t *testing.T, server *testServer, key string) (*io.PipeWriter, <-chan int) {
+	reader, writer := io.Pipe()
+	status := make(chan int, 1)
+	go func() {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/file/object/photos/"+key, reader)
+		if err != nil {
+			status <- 0
+			return
+		}
+		req.Header.Set("Authorization", "Bearer "+server.AdminToken)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	if _, err := writer.Write([]byte("hello ")); err != nil {
+		t.Fatal(err)
+	}
+	// 等待服务端开始处理上传
+	time.Sleep(100 * time.Millisecond)
+	return writer, status
+}
+
+// startShutdown 在后台优雅退出,返回退出结果
+func startShutdown(server *testServer) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- bootstrap.Shutdown(server.Config) }()
+	return result
+}
+
+// 退出期间就绪检查失败,等待 drain_delay 期间仍处理新请求,之后等待进行中的上传完成
+func TestShutdownDrain(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"app.drain_delay": 1, "app.drain_timeout": 10})
+	t.Cleanup(func() { new(services.HealthService).SetDraining(false) })
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+
+	resp := server.request(http.MethodGet, "/readyz", "", nil, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusOK || !result.Success {
+		t.Fatalf("Expected server to be ready, got %d %+v", resp.StatusCode, result)
+	}
+
+	writer, upload := startSlowUpload(t, server, "a.txt")
+	start := time.Now()
+	shutdown := startShutdown(server)
+	time.Sleep(100 * time.Millisecond)
+
+	resp = server.request(http.MethodGet, "/readyz", "", nil, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusServiceUnavailable || result.Success {
+		t.Errorf("Expected readyz to fail while draining, got %d %+v", resp.StatusCode, result)
+	}
+	resp = server.request(http.MethodGet, "/healthz", "", nil, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusOK || !result.Success {
+		t.Errorf("Expected requests to be served during drain_delay, got %d %+v", resp.StatusCode, result)
+	}
+
+	// drain_delay 结束后服务停止接收新请求,但仍等待进行中的上传
+	time.Sleep(time.Second)
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before the upload finished: %v", err)
+	default:
+	}
+	if _, err := writer.Write([]byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	if status := <-upload; status != http.StatusOK {
+		t.Errorf("Expected in-flight upload to finish, got %d", status)
+	}
+
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Errorf("Expected graceful shutdown, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("Expected shutdown to wait drain_delay, took %v", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not finish after the upload completed")
+	}
+}
+
+// 超过 drain_timeout 仍有未完成的请求时返回错误
+func TestShutdownDrainTimeout(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"app.drain_delay": 0, "app.drain_timeout": 1})
+	t.Cleanup(func() { new(services.HealthService).SetDraining(false) })
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+
+	writer, upload := startSlowUpload(t, server, "a.txt")
+	start := time.Now()
+	select {
+	case err := <-startShutdown(server):
+		if err == nil || !strings.Contains(err.Error(), "deadline") {
+			t.Errorf("Expected shutdown to time out, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second || elapsed > 5*time.Second {
+			t.Errorf("Expected shutdown to give up after drain_timeout, took %v", elapsed)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Shutdown did not honour drain_timeout")
+	}
+
+	// 中断上传,等待服务端的处理结束
+	writer.CloseWithError(io.ErrUnexpectedEOF)
+	<-upload
+}
@@ -0,0 +1,184 @@
+/*
+ * @PackageName: tests
+ * @Description: 图片处理缓存测试,覆盖缓存空间上限、覆盖删除重命名文件及删除存储桶时清除缓存、关闭图片处理
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 14:36:08
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 14:36:08
+ */
+
+package tests
+
+import (
+	"bytes"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// noisePNG 生成随机像素的图片,压缩后仍有较大的体积
+func noisePNG(t *testing.T, size int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, color.NRGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// imageCacheFiles 返回缓存文件及其总大小
+func imageCacheFiles(t *testing.T, dir string) ([]string, int64) {
+	var files []string
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, path)
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files, total
+}
+
+// getImage 访问图片并返回响应内容
+func getImage(t *testing.T, server *testServer, path string) (int, string, []byte) {
+	resp := server.request(http.MethodGet, path, "", nil, nil)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header.Get("Content-Type"), data
+}
+
+func TestImageCacheInvalidation(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	put := func(key string) {
+		server.mustCall(http.MethodPut, "/file/object/photos/"+key, server.AdminToken, bytes.NewReader(noisePNG(t, 32)), nil)
+	}
+	cached := func(key string) int {
+		status, contentType, _ := getImage(t, server, "/storage/photos/"+key+"?width=16&format=png")
+		if status != http.StatusOK || contentType != "image/png" {
+			t.Fatalf("Unexpected image response %d %s", status, contentType)
+		}
+		files, _ := imageCacheFiles(t, "tmp/cache/image/photos")
+		return len(files)
+	}
+
+	put("a.png")
+	put("b.png")
+	if n := cached("a.png"); n != 1 {
+		t.Fatalf("Expected 1 cached image, got %d", n)
+	}
+	if n := cached("b.png"); n != 2 {
+		t.Fatalf("Expected 2 cached images, got %d", n)
+	}
+
+	// 覆盖、重命名及删除文件时清除该文件的缓存
+	put("a.png")
+	if files, _ := imageCacheFiles(t, "tmp/cache/image/photos"); len(files) != 1 {
+		t.Errorf("Expected overwrite to clear the cache, got %v", files)
+	}
+	server.mustCall(http.MethodPost, "/file/rename", server.AdminToken, map[string]string{"bucket": "photos", "from": "b.png", "to": "c.png"}, nil)
+	if files, _ := imageCacheFiles(t, "tmp/cache/image/photos"); len(files) != 0 {
+		t.Errorf("Expected rename to clear the cache, got %v", files)
+	}
+	cached("a.png")
+	server.mustCall(http.MethodDelete, "/file/delete?bucket=photos&filename=a.png", server.AdminToken, nil, nil)
+	if files, _ := imageCacheFiles(t, "tmp/cache/image/photos"); len(files) != 0 {
+		t.Errorf("Expected delete to clear the cache, got %v", files)
+	}
+
+	// 删除存储桶时清除整个存储桶的缓存
+	cached("c.png")
+	var job model.BucketDeleteJob
+	server.mustCall(http.MethodDelete, "/bucket/delete?bucket=photos&force=true", server.AdminToken, nil, &job)
+	if _, err := os.Stat("tmp/cache/image/photos"); !os.IsNotExist(err) {
+		t.Errorf("Expected bucket cache to be removed, got %v", err)
+	}
+	// 等待后台删除任务结束,避免影响之后的测试
+	deadline := time.Now().Add(10 * time.Second)
+	for job.Status == services.BucketDeleteRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("Delete job did not finish in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+		server.mustCall(http.MethodGet, "/bucket/delete/job?id="+job.ID, server.AdminToken, nil, &job)
+	}
+}
+
+// 缓存超出上限时淘汰最久未使用的缓存
+func TestImageCacheSizeLimit(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"image.cache_max_size": 1})
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/photos/noise.png", server.AdminToken, bytes.NewReader(noisePNG(t, 400)), nil)
+
+	var images [][]byte
+	for _, width := range []string{"400", "399", "398", "397"} {
+		status, _, data := getImage(t, server, "/storage/photos/noise.png?format=png&width="+width)
+		if status != http.StatusOK {
+			t.Fatalf("width=%s: unexpected status %d", width, status)
+		}
+		images = append(images, data)
+	}
+	files, total := imageCacheFiles(t, "tmp/cache/image")
+	if total > 1024*1024 || len(files) == 0 || len(files) >= len(images) {
+		t.Fatalf("Expected cache to be trimmed within 1MB, got %d bytes in %v", total, files)
+	}
+	kept := func(image []byte) bool {
+		for _, file := range files {
+			if data, err := os.ReadFile(file); err == nil && bytes.Equal(data, image) {
+				return true
+			}
+		}
+		return false
+	}
+	if kept(images[0]) || !kept(images[len(images)-1]) {
+		t.Errorf("Expected the oldest image to be evicted and the latest kept, got %v", files)
+	}
+}
+
+// 配置为 false 时关闭图片处理,返回原图
+func TestImageProcessingDisabled(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"image.enabled": false})
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	original := noisePNG(t, 32)
+	server.mustCall(http.MethodPut, "/file/object/photos/a.png", server.AdminToken, bytes.NewReader(original), nil)
+
+	status, contentType, data := getImage(t, server, "/storage/photos/a.png?width=16&format=jpeg")
+	if status != http.StatusOK || !strings.HasPrefix(contentType, "image/png") || !bytes.Equal(data, original) {
+		t.Errorf("Expected original image, got %d %s (%d bytes)", status, contentType, len(data))
+	}
+	if files, _ := imageCacheFiles(t, "tmp/cache/image"); len(files) != 0 {
+		t.Errorf("Expected no cached images, got %v", files)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * @PackageName: tests
+ * @Description: 图片处理测试,覆盖缩放、裁剪、旋转、参数校验及按比例计算的尺寸限制
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 14:02:36
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 14:02:36
+ */
+
+package tests
+
+import (
+	"bytes"
+	"easy_dfs/pkg/imageproc"
+	"image"
+	"image/png"
+	"net/url"
+	"testing"
+)
+
+func TestImageProcess(t *testing.T) {
+	var src bytes.Buffer
+	if err := png.Encode(&src, image.NewNRGBA(image.Rect(0, 0, 400, 200))); err != nil {
+		t.Fatalf("Failed to encode source image: %v", err)
+	}
+	limits := imageproc.Limits{MaxWidth: 1000, MaxHeight: 1000, DefaultQuality: 80}
+
+	cases := []struct {
+		query         string
+		width, height int
+	}{
+		{"width=100", 100, 50},
+		{"width=100&height=100", 100, 50},
+		{"width=100&height=100&fit=cover", 100, 100},
+		{"width=100&height=100&fit=fill", 100, 100},
+		{"rotate=90", 200, 400},
+	}
+	for _, tc := range cases {
+		query, _ := url.ParseQuery(tc.query)
+		opts, ok, err := imageproc.ParseOptions(query, limits)
+		if err != nil || !ok {
+			t.Fatalf("%s: unexpected parse result %v %v", tc.query, ok, err)
+		}
+		var out bytes.Buffer
+		format, err := imageproc.Process(bytes.NewReader(src.Bytes()), &out, opts, limits)
+		if err != nil || format != imageproc.FormatPNG {
+			t.Fatalf("%s: failed to process image: %v %s", tc.query, err, format)
+		}
+		cfg, _, err := image.DecodeConfig(&out)
+		if err != nil || cfg.Width != tc.width || cfg.Height != tc.height {
+			t.Fatalf("%s: expected %dx%d but got %dx%d", tc.query, tc.width, tc.height, cfg.Width, cfg.Height)
+		}
+	}
+
+	for _, bad := range []string{"width=2000", "fit=stretch", "quality=101", "format=bmp", "rotate=45"} {
+		query, _ := url.ParseQuery(bad)
+		if _, _, err := imageproc.ParseOptions(query, limits); err == nil {
+			t.Fatalf("%s: expected error", bad)
+		}
+	}
+}
+
+// 只指定一边时按比例算出的另一边不超过限制,比例悬殊的原图不会分配过大的内存
+func TestImageProcessClampsDerivedSide(t *testing.T) {
+	limits := imageproc.Limits{MaxWidth: 100, MaxHeight: 100, DefaultQuality: 80}
+	cases := []struct {
+		srcW, srcH    int
+		query         string
+		width, height int
+	}{
+		{2000, 10, "height=50", 100, 1},
+		{2000, 10, "height=50&fit=cover", 100, 1},
+		{10, 2000, "width=50&fit=fill", 1, 100},
+		{400, 200, "height=50", 100, 50},
+	}
+	for _, tc := range cases {
+		var src bytes.Buffer
+		if err := png.Encode(&src, image.NewNRGBA(image.Rect(0, 0, tc.srcW, tc.srcH))); err != nil {
+			t.Fatalf("Failed to encode source image: %v", err)
+		}
+		query, _ := url.ParseQuery(tc.query)
+		opts, _, err := imageproc.ParseOptions(query, limits)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error %v", tc.query, err)
+		}
+		var out bytes.Buffer
+		if _, err := imageproc.Process(bytes.NewReader(src.Bytes()), &out, opts, limits); err != nil {
+			t.Fatalf("%s: failed to process image: %v", tc.query, err)
+		}
+		cfg, _, err := image.DecodeConfig(&out)
+		if err != nil || cfg.Width != tc.width || cfg.Height != tc.height {
+			t.Errorf("%dx%d %s: expected %dx%d but got %dx%d", tc.srcW, tc.srcH, tc.query, tc.width, tc.height, cfg.Width, cfg.Height)
+		}
+	}
+}
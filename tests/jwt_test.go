@@ -0,0 +1,49 @@
+/*
+ * @PackageName: tests
+ * @Description:
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 06:18:44
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 06:18:44
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/jwt"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWT(t *testing.T) {
+	now := time.Now()
+	secret := []byte("secret")
+	token, err := jwt.Sign(secret, jwt.Claims{Subject: "alice", Version: 2, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	claims, err := jwt.Parse(secret, token, now)
+	if err != nil {
+		t.Fatalf("Expected valid token: %v", err)
+	}
+	if claims.Subject != "alice" || claims.Version != 2 {
+		t.Fatalf("Unexpected claims: %+v", claims)
+	}
+	if _, err := jwt.Parse([]byte("other"), token, now); !errors.Is(err, jwt.ErrInvalidToken) {
+		t.Fatalf("Expected invalid token for wrong secret, got %v", err)
+	}
+	if _, err := jwt.Parse(secret, token, now.Add(2*time.Hour)); !errors.Is(err, jwt.ErrExpired) {
+		t.Fatalf("Expected expired token, got %v", err)
+	}
+
+	// 篡改声明后签名不再匹配
+	parts := strings.Split(token, ".")
+	forged, _ := jwt.Sign([]byte("other"), jwt.Claims{Subject: "admin", ExpiresAt: now.Add(time.Hour).Unix()})
+	parts[1] = strings.Split(forged, ".")[1]
+	if _, err := jwt.Parse(secret, strings.Join(parts, "."), now); !errors.Is(err, jwt.ErrInvalidToken) {
+		t.Fatalf("Expected invalid token for tampered payload, got %v", err)
+	}
+}
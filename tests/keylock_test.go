@@ -0,0 +1,57 @@
+/*
+ * @PackageName: tests
+ * @Description: 按键加锁测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 01:31:48
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 01:31:48
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/keylock"
+	"testing"
+	"time"
+)
+
+func TestKeyLock(t *testing.T) {
+	locks := keylock.New()
+
+	// 同一键的写锁阻塞读锁,不同键互不影响
+	unlock := locks.Lock("a/1.txt")
+	acquired := make(chan struct{})
+	released := make(chan struct{})
+	go func() {
+		runlock := locks.RLock("a/1.txt")
+		close(acquired)
+		runlock()
+		close(released)
+	}()
+	locks.RLock("a/2.txt")()
+	select {
+	case <-acquired:
+		t.Fatal("Expected read lock to wait for the writer")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected read lock after writer released")
+	}
+	<-released
+
+	// 多个读锁可同时持有
+	r1 := locks.RLock("b")
+	r2 := locks.RLock("b")
+	r1()
+	r2()
+
+	// 重复的键只加一次锁,不会自锁
+	locks.LockAll("c", "d", "c")()
+
+	if n := locks.Len(); n != 0 {
+		t.Fatalf("Expected released locks to be removed but %d remain", n)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * @PackageName: tests
+ * @Description: 监控指标测试,覆盖接口开关、访问令牌校验及指标标签
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 15:02:37
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 15:02:37
+ */
+
+package tests
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// getMetrics 访问监控指标接口,返回状态码及响应内容
+func getMetrics(t *testing.T, server *testServer, header map[string]string) (int, string) {
+	resp := server.request(http.MethodGet, "/metrics", "", nil, header)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestMetricsAuth(t *testing.T) {
+	// 未开启时接口不存在
+	server := newEmptyTestServer(t, map[string]interface{}{"metrics.enabled": false})
+	resp := server.request(http.MethodGet, "/metrics", "", nil, nil)
+	if result := decodeResponse(t, resp); result.Success || result.Code != response_code.QUERY_EMPTY {
+		t.Errorf("Expected metrics to be disabled, got %+v", result)
+	}
+
+	server = newEmptyTestServer(t, map[string]interface{}{"metrics.enabled": true, "metrics.token": "t0ken"})
+	for name, header := range map[string]map[string]string{
+		"missing token": nil,
+		"wrong token":   {"Authorization": "Bearer nope"},
+		"wrong scheme":  {"Authorization": "Basic t0ken"},
+	} {
+		resp := server.request(http.MethodGet, "/metrics", "", nil, header)
+		if result := decodeResponse(t, resp); result.Success || result.Code != response_code.TOKEN_INVALID {
+			t.Errorf("%s: expected token to be rejected, got %+v", name, result)
+		}
+	}
+	status, body := getMetrics(t, server, map[string]string{"Authorization": "Bearer t0ken"})
+	if status != http.StatusOK || !strings.Contains(body, "easydfs_http_requests_total") {
+		t.Errorf("Expected metrics with a valid token, got %d %.200s", status, body)
+	}
+
+	// 未配置令牌时无需认证
+	server = newEmptyTestServer(t, map[string]interface{}{"metrics.enabled": true, "metrics.token": ""})
+	if status, body := getMetrics(t, server, nil); status != http.StatusOK || !strings.Contains(body, "easydfs_http_requests_total") {
+		t.Errorf("Expected metrics without a token, got %d %.200s", status, body)
+	}
+}
+
+// 请求按路由模板记录,文件名等路径参数不出现在标签中
+func TestMetricsLabels(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"metrics.enabled": true, "metrics.token": ""})
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "metered"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/metered/secret-name.txt", server.AdminToken, strings.NewReader("hello"), nil)
+	resp := server.request(http.MethodGet, "/storage/metered/secret-name.txt", "", nil, nil)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	server.request(http.MethodGet, "/no-such-route/secret-name.txt", "", nil, nil).Body.Close()
+
+	_, body := getMetrics(t, server, nil)
+	for _, want := range []string{
+		`easydfs_http_requests_total{method="GET",route="/storage/*path",status="200"}`,
+		`easydfs_http_requests_total{method="PUT",route="/file/object/:bucket/*key",status="200"}`,
+		`easydfs_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`easydfs_http_request_duration_seconds_count{method="GET",route="/storage/*path",status="200"}`,
+		`easydfs_uploaded_bytes_total{bucket="metered"} 5`,
+		`easydfs_downloaded_bytes_total{bucket="metered"} 5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metric %s", want)
+		}
+	}
+	if strings.Contains(body, "secret-name") {
+		t.Error("Object key leaked into metric labels")
+	}
+}
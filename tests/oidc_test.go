@@ -0,0 +1,365 @@
+/*
+ * @PackageName: tests
+ * @Description: OIDC 单点登录测试,覆盖授权码及 PKCE、跳转地址校验、角色映射、按 iss 及 sub 匹配用户及回调接口
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 07:24:16
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 07:24:16
+ */
+
+package tests
+
+import (
+	"context"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/oidc"
+	"easy_dfs/pkg/oidc/oidctest"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oidcAuthorize 访问模拟身份提供方的登录页,返回回调地址中的授权码
+func oidcAuthorize(t *testing.T, authURL, state string) string {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Failed to authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("Expected redirect to callback, got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if location.Query().Get("state") != state {
+		t.Fatalf("Expected state %s, got %s", state, location.Query().Get("state"))
+	}
+	return location.Query().Get("code")
+}
+
+func TestOIDCAuthorizationCodeWithPKCE(t *testing.T) {
+	mock, err := oidctest.New("", "easydfs", "secret")
+	if err != nil {
+		t.Fatalf("Failed to create mock provider: %v", err)
+	}
+	if err := mock.AddUser(oidctest.User{Username: "alice", Groups: []string{"admins", "team-a"}}); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	mock.Issuer = server.URL
+
+	provider := &oidc.Provider{
+		Issuer:       server.URL,
+		ClientID:     "easydfs",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/auth/oidc/callback",
+		Scopes:       []string{"openid", "groups"},
+	}
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("Failed to build auth url: %v", err)
+	}
+	code := oidcAuthorize(t, authURL, "state-1")
+
+	// PKCE 校验码不一致时换取失败,且授权码只能使用一次
+	if _, err := provider.Exchange(ctx, code, "verifier-2"); err == nil {
+		t.Fatal("Expected exchange to fail with wrong code verifier")
+	}
+	if _, err := provider.Exchange(ctx, code, "verifier-1"); err == nil {
+		t.Fatal("Expected used code to be rejected")
+	}
+
+	authURL, _ = provider.AuthCodeURL(ctx, "state-2", "nonce-2", "verifier-2")
+	idToken, err := provider.Exchange(ctx, oidcAuthorize(t, authURL, "state-2"), "verifier-2")
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, idToken, "nonce-2", time.Now())
+	if err != nil {
+		t.Fatalf("Expected valid id token: %v", err)
+	}
+	if oidc.StringClaim(claims, "preferred_username") != "alice" {
+		t.Fatalf("Unexpected claims: %v", claims)
+	}
+	if groups := oidc.StringsClaim(claims, "groups"); len(groups) != 2 || groups[1] != "team-a" {
+		t.Fatalf("Unexpected groups: %v", groups)
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, idToken, "nonce-1", time.Now()); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Fatalf("Expected nonce mismatch, got %v", err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, idToken, "nonce-2", time.Now().Add(time.Hour)); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("Expected expired id token, got %v", err)
+	}
+	other := &oidc.Provider{Issuer: server.URL, ClientID: "other"}
+	if _, err := other.VerifyIDToken(ctx, idToken, "nonce-2", time.Now()); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("Expected audience mismatch, got %v", err)
+	}
+	tampered := idToken[:len(idToken)-4] + "AAAA"
+	if _, err := provider.VerifyIDToken(ctx, tampered, "nonce-2", time.Now()); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("Expected invalid signature, got %v", err)
+	}
+}
+
+func TestIsLocalRedirect(t *testing.T) {
+	for _, redirect := range []string{"/", "/console", "/console/files?bucket=photos&path=a%20b", "/console#files"} {
+		if !services.IsLocalRedirect(redirect) {
+			t.Errorf("Expected %q to be local", redirect)
+		}
+	}
+	for _, redirect := range []string{
+		"",
+		"console",
+		"//evil.example",
+		"/\\evil.example",
+		"/\t/evil.example",
+		"/%09/evil.example",
+		"/%2F/evil.example",
+		"/%5Cevil.example",
+		"/ /evil.example",
+		"/\r\n/evil.example",
+		"/\u00a0/evil.example",
+		"https://evil.example/",
+		"http:/evil.example",
+		"javascript:alert(1)",
+	} {
+		if services.IsLocalRedirect(redirect) {
+			t.Errorf("Expected %q to be rejected", redirect)
+		}
+	}
+}
+
+// oidcSettings 单点登录的配置,用户组 admins、ops 为管理员,team-a 为存储桶所有者,viewers 为只读用户
+func oidcSettings(issuer string) map[string]interface{} {
+	return map[string]interface{}{
+		"oidc.enabled":         true,
+		"oidc.issuer":          issuer,
+		"oidc.client_id":       "easydfs",
+		"oidc.client_secret":   "secret",
+		"oidc.redirect_url":    "http://localhost/auth/oidc/callback",
+		"oidc.admin_groups":    "admins, ops",
+		"oidc.owner_groups":    "team-a",
+		"oidc.readonly_groups": "viewers",
+		"oidc.default_role":    "",
+		"oidc.group_buckets":   map[string]string{"Team-A": "photos, videos", "team-b": "videos"},
+	}
+}
+
+// newMockIdP 启动模拟的身份提供方
+func newMockIdP(t *testing.T) *oidctest.Provider {
+	mock, err := oidctest.New("", "easydfs", "secret")
+	if err != nil {
+		t.Fatalf("Failed to create mock provider: %v", err)
+	}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	mock.Issuer = server.URL
+	return mock
+}
+
+// oidcLogin 以指定用户完成单点登录,返回回调接口的响应
+func oidcLogin(t *testing.T, server *testServer, username, redirect string) *http.Response {
+	path := "/auth/oidc/login"
+	if redirect != "" {
+		path += "?redirect=" + url.QueryEscape(redirect)
+	}
+	resp := server.request(http.MethodGet, path, "", nil, nil)
+	resp.Body.Close()
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("Expected redirect to provider, got %d", resp.StatusCode)
+	}
+	query := authURL.Query()
+	query.Set("login_hint", username)
+	authURL.RawQuery = query.Encode()
+	state := query.Get("state")
+	code := oidcAuthorize(t, authURL.String(), state)
+	return server.request(http.MethodGet, "/auth/oidc/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), "", nil, nil)
+}
+
+func TestOidcRole(t *testing.T) {
+	setupTestDir(t, oidcSettings("http://idp.example"))
+
+	cases := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"Ops"}, user_role.ADMIN},
+		{[]string{"viewers", "TEAM-A"}, user_role.BUCKET_OWNER},
+		{[]string{"viewers"}, user_role.READ_ONLY},
+		{[]string{"team-a", "admins"}, user_role.ADMIN},
+	}
+	for _, c := range cases {
+		if role, err := services.OidcRole(c.groups); err != nil || role != c.want {
+			t.Errorf("%v: expected %s, got %s %v", c.groups, c.want, role, err)
+		}
+	}
+	for _, groups := range [][]string{nil, {"others"}, {"admin"}} {
+		if _, err := services.OidcRole(groups); !errors.Is(err, services.ErrOidcNoRole) {
+			t.Errorf("%v: expected no role, got %v", groups, err)
+		}
+	}
+
+	// 都不匹配时使用默认角色,默认角色无效时拒绝
+	config.Set("oidc.default_role", user_role.READ_ONLY)
+	if role, err := services.OidcRole([]string{"others"}); err != nil || role != user_role.READ_ONLY {
+		t.Errorf("Expected default role, got %s %v", role, err)
+	}
+	config.Set("oidc.default_role", "superuser")
+	if _, err := services.OidcRole(nil); !errors.Is(err, services.ErrOidcNoRole) {
+		t.Errorf("Expected invalid default role to be ignored, got %v", err)
+	}
+}
+
+func TestSyncOidcUser(t *testing.T) {
+	setupTestDir(t, oidcSettings("http://idp.example"))
+	us := new(services.UserService)
+	if _, err := us.CreateUser("bob", "password123", user_role.ADMIN); err != nil {
+		t.Fatal(err)
+	}
+	const issuer = "http://idp.example"
+
+	alice, err := us.SyncOidcUser(issuer, "sub-1", "alice", user_role.BUCKET_OWNER, []string{"team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Username != "alice" || alice.Issuer != issuer || alice.Subject != "sub-1" || alice.Role != user_role.BUCKET_OWNER {
+		t.Fatalf("Unexpected user: %+v", alice)
+	}
+
+	// 同一账号改名后仍对应原用户,角色及用户组随之更新
+	renamed, err := us.SyncOidcUser(issuer, "sub-1", "alice2", user_role.READ_ONLY, []string{"viewers"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Username != "alice" || renamed.Role != user_role.READ_ONLY || len(renamed.Groups) != 1 || renamed.Groups[0] != "viewers" {
+		t.Fatalf("Expected existing user to be updated, got %+v", renamed)
+	}
+
+	// 其他账号使用同名(含大小写不同)的用户名时不能接管已有用户
+	if _, err := us.SyncOidcUser(issuer, "sub-2", "Alice", user_role.ADMIN, nil); !errors.Is(err, services.ErrOidcUsernameTaken) {
+		t.Errorf("Expected username taken, got %v", err)
+	}
+	if _, err := us.SyncOidcUser(issuer, "sub-3", "BOB", user_role.ADMIN, nil); !errors.Is(err, services.ErrOidcLocalUser) {
+		t.Errorf("Expected local user conflict, got %v", err)
+	}
+	// 其他签发方的同一 sub 是不同的账号
+	if _, err := us.SyncOidcUser("http://other.example", "sub-1", "alice", user_role.ADMIN, nil); !errors.Is(err, services.ErrOidcUsernameTaken) {
+		t.Errorf("Expected other issuer not to match, got %v", err)
+	}
+	if _, err := us.SyncOidcUser(issuer, "", "carol", user_role.ADMIN, nil); !errors.Is(err, services.ErrOidcSubject) {
+		t.Errorf("Expected missing subject to be rejected, got %v", err)
+	}
+	if _, err := us.SyncOidcUser(issuer, "sub-4", "bad name", user_role.ADMIN, nil); !errors.Is(err, services.ErrInvalidUsername) {
+		t.Errorf("Expected invalid username to be rejected, got %v", err)
+	}
+
+	// 被禁用的用户不能再通过单点登录
+	if err := us.RevokeUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := us.SyncOidcUser(issuer, "sub-1", "alice", user_role.ADMIN, nil); !errors.Is(err, services.ErrUserDisabled) {
+		t.Errorf("Expected disabled user to be rejected, got %v", err)
+	}
+	user, err := us.FindUser("alice")
+	if err != nil || user.Role != user_role.READ_ONLY {
+		t.Errorf("Expected disabled user to keep its role, got %+v %v", user, err)
+	}
+}
+
+func TestOidcCallback(t *testing.T) {
+	mock := newMockIdP(t)
+	server := newTestServer(t, oidcSettings(mock.Issuer))
+	for _, user := range []oidctest.User{
+		{Username: "alice", Groups: []string{"Team-A", "team-b"}},
+		{Username: "admin", Groups: []string{"admins"}},
+		{Username: "mallory", Groups: []string{"others"}},
+	} {
+		if err := mock.AddUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 未指定跳转地址时直接返回登录结果,用户组授权的存储桶不区分大小写
+	var login model.LoginResult
+	resp := oidcLogin(t, server, "alice", "")
+	result := decodeResponse(t, resp)
+	if !result.Success {
+		t.Fatalf("Expected login to succeed, got %s %v", result.Code, result.Errors)
+	}
+	data, _ := json.Marshal(result.Data)
+	if err := json.Unmarshal(data, &login); err != nil || login.Token == "" || login.User.Role != user_role.BUCKET_OWNER {
+		t.Fatalf("Unexpected login result: %+v %v", login, err)
+	}
+	var principal model.Principal
+	server.mustCall(http.MethodGet, "/auth/me", login.Token, nil, &principal)
+	if principal.Username != "alice" || len(principal.Buckets) != 2 || principal.Buckets[0] != "photos" || principal.Buckets[1] != "videos" {
+		t.Errorf("Unexpected principal: %+v", principal)
+	}
+
+	// 指定跳转地址时令牌附在片段中
+	resp = oidcLogin(t, server, "alice", "/console?tab=files")
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil || location.Path != "/console" || location.RawQuery != "tab=files" {
+		t.Fatalf("Unexpected redirect: %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if fragment.Get("oidc_token") == "" || fragment.Get("expires_at") == "" {
+		t.Errorf("Expected token in fragment, got %s", location.Fragment)
+	}
+
+	// 身份提供方中同名的其他账号、同名的本地用户及未被授权的用户组均被拒绝
+	if err := mock.AddUser(oidctest.User{Username: "alice", Groups: []string{"admins"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"alice", "admin", "mallory"} {
+		resp = oidcLogin(t, server, username, "")
+		if result := decodeResponse(t, resp); resp.StatusCode != http.StatusForbidden || result.Code != response_code.REQUEST_DENIED {
+			t.Errorf("%s: expected login to be denied, got %d %s", username, resp.StatusCode, result.Code)
+		}
+	}
+	if user, err := new(services.UserService).FindUser("alice"); err != nil || user.Role != user_role.BUCKET_OWNER {
+		t.Errorf("Expected alice to be unchanged, got %+v %v", user, err)
+	}
+
+	// 失败时跳转回发起页面并附上错误信息
+	resp = oidcLogin(t, server, "mallory", "/console")
+	resp.Body.Close()
+	if location, err := url.Parse(resp.Header.Get("Location")); err != nil || location.Path != "/console" || !strings.Contains(location.Fragment, "oidc_error=") {
+		t.Errorf("Expected error redirect, got %s", resp.Header.Get("Location"))
+	}
+
+	// 跳转地址只能为本站路径,state 只能使用一次
+	if result := server.call(http.MethodGet, "/auth/oidc/login?redirect="+url.QueryEscape("/\t/evil.example"), "", nil, nil); result.Code != response_code.PARAM_ERROR {
+		t.Errorf("Expected external redirect to be rejected, got %s", result.Code)
+	}
+	resp = server.request(http.MethodGet, "/auth/oidc/callback?code=x&state=unknown", "", nil, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusBadRequest || result.Code != response_code.PARAM_ERROR {
+		t.Errorf("Expected unknown state to be rejected, got %d %s", resp.StatusCode, result.Code)
+	}
+	resp = server.request(http.MethodGet, "/auth/oidc/callback?error=access_denied&state=unknown", "", nil, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusUnauthorized || result.Code != response_code.TOKEN_INVALID {
+		t.Errorf("Expected provider error to be returned, got %d %s", resp.StatusCode, result.Code)
+	}
+
+	// 未启用时返回不存在
+	config.Set("oidc.enabled", false)
+	resp = server.request(http.MethodGet, "/auth/oidc/login", "", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected disabled login to be not found, got %d", resp.StatusCode)
+	}
+}
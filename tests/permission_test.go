@@ -0,0 +1,292 @@
+/*
+ * @PackageName: tests
+ * @Description: 用户权限测试,覆盖角色校验、存储桶名称不一致、文件名越出存储桶目录、初始化、登录及禁用用户
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 12:41:26
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 12:41:26
+ */
+
+package tests
+
+import (
+	"bytes"
+	"easy_dfs/app/enum/access_policy"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/http/http_response"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// decodeResponse 解析统一格式的响应
+func decodeResponse(t *testing.T, resp *http.Response) http_response.ResponseData {
+	defer resp.Body.Close()
+	var result http_response.ResponseData
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	return result
+}
+
+func TestAuthorizeBucket(t *testing.T) {
+	bucket := &model.BucketInfo{Name: "photos", Owner: "alice"}
+	cases := []struct {
+		principal model.Principal
+		write     bool
+		want      error
+	}{
+		{model.Principal{Username: "root", Role: user_role.ADMIN}, true, nil},
+		{model.Principal{Username: "reader", Role: user_role.READ_ONLY}, false, nil},
+		{model.Principal{Username: "reader", Role: user_role.READ_ONLY}, true, services.ErrReadOnly},
+		{model.Principal{Username: "alice", Role: user_role.BUCKET_OWNER}, true, nil},
+		{model.Principal{Username: "bob", Role: user_role.BUCKET_OWNER}, false, services.ErrNotBucketOwner},
+		{model.Principal{Username: "bob", Role: user_role.BUCKET_OWNER, Buckets: []string{"photos"}}, true, nil},
+		{model.Principal{Username: "eve", Role: "guest"}, false, services.ErrPermissionDenied},
+	}
+	for _, c := range cases {
+		if err := services.AuthorizeBucket(c.principal, bucket, c.write); !errors.Is(err, c.want) {
+			t.Errorf("%+v write=%v: expected %v, got %v", c.principal, c.write, c.want, err)
+		}
+	}
+	// 没有所有者的存储桶只有管理员可以管理
+	if err := services.AuthorizeBucket(model.Principal{Role: user_role.BUCKET_OWNER}, &model.BucketInfo{Name: "shared"}, false); !errors.Is(err, services.ErrNotBucketOwner) {
+		t.Errorf("Expected ownerless bucket to be denied, got %v", err)
+	}
+}
+
+func TestBucketPermission(t *testing.T) {
+	server := newTestServer(t, nil)
+	aliceToken := server.createUser("alice", user_role.BUCKET_OWNER)
+	bobToken := server.createUser("bob", user_role.BUCKET_OWNER)
+	readerToken := server.createUser("reader", user_role.READ_ONLY)
+	server.mustCall(http.MethodPost, "/bucket/create", aliceToken, map[string]string{"name": "photos"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/photos/a.txt", aliceToken, strings.NewReader("data"), nil)
+
+	status := func(method, path, token string, body interface{}) int {
+		resp := server.request(method, path, token, body, nil)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	cases := []struct {
+		method, path, token string
+		body                interface{}
+		want                int
+	}{
+		// 所有者只能操作自己的存储桶
+		{http.MethodGet, "/file/list?bucket=photos", aliceToken, nil, http.StatusOK},
+		{http.MethodGet, "/file/list?bucket=photos", bobToken, nil, http.StatusForbidden},
+		{http.MethodPut, "/file/object/photos/b.txt", bobToken, strings.NewReader("data"), http.StatusForbidden},
+		{http.MethodPut, "/bucket/policy", bobToken, map[string]string{"bucket": "photos", "accessPolicy": access_policy.PUBLIC}, http.StatusForbidden},
+		{http.MethodGet, "/file/list-all", bobToken, nil, http.StatusForbidden},
+		{http.MethodGet, "/bucket/list", bobToken, nil, http.StatusOK},
+		// 只读用户只能查看及下载
+		{http.MethodGet, "/file/list?bucket=photos", readerToken, nil, http.StatusOK},
+		{http.MethodGet, "/file/download?bucket=photos&filename=a.txt", readerToken, nil, http.StatusOK},
+		{http.MethodPost, "/file/archive", readerToken, map[string]interface{}{"bucket": "photos", "keys": []string{"a.txt"}}, http.StatusOK},
+		{http.MethodDelete, "/file/delete?bucket=photos&filename=a.txt", readerToken, nil, http.StatusForbidden},
+		{http.MethodPost, "/bucket/create", readerToken, map[string]string{"name": "notes"}, http.StatusForbidden},
+		// 只有管理员可以管理用户
+		{http.MethodGet, "/user/list", aliceToken, nil, http.StatusForbidden},
+		{http.MethodGet, "/user/list", server.AdminToken, nil, http.StatusOK},
+	}
+	for _, c := range cases {
+		if got := status(c.method, c.path, c.token, c.body); got != c.want {
+			t.Errorf("%s %s: expected %d, got %d", c.method, c.path, c.want, got)
+		}
+	}
+
+	// 存储桶不存在时交由控制器返回
+	if result := server.call(http.MethodGet, "/bucket/info?bucket=missing", bobToken, nil, nil); result.Code == response_code.REQUEST_DENIED {
+		t.Errorf("Expected missing bucket not to be denied, got %s", result.Code)
+	}
+	// 列表只返回自己的存储桶
+	var buckets []model.BucketInfo
+	server.mustCall(http.MethodGet, "/bucket/list", bobToken, nil, &buckets)
+	if len(buckets) != 0 {
+		t.Errorf("Expected bob to see no buckets, got %+v", buckets)
+	}
+}
+
+// 查询参数与请求体指定了不同的存储桶时拒绝,避免以自己的存储桶通过校验而修改他人的存储桶
+func TestBucketPermissionRejectsMismatchedBucket(t *testing.T) {
+	server := newTestServer(t, nil)
+	aliceToken := server.createUser("alice", user_role.BUCKET_OWNER)
+	server.mustCall(http.MethodPost, "/bucket/create", aliceToken, map[string]string{"name": "mine"}, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "victim"}, nil)
+
+	resp := server.request(http.MethodPut, "/bucket/policy?bucket=mine", aliceToken, map[string]string{"bucket": "victim", "accessPolicy": access_policy.PUBLIC}, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusForbidden || result.Code != response_code.REQUEST_DENIED {
+		t.Errorf("Expected mismatched JSON bucket to be denied, got %d %s", resp.StatusCode, result.Code)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("bucket", "victim")
+	part, err := writer.CreateFormFile("file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte("data"))
+	_ = writer.Close()
+	resp = server.request(http.MethodPost, "/file/upload?bucket=mine", aliceToken, &body, map[string]string{"Content-Type": writer.FormDataContentType()})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected mismatched form bucket to be denied, got %d", resp.StatusCode)
+	}
+
+	// 管理员同样不允许指定不同的存储桶
+	resp = server.request(http.MethodPut, "/bucket/policy?bucket=mine", server.AdminToken, map[string]string{"bucket": "victim", "accessPolicy": access_policy.PUBLIC}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected mismatched bucket to be denied for admin, got %d", resp.StatusCode)
+	}
+
+	victim, err := new(services.BucketService).FindBucketInfo("victim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if victim.AccessPolicy == access_policy.PUBLIC {
+		t.Error("Expected victim bucket policy to be unchanged")
+	}
+	if _, err := os.Stat("tmp/storage/victim/a.txt"); !os.IsNotExist(err) {
+		t.Errorf("Expected no file in victim bucket, got %v", err)
+	}
+
+	// 只在查询参数中指定存储桶时以查询参数为准
+	server.mustCall(http.MethodPut, "/bucket/policy?bucket=mine", aliceToken, map[string]string{"accessPolicy": access_policy.PUBLIC}, nil)
+	if mine, _ := new(services.BucketService).FindBucketInfo("mine"); mine == nil || mine.AccessPolicy != access_policy.PUBLIC {
+		t.Errorf("Expected query bucket to be updated, got %+v", mine)
+	}
+}
+
+// 文件名中的 ../ 不能越出存储桶目录,读取或删除配置文件及其他存储桶中的文件
+func TestObjectKeyTraversal(t *testing.T) {
+	server := newTestServer(t, nil)
+	aliceToken := server.createUser("alice", user_role.BUCKET_OWNER)
+	server.mustCall(http.MethodPost, "/bucket/create", aliceToken, map[string]string{"name": "mine"}, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "victim"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/victim/a.txt", server.AdminToken, strings.NewReader("secret"), nil)
+
+	protected := []string{"tmp/config/user.json", "tmp/storage/victim/a.txt"}
+	for _, path := range protected {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("Expected %s to exist before the test: %v", path, err)
+		}
+	}
+
+	for _, key := range []string{"../../config/jwt_secret", "../../config/user.json", "../victim/a.txt", "x/../../victim/a.txt"} {
+		escaped := url.QueryEscape(key)
+		resp := server.request(http.MethodGet, "/file/download?bucket=mine&filename="+escaped, aliceToken, nil, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Download %s: expected 404, got %d", key, resp.StatusCode)
+		}
+		if result := server.call(http.MethodGet, "/file/info?bucket=mine&filename="+escaped, aliceToken, nil, nil); result.Code != response_code.QUERY_EMPTY {
+			t.Errorf("Info %s: expected not found, got %s", key, result.Code)
+		}
+		if result := server.call(http.MethodDelete, "/file/delete?bucket=mine&filename="+escaped, aliceToken, nil, nil); result.Success {
+			t.Errorf("Delete %s: expected to fail", key)
+		}
+	}
+	for _, path := range protected {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept: %v", path, err)
+		}
+	}
+
+	// 规范化后为空的文件名为参数错误,不存在的存储桶同样拒绝,管理员也不例外
+	if result := server.call(http.MethodDelete, "/file/delete?bucket=mine&filename=..%2F", aliceToken, nil, nil); result.Code != response_code.PARAM_ERROR {
+		t.Errorf("Expected empty key to be rejected, got %s", result.Code)
+	}
+	if result := server.call(http.MethodGet, "/file/info?bucket=..&filename=config%2Fuser.json", server.AdminToken, nil, nil); result.Success {
+		t.Error("Expected unknown bucket to be rejected")
+	}
+
+	fs := new(services.FileService)
+	if _, err := fs.LoadFile("mine", "../../config/jwt_secret"); err == nil {
+		t.Error("Expected LoadFile to stay inside the bucket")
+	}
+	if _, err := fs.LoadFileByPath("..", "config/jwt_secret"); !errors.Is(err, services.ErrBucketNotFound) {
+		t.Errorf("Expected unknown bucket, got %v", err)
+	}
+	if exists, err := fs.FileExists("mine", "../victim/a.txt"); err != nil || exists {
+		t.Errorf("Expected key to stay inside the bucket, got %v %v", exists, err)
+	}
+	if _, err := fs.GetFileSize("mine", "../../config/user.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected key to stay inside the bucket, got %v", err)
+	}
+}
+
+func TestBootstrapAndLogin(t *testing.T) {
+	server := newEmptyTestServer(t, nil)
+
+	// 尚未创建任何用户时可以不经校验创建第一个用户
+	server.mustCall(http.MethodPost, "/user/create", "", map[string]string{"username": "root", "password": "password123", "role": user_role.ADMIN}, nil)
+	if result := server.call(http.MethodPost, "/user/create", "", map[string]string{"username": "mallory", "password": "password123", "role": user_role.ADMIN}, nil); result.Code != response_code.TOKEN_INVALID {
+		t.Fatalf("Expected bootstrap to be closed after first user, got %s", result.Code)
+	}
+
+	resp := server.request(http.MethodPost, "/auth/login", "", map[string]string{"username": "root", "password": "wrong-password"}, nil)
+	if result := decodeResponse(t, resp); resp.StatusCode != http.StatusUnauthorized || result.Code != response_code.TOKEN_INVALID {
+		t.Errorf("Expected wrong password to be rejected, got %d %s", resp.StatusCode, result.Code)
+	}
+	resp = server.request(http.MethodPost, "/auth/login", "", map[string]string{"username": "nobody", "password": "password123"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected unknown user to be rejected, got %d", resp.StatusCode)
+	}
+
+	var login model.LoginResult
+	server.mustCall(http.MethodPost, "/auth/login", "", map[string]string{"username": "root", "password": "password123"}, &login)
+	if login.Token == "" || login.User.Username != "root" || login.User.Password != "" {
+		t.Fatalf("Unexpected login result: %+v", login)
+	}
+	var principal model.Principal
+	server.mustCall(http.MethodGet, "/auth/me", login.Token, nil, &principal)
+	if principal.Username != "root" || principal.Role != user_role.ADMIN {
+		t.Errorf("Unexpected principal: %+v", principal)
+	}
+	if result := server.call(http.MethodGet, "/auth/me", "", nil, nil); result.Code != response_code.TOKEN_INVALID {
+		t.Errorf("Expected request without token to be rejected, got %s", result.Code)
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	server := newTestServer(t, nil)
+	aliceToken := server.createUser("alice", user_role.BUCKET_OWNER)
+	var key model.AccessKeyInfo
+	server.mustCall(http.MethodPost, "/access_key/create", aliceToken, map[string]string{"name": "alice-key"}, &key)
+	keyHeader := map[string]string{"X-Access-Key": key.AccessKey, "X-Secret-Key": key.SecretKey}
+	if result := decodeResponse(t, server.request(http.MethodGet, "/auth/me", "", nil, keyHeader)); !result.Success {
+		t.Fatalf("Expected access key to work before revoke, got %s", result.Code)
+	}
+
+	server.mustCall(http.MethodPost, "/user/revoke?username=alice", server.AdminToken, nil, nil)
+
+	// 已签发的会话令牌及访问密钥均失效,也不能再次登录
+	if result := server.call(http.MethodGet, "/auth/me", aliceToken, nil, nil); result.Code != response_code.TOKEN_INVALID {
+		t.Errorf("Expected revoked token to be rejected, got %s", result.Code)
+	}
+	if result := decodeResponse(t, server.request(http.MethodGet, "/auth/me", "", nil, keyHeader)); result.Code != response_code.TOKEN_INVALID {
+		t.Errorf("Expected revoked access key to be rejected, got %s", result.Code)
+	}
+	resp := server.request(http.MethodPost, "/auth/login", "", map[string]string{"username": "alice", "password": "password123"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected disabled user login to be forbidden, got %d", resp.StatusCode)
+	}
+
+	// 不能禁用最后一个管理员
+	if result := server.call(http.MethodPost, "/user/revoke?username=admin", server.AdminToken, nil, nil); result.Success {
+		t.Error("Expected revoking the last admin to fail")
+	}
+}
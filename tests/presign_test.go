@@ -0,0 +1,44 @@
+/*
+ * @PackageName: tests
+ * @Description:
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 18:06:21
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 18:06:21
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/presign"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPresignURL(t *testing.T) {
+	now := time.Now()
+	signed, err := url.Parse(presign.URL("http://localhost:18088/", "docs", "a/b c.txt", "ak", "sk", now.Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("Failed to parse presigned url: %v", err)
+	}
+	if signed.Path != "/storage/docs/a/b c.txt" {
+		t.Fatalf("Unexpected path: %s", signed.Path)
+	}
+	query := signed.Query()
+	if err := presign.Verify("sk", "GET", "docs", "a/b c.txt", query, now); err != nil {
+		t.Fatalf("Expected valid signature: %v", err)
+	}
+	if err := presign.Verify("sk", "HEAD", "docs", "a/b c.txt", query, now); err != nil {
+		t.Fatalf("Expected HEAD to share GET signature: %v", err)
+	}
+	if err := presign.Verify("other", "GET", "docs", "a/b c.txt", query, now); err == nil {
+		t.Fatal("Expected error for wrong secret key")
+	}
+	if err := presign.Verify("sk", "GET", "docs", "a/other.txt", query, now); err == nil {
+		t.Fatal("Expected error for different key")
+	}
+	if err := presign.Verify("sk", "GET", "docs", "a/b c.txt", query, now.Add(2*time.Minute)); err == nil {
+		t.Fatal("Expected error for expired signature")
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * @PackageName: tests
+ * @Description: 限流器测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:58:21
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:58:21
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRetryAfter(t *testing.T) {
+	limiter := ratelimit.New(1, 2)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatalf("request %d within burst should be allowed", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("a")
+	if ok {
+		t.Fatal("request beyond burst should be rejected")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Fatalf("unexpected retry after: %v", wait)
+	}
+
+	// 不同 key 的额度互不影响
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Fatal("other key should have its own budget")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := ratelimit.New(0, 0)
+	for i := 0; i < 100; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatal("zero rate should not limit")
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * @PackageName: tests
+ * @Description: 防盗链规则测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 21:44:18
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 21:44:18
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/referer"
+	"testing"
+)
+
+func TestRefererRules(t *testing.T) {
+	allow := referer.Rule{Mode: referer.ModeAllowlist, Referers: []string{"example.com", "*.example.com"}}
+	deny := referer.Rule{Mode: referer.ModeDenylist, Referers: []string{"*.thief.net"}, AllowEmpty: true}
+
+	cases := []struct {
+		rule    referer.Rule
+		referer string
+		want    bool
+	}{
+		{allow, "https://example.com/page", true},
+		{allow, "https://img.Example.com:8443/a.html", true},
+		{allow, "https://example.com.evil.org/", false},
+		{allow, "", false},
+		{allow, "not a url", false},
+		{deny, "http://www.thief.net/post/1", false},
+		{deny, "https://friend.org/", true},
+		{deny, "", true},
+	}
+	for _, c := range cases {
+		if got := c.rule.Allowed(c.referer); got != c.want {
+			t.Errorf("%s %q: got %v, want %v", c.rule.Mode, c.referer, got, c.want)
+		}
+	}
+
+	if err := (referer.Rule{Mode: "block"}).Validate(); err == nil {
+		t.Error("invalid mode should fail validation")
+	}
+	if err := (referer.Rule{Mode: referer.ModeAllowlist, Referers: []string{"[bad"}}).Validate(); err == nil {
+		t.Error("invalid pattern should fail validation")
+	}
+}
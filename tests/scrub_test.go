@@ -0,0 +1,133 @@
+/*
+ * @PackageName: tests
+ * @Description: 数据完整性巡检测试,人为损坏、截断及删除文件后检查巡检报告
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 10:41:18
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 10:41:18
+ */
+
+package tests
+
+import (
+	"crypto/sha256"
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"easy_dfs/pkg/config"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitScrubReport 等待巡检完成并返回报告
+func waitScrubReport(t *testing.T, server *testServer) model.ScrubReport {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var report model.ScrubReport
+		server.mustCall(http.MethodGet, "/scrub/report", server.AdminToken, nil, &report)
+		if report.Status != services.ScrubRunning {
+			return report
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Scrub did not finish in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestScrubDetectsDamagedObjects(t *testing.T) {
+	server := newTestServer(t, nil)
+	if result := server.call(http.MethodGet, "/scrub/report", server.AdminToken, nil, nil); result.Code != response_code.QUERY_EMPTY {
+		t.Fatalf("Expected no report before first scrub, got %s", result.Code)
+	}
+
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	for _, key := range []string{"ok.txt", "corrupted.txt", "truncated.txt", "dir/missing.txt"} {
+		server.mustCall(http.MethodPut, "/file/object/photos/"+key, server.AdminToken, strings.NewReader("original content"), nil)
+	}
+	root := filepath.Join("tmp", "storage", "photos")
+	// 同样大小的不同内容、截断、删除,以及绕过接口直接写入的没有摘要的文件
+	if err := os.WriteFile(filepath.Join(root, "corrupted.txt"), []byte("0riginal content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "truncated.txt"), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "dir", "missing.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "copied.txt"), []byte("copied"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var started model.ScrubReport
+	server.mustCall(http.MethodPost, "/scrub/run", server.AdminToken, nil, &started)
+	if started.ID == "" || started.Trigger != services.ScrubTriggerManual {
+		t.Fatalf("Unexpected started report: %+v", started)
+	}
+	report := waitScrubReport(t, server)
+
+	if report.ID != started.ID || report.Status != services.ScrubCompleted || report.FinishedAt == "" {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if report.Objects != 4 || report.Verified != 1 || report.Unverified != 1 || report.Corrupted != 2 || report.Missing != 1 || report.Failed != 0 {
+		t.Fatalf("Unexpected counts: %+v", report)
+	}
+	if report.Bytes != int64(len("original content")) {
+		t.Errorf("Expected only verified bytes to be counted, got %d", report.Bytes)
+	}
+
+	issues := make(map[string]model.ScrubIssue)
+	for _, issue := range report.Issues {
+		if issue.Bucket != "photos" {
+			t.Errorf("Unexpected bucket in issue %+v", issue)
+		}
+		issues[issue.Key] = issue
+	}
+	if len(issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %+v", report.Issues)
+	}
+	want := sha256.Sum256([]byte("original content"))
+	got := sha256.Sum256([]byte("0riginal content"))
+	if issue := issues["corrupted.txt"]; issue.Type != services.ScrubIssueCorrupted || issue.Expected != hex.EncodeToString(want[:]) || issue.Actual != hex.EncodeToString(got[:]) {
+		t.Errorf("Unexpected corrupted issue: %+v", issue)
+	}
+	if issue := issues["truncated.txt"]; issue.Type != services.ScrubIssueSizeMismatch || issue.Expected != "16" || issue.Actual != "8" {
+		t.Errorf("Unexpected size mismatch issue: %+v", issue)
+	}
+	if issue := issues["dir/missing.txt"]; issue.Type != services.ScrubIssueMissing {
+		t.Errorf("Unexpected missing issue: %+v", issue)
+	}
+
+	// 报告已保存,进行中的状态已清除,可以再次触发
+	data, err := os.ReadFile(filepath.Join("tmp", "config", "scrub_report.json"))
+	if err != nil || !strings.Contains(string(data), started.ID) {
+		t.Fatalf("Expected report to be saved, got %v", err)
+	}
+	server.mustCall(http.MethodPost, "/scrub/run", server.AdminToken, nil, &started)
+	waitScrubReport(t, server)
+}
+
+func TestScrubScheduleInterval(t *testing.T) {
+	setupTestDir(t, map[string]interface{}{"scrub.enabled": true, "scrub.interval": 12})
+	ss := new(services.ScrubService)
+	if got := ss.ScheduleInterval(); got != 12*time.Hour {
+		t.Errorf("Expected 12h interval, got %v", got)
+	}
+
+	// 配置为 false 时关闭定期巡检,不使用默认值
+	config.Set("scrub.enabled", false)
+	if got := ss.ScheduleInterval(); got != 0 {
+		t.Errorf("Expected schedule to be disabled, got %v", got)
+	}
+	config.Set("scrub.enabled", true)
+	config.Set("scrub.interval", -1)
+	if got := ss.ScheduleInterval(); got != 0 {
+		t.Errorf("Expected non-positive interval to disable the schedule, got %v", got)
+	}
+}
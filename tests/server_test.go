@@ -0,0 +1,166 @@
+/*
+ * @PackageName: tests
+ * @Description: 接口测试的公共方法,每个测试在独立的临时目录中启动服务
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 09:12:40
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 09:12:40
+ */
+
+package tests
+
+import (
+	"bytes"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/middlewares"
+	"easy_dfs/app/services"
+	"easy_dfs/bootstrap"
+	btsConfig "easy_dfs/config"
+	"easy_dfs/pkg/config"
+	"easy_dfs/pkg/http/http_response"
+	"easy_dfs/routes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 配置只能加载一次,需在测试目录中加载 app.yml
+var configOnce sync.Once
+
+// 不跟随重定向的客户端,以便检查重定向响应
+var noRedirectClient = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+// testServer 测试用的服务及管理员会话令牌
+type testServer struct {
+	*httptest.Server
+	t          *testing.T
+	AdminToken string
+}
+
+// setupTestDir 加载配置并切换到临时目录,生成配置及存储目录;settings 覆盖配置项,测试结束后恢复
+func setupTestDir(t *testing.T, settings map[string]interface{}) {
+	configOnce.Do(func() {
+		btsConfig.Initialize()
+		config.InitConfig("")
+		gin.SetMode(gin.TestMode)
+	})
+	for path, value := range settings {
+		previous := config.GetString(path)
+		config.Set(path, value)
+		t.Cleanup(func() { config.Set(path, previous) })
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	bootstrap.SetupConfigDir()
+}
+
+// newTestServer 在临时目录中启动注册了全部路由的服务,并创建管理员 admin
+func newTestServer(t *testing.T, settings map[string]interface{}) *testServer {
+	server := newEmptyTestServer(t, settings)
+	server.AdminToken = server.createUser("admin", user_role.ADMIN)
+	return server
+}
+
+// newEmptyTestServer 在临时目录中启动注册了全部路由的服务,不创建任何用户
+func newEmptyTestServer(t *testing.T, settings map[string]interface{}) *testServer {
+	setupTestDir(t, settings)
+
+	router := gin.New()
+	// 与正式服务一致,在注册路由前加入监控指标中间件
+	router.Use(middlewares.Metrics())
+	routes.RegisterRoutes(router)
+	server := &testServer{Server: httptest.NewServer(middlewares.VirtualHost(router)), t: t}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// createUser 创建用户并登录,返回会话令牌,密码为 password123
+func (s *testServer) createUser(username, role string) string {
+	us := new(services.UserService)
+	if _, err := us.CreateUser(username, "password123", role); err != nil {
+		s.t.Fatalf("Failed to create user %s: %v", username, err)
+	}
+	result, err := us.Login(username, "password123")
+	if err != nil {
+		s.t.Fatalf("Failed to login %s: %v", username, err)
+	}
+	return result.Token
+}
+
+// request 发送请求,body 为 io.Reader 时原样发送,否则编码为 JSON;token 为空时不带会话令牌
+func (s *testServer) request(method, path, token string, body interface{}, header map[string]string) *http.Response {
+	var reader io.Reader
+	switch v := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if _, ok := body.(io.Reader); !ok && body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range header {
+		if k == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	resp, err := noRedirectClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return resp
+}
+
+// call 发送请求并解析统一格式的响应,data 不为空时解析其中的数据
+func (s *testServer) call(method, path, token string, body interface{}, data interface{}) http_response.ResponseData {
+	resp := s.request(method, path, token, body, nil)
+	defer resp.Body.Close()
+	var result struct {
+		http_response.ResponseData
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		s.t.Fatalf("%s %s: invalid response: %v", method, path, err)
+	}
+	if data != nil && len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, data); err != nil {
+			s.t.Fatalf("%s %s: invalid data: %v", method, path, err)
+		}
+	}
+	return result.ResponseData
+}
+
+// mustCall 发送请求,返回码不是成功时测试失败
+func (s *testServer) mustCall(method, path, token string, body interface{}, data interface{}) {
+	s.t.Helper()
+	if result := s.call(method, path, token, body, data); !result.Success {
+		s.t.Fatalf("%s %s failed: %s %v", method, path, result.Code, result.Errors)
+	}
+}
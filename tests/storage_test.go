@@ -1,6 +1,6 @@
 /*
  * @PackageName: tests
- * @Description:
+ * @Description: 文件存储测试,覆盖原子写入、临时文件前缀及文件名规范化
  * @Author: gabbymrh
  * @Date: 2024-07-18 10:18:04
  * @LastModifiedBy: gabbymrh
@@ -12,7 +12,15 @@ package tests
 import (
 	"bytes"
 	"easy_dfs/pkg/filesystem"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestSaveAndLoadFile(t *testing.T) {
@@ -42,3 +50,97 @@ func TestSaveAndLoadFile(t *testing.T) {
 		t.Fatalf("Failed to delete file: %v", err)
 	}
 }
+
+// 写入失败时保留原文件,写入中的临时文件不出现在文件列表中
+func TestSaveIsAtomic(t *testing.T) {
+	storage := filesystem.FileSystemStorage{BaseDir: "./testdata"}
+	filename := "atomic/file.txt"
+	defer os.RemoveAll(filepath.Join("testdata", "atomic"))
+
+	if err := storage.Save(filename, strings.NewReader("old")); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	failing := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("client gone")))
+	if err := storage.Save(filename, failing); err == nil {
+		t.Fatal("Expected interrupted write to fail")
+	}
+	data, _ := os.ReadFile(filepath.Join("testdata", filename))
+	if string(data) != "old" {
+		t.Fatalf("Expected original content to be kept but got %q", data)
+	}
+
+	// 失败的写入不留下新建的目录
+	nested := filepath.Join("tmp", "storage", "atomic-bucket", "a", "b", "file.txt")
+	defer os.RemoveAll(filepath.Join("testdata", "tmp"))
+	if err := storage.Save(nested, iotest.ErrReader(errors.New("client gone"))); err == nil {
+		t.Fatal("Expected failed write to return error")
+	}
+	if _, err := os.Stat(filepath.Join("testdata", "tmp", "storage", "atomic-bucket")); !os.IsNotExist(err) {
+		t.Fatalf("Expected created directories to be removed but got %v", err)
+	}
+
+	tmpPath, err := storage.SaveTemp(filename, strings.NewReader("new"))
+	if err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	files, _ := storage.ListFilesUnder("atomic")
+	if len(files) != 1 || files[0] != "file.txt" {
+		t.Fatalf("Expected temp file to be hidden but got %v", files)
+	}
+	if err := storage.Commit(tmpPath, filename); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join("testdata", filename))
+	if string(data) != "new" {
+		t.Fatalf("Expected committed content but got %q", data)
+	}
+}
+
+// 临时文件名前缀保留给写入中的临时文件,不能作为文件名
+func TestObjectKeyRejectsTempFilePrefix(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	for _, key := range []string{filesystem.TempFilePrefix + "x", "a/" + filesystem.TempFilePrefix + "x", "a/../" + filesystem.TempFilePrefix + "x"} {
+		if result := server.call(http.MethodPut, "/file/object/photos/"+key, server.AdminToken, strings.NewReader("data"), nil); result.Success {
+			t.Errorf("Expected key %s to be rejected", key)
+		}
+	}
+
+	server.mustCall(http.MethodPut, "/file/object/photos/a.txt", server.AdminToken, strings.NewReader("data"), nil)
+	rename := map[string]string{"bucket": "photos", "from": "a.txt", "to": "b/" + filesystem.TempFilePrefix + "a.txt"}
+	if result := server.call(http.MethodPost, "/file/rename", server.AdminToken, rename, nil); result.Success {
+		t.Error("Expected rename to temp file prefix to be rejected")
+	}
+	server.mustCall(http.MethodPut, "/file/object/photos/"+strings.TrimPrefix(filesystem.TempFilePrefix, ".")+"x", server.AdminToken, strings.NewReader("data"), nil)
+}
+
+// 读取及删除文件时与写入使用相同的规范化规则,不同写法的文件名对应同一文件、元数据及用量
+func TestObjectKeyNormalisedOnReadAndDelete(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "photos"}, nil)
+	server.mustCall(http.MethodPut, "/bucket/update", server.AdminToken, map[string]interface{}{
+		"bucket": "photos",
+		"quota":  map[string]int64{"maxObjects": 1},
+	}, nil)
+	server.mustCall(http.MethodPut, "/file/object/photos/dir/b.txt", server.AdminToken, strings.NewReader("data"), nil)
+
+	for _, key := range []string{"dir/x/../b.txt", "/dir//b.txt", "./dir/b.txt"} {
+		escaped := url.QueryEscape(key)
+		server.mustCall(http.MethodGet, "/file/info?bucket=photos&filename="+escaped, server.AdminToken, nil, nil)
+		resp := server.request(http.MethodGet, "/file/download?bucket=photos&filename="+escaped, server.AdminToken, nil, nil)
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(data) != "data" {
+			t.Errorf("Download %s: expected file content, got %d %q", key, resp.StatusCode, data)
+		}
+	}
+
+	server.mustCall(http.MethodDelete, "/file/delete?bucket=photos&filename="+url.QueryEscape("dir/x/../b.txt"), server.AdminToken, nil, nil)
+	for _, path := range []string{"tmp/storage/photos/dir/b.txt", "tmp/meta/photos/dir/b.txt.json"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted, got %v", path, err)
+		}
+	}
+	// 删除后用量随之减少,配额允许再上传一个文件
+	server.mustCall(http.MethodPut, "/file/object/photos/c.txt", server.AdminToken, strings.NewReader("data"), nil)
+}
@@ -0,0 +1,147 @@
+/*
+ * @PackageName: tests
+ * @Description: 虚拟主机、自定义域名及文件访问路径解析测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 09:58:44
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 09:58:44
+ */
+
+package tests
+
+import (
+	"easy_dfs/app/enum/response_code"
+	"easy_dfs/app/enum/user_role"
+	"easy_dfs/app/services"
+	"easy_dfs/pkg/config"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// getViaHost 按指定的 Host 请求文件,返回状态码及内容
+func getViaHost(server *testServer, host, path string) (int, string) {
+	resp := server.request(http.MethodGet, path, "", nil, map[string]string{"Host": host})
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestVirtualHostAndCustomDomain(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{
+		"storage.domain": "files.test",
+		"app.url":        "http://api.test",
+	})
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "my.photos", "accessPolicy": "public"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/my.photos/docs/a.txt", server.AdminToken, strings.NewReader("hello"), nil)
+
+	// 名称包含点的存储桶同样可以通过虚拟主机访问
+	if status, body := getViaHost(server, "my.photos.files.test", "/docs/a.txt"); status != http.StatusOK || body != "hello" {
+		t.Fatalf("Expected virtual host access, got %d %q", status, body)
+	}
+	if status, body := getViaHost(server, "MY.PHOTOS.files.test:8080", "/docs/a.txt"); status != http.StatusOK || body != "hello" {
+		t.Fatalf("Expected host to be case insensitive with port, got %d %q", status, body)
+	}
+	for _, host := range []string{"files.test", "-bad.files.test", "photos.other.test", "api.test", "127.0.0.1"} {
+		if _, body := getViaHost(server, host, "/docs/a.txt"); body == "hello" {
+			t.Errorf("Host %s should not resolve to a bucket", host)
+		}
+	}
+
+	server.mustCall(http.MethodPut, "/bucket/domain", server.AdminToken, map[string]interface{}{"bucket": "my.photos", "domains": []string{"CDN.Example.com"}}, nil)
+	if status, body := getViaHost(server, "cdn.example.com", "/docs/a.txt"); status != http.StatusOK || body != "hello" {
+		t.Fatalf("Expected custom domain access, got %d %q", status, body)
+	}
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "other"}, nil)
+	if result := server.call(http.MethodPut, "/bucket/domain", server.AdminToken, map[string]interface{}{"bucket": "other", "domains": []string{"cdn.example.com"}}, nil); result.Success {
+		t.Fatal("Expected domain used by another bucket to be rejected")
+	}
+
+	// 其他进程直接修改配置文件后,自定义域名缓存同样失效
+	data, err := os.ReadFile("tmp/config/bucket.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buckets []map[string]interface{}
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range buckets {
+		delete(bucket, "domains")
+	}
+	data, _ = json.Marshal(buckets)
+	if err := os.WriteFile("tmp/config/bucket.json", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, body := getViaHost(server, "cdn.example.com", "/docs/a.txt"); body == "hello" {
+		t.Fatal("Expected removed domain to stop resolving")
+	}
+}
+
+// 自定义域名不能使用文件域名及其子域名,否则可以占用其他存储桶的虚拟主机地址
+func TestCustomDomainRejectsStorageDomain(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{
+		"storage.domain": "files.test",
+		"app.url":        "http://api.test",
+	})
+	aliceToken := server.createUser("alice", user_role.BUCKET_OWNER)
+	server.mustCall(http.MethodPost, "/bucket/create", aliceToken, map[string]string{"name": "mine"}, nil)
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "victim", "accessPolicy": "public"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/victim/a.txt", server.AdminToken, strings.NewReader("victim"), nil)
+
+	for _, domain := range []string{"files.test", "victim.files.test", "Victim.Files.Test", "a.b.files.test"} {
+		if result := server.call(http.MethodPut, "/bucket/domain", aliceToken, map[string]interface{}{"bucket": "mine", "domains": []string{domain}}, nil); result.Code != response_code.REQUEST_FAILS || result.Success {
+			t.Errorf("Expected domain %s to be rejected, got %s", domain, result.Code)
+		}
+	}
+	// 相似但不属于文件域名的域名可以使用
+	server.mustCall(http.MethodPut, "/bucket/domain", aliceToken, map[string]interface{}{"bucket": "mine", "domains": []string{"myfiles.test"}}, nil)
+
+	// 修改文件域名前绑定的域名不会覆盖虚拟主机解析
+	config.Set("storage.domain", "")
+	server.mustCall(http.MethodPut, "/bucket/domain", aliceToken, map[string]interface{}{"bucket": "mine", "domains": []string{"victim.files.test"}}, nil)
+	config.Set("storage.domain", "files.test")
+	if status, body := getViaHost(server, "victim.files.test", "/a.txt"); status != http.StatusOK || body != "victim" {
+		t.Errorf("Expected virtual host to resolve to victim, got %d %q", status, body)
+	}
+	if bucket, ok := new(services.BucketService).ResolveHostBucket("files.test"); ok {
+		t.Errorf("Expected storage domain itself not to resolve, got %s", bucket)
+	}
+}
+
+func TestResolveHostBucketWithoutStorageDomain(t *testing.T) {
+	setupTestDir(t, map[string]interface{}{"storage.domain": ""})
+	bs := new(services.BucketService)
+	for _, host := range []string{"", "localhost", "localhost:18088", "10.0.0.1", "[::1]:80", "photos.files.test"} {
+		if bucket, ok := bs.ResolveHostBucket(host); ok {
+			t.Errorf("Host %q should not resolve, got %s", host, bucket)
+		}
+	}
+}
+
+func TestResolveStoragePath(t *testing.T) {
+	fs := new(services.FileService)
+	cases := []struct {
+		path, query string
+		bucket, key string
+		ok          bool
+	}{
+		{"/storage/photos/a/b.png", "", "photos", "a/b.png", true},
+		{"/storage/my.photos/a.png", "my.photos", "my.photos", "a.png", true},
+		{"/storage/photos/../../etc/passwd", "", "photos", "etc/passwd", true},
+		{"/storage/photos/a/./b//c.png", "", "photos", "a/b/c.png", true},
+		{"/storage/photos/", "", "photos", "", true},
+		{"/storage/photos/a.png", "docs", "", "", false},
+		{"/storage//a.png", "", "", "", false},
+		{"/files/photos/a.png", "", "", "", false},
+	}
+	for _, c := range cases {
+		bucket, key, err := fs.ResolveStoragePath(c.path, c.query)
+		if (err == nil) != c.ok || bucket != c.bucket || key != c.key {
+			t.Errorf("%s?bucket=%s: got (%q, %q, %v)", c.path, c.query, bucket, key, err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+/*
+ * @PackageName: tests
+ * @Description: 事件通知测试,覆盖签名密钥在接口返回时的屏蔽、请求签名、重试间隔及重启后继续投递
+ * @Author: gabbymrh
+ * @Date: 2026-10-20 08:02:11
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-20 08:02:11
+ */
+
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"easy_dfs/app/enum/event_type"
+	"easy_dfs/app/services"
+	"easy_dfs/model"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaskWebhookSecrets(t *testing.T) {
+	stored := []model.WebhookConfig{{URL: "http://a.example/hook", Secret: "s3cret"}, {URL: "http://b.example/hook"}}
+	bucketInfo := model.BucketInfo{Name: "docs", Webhooks: stored}
+	services.MaskWebhookSecrets(&bucketInfo)
+
+	data, _ := json.Marshal(bucketInfo)
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatalf("Secret leaked in response: %s", data)
+	}
+	if !bucketInfo.Webhooks[0].HasSecret || bucketInfo.Webhooks[1].HasSecret {
+		t.Fatalf("Unexpected hasSecret flags: %+v", bucketInfo.Webhooks)
+	}
+	// 屏蔽的是副本,保存的配置不受影响
+	if stored[0].Secret != "s3cret" {
+		t.Fatal("Masking modified the stored config")
+	}
+}
+
+// webhookReceiver 接收事件通知的服务,按顺序记录收到的请求,响应状态码由 status 决定
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []webhookRequest
+	received chan struct{}
+}
+
+// webhookRequest 收到的事件通知
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+	at     int64
+}
+
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+	receiver := &webhookReceiver{status: status, received: make(chan struct{}, 16)}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, webhookRequest{header: r.Header.Clone(), body: body, at: time.Now().Unix()})
+		status := receiver.status
+		receiver.mu.Unlock()
+		w.WriteHeader(status)
+		receiver.received <- struct{}{}
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// setStatus 修改之后请求的响应状态码
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// waitRequest 等待第 n 个请求(从 1 开始)
+func (r *webhookReceiver) waitRequest(t *testing.T, n int) webhookRequest {
+	deadline := time.After(15 * time.Second)
+	for {
+		r.mu.Lock()
+		if len(r.requests) >= n {
+			request := r.requests[n-1]
+			r.mu.Unlock()
+			return request
+		}
+		r.mu.Unlock()
+		select {
+		case <-r.received:
+		case <-deadline:
+			t.Fatalf("Webhook request %d not received in time", n)
+		}
+	}
+}
+
+// startWebhookDispatcher 启动投递协程,测试结束时停止,避免之后的测试修改配置时仍在投递
+func startWebhookDispatcher(t *testing.T) *services.WebhookService {
+	ws := new(services.WebhookService)
+	ws.StartDispatcher()
+	t.Cleanup(ws.StopDispatcher)
+	return ws
+}
+
+// waitDelivery 等待投递记录满足条件
+func waitDelivery(t *testing.T, ws *services.WebhookService, done func(model.WebhookDelivery) bool) model.WebhookDelivery {
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		deliveries, err := ws.ListDeliveries("docs", "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) == 1 && done(deliveries[0]) {
+			return deliveries[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Unexpected deliveries %+v", deliveries)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// 请求头携带事件信息及 HMAC-SHA256(secret, timestamp + "." + body) 签名
+func TestWebhookSignature(t *testing.T) {
+	setupTestDir(t, nil)
+	ws := startWebhookDispatcher(t)
+	receiver := newWebhookReceiver(t, http.StatusOK)
+
+	bucketInfo := model.BucketInfo{Name: "docs", Webhooks: []model.WebhookConfig{{URL: receiver.URL, Secret: "s3cret"}}}
+	if err := ws.Publish(event_type.OBJECT_CREATED, bucketInfo, "a.txt", 3); err != nil {
+		t.Fatal(err)
+	}
+	request := receiver.waitRequest(t, 1)
+	delivery := waitDelivery(t, ws, func(d model.WebhookDelivery) bool { return d.Status == services.DeliverySuccess })
+
+	var event model.Event
+	if err := json.Unmarshal(request.body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != event_type.OBJECT_CREATED || event.Bucket != "docs" || event.Key != "a.txt" || event.Size != 3 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if request.header.Get("X-EasyDFS-Event") != event_type.OBJECT_CREATED || request.header.Get("X-EasyDFS-Delivery") != delivery.ID {
+		t.Errorf("Unexpected event headers %v", request.header)
+	}
+	timestamp := request.header.Get("X-EasyDFS-Timestamp")
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(request.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); timestamp == "" || request.header.Get("X-EasyDFS-Signature") != want {
+		t.Errorf("Expected signature %s, got %q (timestamp %q)", want, request.header.Get("X-EasyDFS-Signature"), timestamp)
+	}
+	if delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusOK {
+		t.Errorf("Unexpected delivery %+v", delivery)
+	}
+}
+
+// 投递失败后按指数退避重试,等待时间不超过 retry_max,超出最大次数后标记为失败
+func TestWebhookRetrySchedule(t *testing.T) {
+	setupTestDir(t, map[string]interface{}{"webhook.retry_base": 1, "webhook.retry_max": 2, "webhook.max_attempts": 4})
+	ws := startWebhookDispatcher(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+
+	bucketInfo := model.BucketInfo{Name: "docs", Webhooks: []model.WebhookConfig{{URL: receiver.URL}}}
+	if err := ws.Publish(event_type.OBJECT_CREATED, bucketInfo, "a.txt", 3); err != nil {
+		t.Fatal(err)
+	}
+	// 第 n 次失败后等待 1、2、2 秒
+	for attempt, delay := range []int64{1, 2, 2} {
+		request := receiver.waitRequest(t, attempt+1)
+		delivery := waitDelivery(t, ws, func(d model.WebhookDelivery) bool { return d.Attempts == attempt+1 })
+		if delivery.Status != services.DeliveryPending || delivery.LastStatusCode != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: unexpected delivery %+v", attempt+1, delivery)
+		}
+		// 记录在收到请求的同一秒或下一秒写入
+		if wait := delivery.NextAttemptAt - request.at; wait < delay || wait > delay+1 {
+			t.Errorf("attempt %d: expected retry after %ds, got %ds", attempt+1, delay, wait)
+		}
+		if attempt > 0 {
+			previous := receiver.waitRequest(t, attempt)
+			if request.at-previous.at < delay-1 {
+				t.Errorf("attempt %d: retried %ds after the previous attempt", attempt+1, request.at-previous.at)
+			}
+		}
+	}
+	receiver.waitRequest(t, 4)
+	delivery := waitDelivery(t, ws, func(d model.WebhookDelivery) bool { return d.Status != services.DeliveryPending })
+	if delivery.Status != services.DeliveryFailed || delivery.Attempts != 4 {
+		t.Errorf("Expected delivery to fail after 4 attempts, got %+v", delivery)
+	}
+}
+
+// 未完成的投递记录保存在文件中,重启后继续投递
+func TestWebhookOutboxSurvivesRestart(t *testing.T) {
+	setupTestDir(t, map[string]interface{}{"webhook.retry_base": 1})
+	ws := startWebhookDispatcher(t)
+	receiver := newWebhookReceiver(t, http.StatusServiceUnavailable)
+
+	bucketInfo := model.BucketInfo{Name: "docs", Webhooks: []model.WebhookConfig{{URL: receiver.URL, Secret: "s3cret"}}}
+	if err := ws.Publish(event_type.OBJECT_REMOVED, bucketInfo, "a.txt", 0); err != nil {
+		t.Fatal(err)
+	}
+	pending := waitDelivery(t, ws, func(d model.WebhookDelivery) bool { return d.Attempts == 1 })
+	// 停止投递协程,等待投递结果写入文件
+	ws.StopDispatcher()
+	receiver.setStatus(http.StatusOK)
+
+	outbox := filepath.Join("tmp", "config", "webhook_delivery.json")
+	info, err := os.Stat(outbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected outbox to be readable only by the owner, got %v", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(outbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []model.WebhookDelivery
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].ID != pending.ID || saved[0].Status != services.DeliveryPending || saved[0].Attempts != 1 || saved[0].Secret != "s3cret" {
+		t.Fatalf("Unexpected outbox %s", data)
+	}
+
+	// 将发件箱文件放到新的工作目录后切换过去并重新启动投递协程,服务从文件重新读取投递记录
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tmp", "config"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, outbox), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	ws.StartDispatcher()
+
+	delivery := waitDelivery(t, ws, func(d model.WebhookDelivery) bool { return d.Status == services.DeliverySuccess })
+	if delivery.ID != pending.ID || delivery.Attempts != 2 {
+		t.Errorf("Expected the saved delivery to be retried, got %+v", delivery)
+	}
+	receiver.mu.Lock()
+	last := receiver.requests[len(receiver.requests)-1]
+	receiver.mu.Unlock()
+	if last.header.Get("X-EasyDFS-Delivery") != pending.ID || last.header.Get("X-EasyDFS-Signature") == "" {
+		t.Errorf("Unexpected retried request headers %v", last.header)
+	}
+}
+
+// 响应慢的地址不影响其他地址的投递
+func TestWebhookSlowEndpointDoesNotBlockOthers(t *testing.T) {
+	setupTestDir(t, map[string]interface{}{"webhook.timeout": 60})
+	ws := startWebhookDispatcher(t)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	fast := newWebhookReceiver(t, http.StatusOK)
+
+	slowBucket := model.BucketInfo{Name: "slow", Webhooks: []model.WebhookConfig{{URL: slow.URL}}}
+	if err := ws.Publish(event_type.OBJECT_CREATED, slowBucket, "a.txt", 3); err != nil {
+		t.Fatal(err)
+	}
+	// 等待慢地址开始投递
+	time.Sleep(100 * time.Millisecond)
+	fastBucket := model.BucketInfo{Name: "docs", Webhooks: []model.WebhookConfig{{URL: fast.URL}}}
+	if err := ws.Publish(event_type.OBJECT_CREATED, fastBucket, "b.txt", 3); err != nil {
+		t.Fatal(err)
+	}
+	fast.waitRequest(t, 1)
+	waitDelivery(t, ws, func(d model.WebhookDelivery) bool { return d.Status == services.DeliverySuccess })
+}
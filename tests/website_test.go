@@ -0,0 +1,102 @@
+/*
+ * @PackageName: tests
+ * @Description: 静态网站托管路径解析测试
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 23:41:06
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 23:41:06
+ */
+
+package tests
+
+import (
+	"easy_dfs/pkg/website"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWebsiteResolve(t *testing.T) {
+	files := map[string]bool{
+		"index.html":      true,
+		"404.html":        true,
+		"docs/index.html": true,
+		"css/app.css":     true,
+	}
+	exists := func(key string) bool { return files[key] }
+
+	site := website.Config{
+		ErrorDocument: "404.html",
+		Redirects:     []website.Redirect{{Prefix: "old/", Target: "/new/"}},
+	}
+	if err := site.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		key      string
+		wantKey  string
+		status   int
+		location string
+	}{
+		{"", "index.html", 200, ""},
+		{"docs/", "docs/index.html", 200, ""},
+		{"docs", "", 302, "docs/"},
+		{"css/app.css", "css/app.css", 200, ""},
+		{"missing", "404.html", 404, ""},
+		{"old/a.html", "", 301, "/new/a.html"},
+	}
+	for _, c := range cases {
+		got := site.Resolve(c.key, exists)
+		if got.Key != c.wantKey || got.Status != c.status || got.Location != c.location {
+			t.Errorf("%q: got %+v", c.key, got)
+		}
+	}
+
+	site.SpaFallback = true
+	if got := site.Resolve("app/route/1", exists); got.Key != "index.html" || got.Status != 200 {
+		t.Errorf("spa fallback: got %+v", got)
+	}
+
+	bad := website.Config{Redirects: []website.Redirect{{Prefix: "a/", Target: "/b/", Status: 200}}}
+	if bad.Validate() == nil {
+		t.Error("expected invalid redirect status to be rejected")
+	}
+}
+
+func TestWebsiteChecksAccessBeforeResolve(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{"storage.enforce_private": true})
+	server.mustCall(http.MethodPost, "/bucket/create", server.AdminToken, map[string]string{"name": "site"}, nil)
+	server.mustCall(http.MethodPut, "/file/object/site/docs/index.html", server.AdminToken, strings.NewReader("<p>docs</p>"), nil)
+	server.mustCall(http.MethodPut, "/bucket/website", server.AdminToken, map[string]interface{}{
+		"bucket":  "site",
+		"website": map[string]interface{}{"indexDocument": "index.html"},
+	}, nil)
+
+	// 私有存储桶未签名时,存在及不存在的目录返回相同的结果,不会重定向
+	for _, path := range []string{"/storage/site/docs", "/storage/site/missing", "/storage/site/"} {
+		resp := server.request(http.MethodGet, path, "", nil, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Location") != "" {
+			t.Errorf("%s: expected access denied without redirect, got %d %s", path, resp.StatusCode, resp.Header.Get("Location"))
+		}
+	}
+
+	server.mustCall(http.MethodPut, "/bucket/policy", server.AdminToken, map[string]string{"bucket": "site", "accessPolicy": "public"}, nil)
+	resp := server.request(http.MethodGet, "/storage/site/docs", "", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected directory redirect for public bucket, got %d", resp.StatusCode)
+	}
+
+	// 防盗链拒绝的请求同样不会按网站配置重定向
+	server.mustCall(http.MethodPut, "/bucket/referer", server.AdminToken, map[string]interface{}{
+		"bucket":  "site",
+		"referer": map[string]interface{}{"mode": "allowlist", "referers": []string{"example.com"}},
+	}, nil)
+	resp = server.request(http.MethodGet, "/storage/site/docs", "", nil, map[string]string{"Referer": "https://evil.test/"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected referer check before redirect, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,672 @@
+/*
+ * EasyDFS 管理控制台
+ * 使用会话令牌或访问密钥调用服务端接口,凭据仅保存在当前标签页的 sessionStorage 中
+ */
+(function () {
+  'use strict';
+
+  var SESSION_KEY = 'easydfs.console';
+  // 文本预览的最大字节数
+  var MAX_TEXT_PREVIEW = 2 * 1024 * 1024;
+  var IMAGE_EXTS = ['jpg', 'jpeg', 'png', 'gif', 'webp', 'bmp', 'svg', 'ico'];
+  var TEXT_EXTS = ['txt', 'md', 'json', 'xml', 'yml', 'yaml', 'csv', 'log', 'ini', 'conf', 'html', 'htm', 'css', 'js', 'ts', 'go', 'py', 'java', 'sh', 'sql'];
+
+  var session = JSON.parse(sessionStorage.getItem(SESSION_KEY) || 'null');
+  var $ = function (id) { return document.getElementById(id); };
+
+  /* ------------------ 工具方法 ------------------ */
+
+  // h 创建 DOM 元素,文本内容一律使用 textContent,避免注入
+  function h(tag, attrs) {
+    var el = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      var v = attrs[k];
+      if (v === undefined || v === null || v === false) return;
+      if (k.indexOf('on') === 0) el.addEventListener(k.slice(2), v);
+      else if (k === 'text') el.textContent = v;
+      else if (k === 'class') el.className = v;
+      else el.setAttribute(k, v === true ? '' : v);
+    });
+    for (var i = 2; i < arguments.length; i++) {
+      var child = arguments[i];
+      if (child === undefined || child === null || child === false) continue;
+      [].concat(child).forEach(function (c) {
+        el.appendChild(typeof c === 'string' ? document.createTextNode(c) : c);
+      });
+    }
+    return el;
+  }
+
+  function render(node) {
+    var main = $('main');
+    main.innerHTML = '';
+    main.appendChild(node);
+  }
+
+  function toast(message, isError) {
+    var el = $('toast');
+    el.textContent = message;
+    el.className = 'toast' + (isError ? ' error' : '');
+    clearTimeout(toast.timer);
+    toast.timer = setTimeout(function () { el.className = 'toast hidden'; }, 3000);
+  }
+
+  function formatSize(n) {
+    if (n < 1024) return n + ' B';
+    var units = ['KB', 'MB', 'GB', 'TB'];
+    var i = -1;
+    do { n /= 1024; i++; } while (n >= 1024 && i < units.length - 1);
+    return n.toFixed(n < 10 ? 2 : 1) + ' ' + units[i];
+  }
+
+  function extOf(name) {
+    var i = name.lastIndexOf('.');
+    return i > name.lastIndexOf('/') ? name.slice(i + 1).toLowerCase() : '';
+  }
+
+  function baseName(name) {
+    return name.slice(name.lastIndexOf('/') + 1);
+  }
+
+  /* ------------------ 接口调用 ------------------ */
+
+  function authHeaders(auth) {
+    auth = auth || session;
+    if (auth.token) return { 'Authorization': 'Bearer ' + auth.token };
+    if (auth.accessKey) return { 'X-Access-Key': auth.accessKey, 'X-Secret-Key': auth.secretKey };
+    return {};
+  }
+
+  function query(params) {
+    return Object.keys(params || {}).map(function (k) {
+      return encodeURIComponent(k) + '=' + encodeURIComponent(params[k]);
+    }).join('&');
+  }
+
+  // api 调用接口并解析统一返回结构,失败时抛出带返回码的错误
+  function api(method, path, options) {
+    options = options || {};
+    var headers = authHeaders(options.auth);
+    var body;
+    if (options.json !== undefined) {
+      headers['Content-Type'] = 'application/json';
+      body = JSON.stringify(options.json);
+    }
+    var url = path + (options.query ? '?' + query(options.query) : '');
+    return fetch(url, { method: method, headers: headers, body: body })
+      .then(function (resp) { return resp.json(); })
+      .then(function (result) {
+        if (!result.success) {
+          var err = new Error((result.errors && result.errors.length ? result.errors.join('; ') : result.message) + ' (' + result.code + ')');
+          err.code = result.code;
+          if (result.code === '40005' && !options.auth) logout();
+          throw err;
+        }
+        return result.data;
+      });
+  }
+
+  // download 以当前密钥下载文件,返回 Blob
+  function download(bucket, key) {
+    return fetch('/file/download?' + query({ bucket: bucket, filename: key }), { headers: authHeaders() })
+      .then(function (resp) {
+        if (resp.status === 404) throw new Error('文件不存在');
+        var type = resp.headers.get('Content-Type') || '';
+        if (!resp.ok || type.indexOf('application/json') === 0) {
+          return resp.json().then(function (result) { throw new Error(result.message + ' (' + result.code + ')'); });
+        }
+        return resp.blob();
+      });
+  }
+
+  function fail(err) {
+    toast(err.message || String(err), true);
+  }
+
+  /* ------------------ 登录 ------------------ */
+
+  function showLogin() {
+    $('app').classList.add('hidden');
+    $('login').classList.remove('hidden');
+    // 启用单点登录时显示入口
+    api('GET', '/auth/providers', { auth: {} }).then(function (providers) {
+      $('login-oidc').classList.toggle('hidden', !providers.oidc);
+    }).catch(function () {});
+  }
+
+  function startSession(auth) {
+    session = auth;
+    sessionStorage.setItem(SESSION_KEY, JSON.stringify(auth));
+    showApp();
+  }
+
+  function showApp() {
+    $('login').classList.add('hidden');
+    $('app').classList.remove('hidden');
+    $('current-key').textContent = session.username ? session.username + ' (' + session.role + ')' : session.accessKey;
+    $('nav-users').classList.toggle('hidden', session.role !== 'admin');
+    route();
+  }
+
+  function logout() {
+    session = null;
+    sessionStorage.removeItem(SESSION_KEY);
+    showLogin();
+  }
+
+  // 登录方式:用户名及密码(默认)或访问密钥
+  var useKey = false;
+  $('login-switch').addEventListener('click', function () {
+    useKey = !useKey;
+    $('login-password').classList.toggle('hidden', useKey);
+    $('login-key').classList.toggle('hidden', !useKey);
+    $('login-hint').textContent = useKey ? '使用访问密钥登录管理控制台' : '使用用户名及密码登录管理控制台';
+    $('login-switch').textContent = useKey ? '使用用户名及密码登录' : '使用访问密钥登录';
+    $('login-error').textContent = '';
+  });
+
+  $('login-form').addEventListener('submit', function (e) {
+    e.preventDefault();
+    var form = e.target;
+    var login;
+    if (useKey) {
+      var auth = { accessKey: form.accessKey.value.trim(), secretKey: form.secretKey.value.trim() };
+      login = api('GET', '/auth/me', { auth: auth }).then(function (me) {
+        auth.username = me.username;
+        auth.role = me.role;
+        return auth;
+      });
+    } else {
+      login = api('POST', '/auth/login', { auth: {}, json: { username: form.username.value.trim(), password: form.password.value } })
+        .then(function (result) {
+          return { token: result.token, username: result.user.username, role: result.user.role };
+        });
+    }
+    $('login-error').textContent = '';
+    login.then(function (auth) {
+      form.reset();
+      startSession(auth);
+    }).catch(function (err) {
+      $('login-error').textContent = err.message;
+    });
+  });
+
+  // 单点登录:跳转到身份提供方,登录后回到当前页面,会话令牌附在地址的片段中
+  $('login-oidc').addEventListener('click', function () {
+    location.href = '/auth/oidc/login?' + query({ redirect: location.pathname });
+  });
+
+  // takeOidcResult 取出并清除地址片段中的单点登录结果,没有时返回 null
+  function takeOidcResult() {
+    var params = new URLSearchParams(location.hash.replace(/^#/, ''));
+    if (!params.has('oidc_token') && !params.has('oidc_error')) return null;
+    history.replaceState(null, '', location.pathname + location.search);
+    return params;
+  }
+
+  $('logout').addEventListener('click', logout);
+
+  /* ------------------ 路由 ------------------ */
+
+  // 地址格式: #/buckets、#/browse/{bucket}/{prefix}、#/keys、#/users、#/usage
+  function route() {
+    if (!session) return showLogin();
+    var hash = location.hash.replace(/^#\/?/, '');
+    var parts = hash.split('/');
+    var view = parts[0] || 'buckets';
+
+    document.querySelectorAll('.sidebar a').forEach(function (a) {
+      var v = a.getAttribute('data-view');
+      a.classList.toggle('active', v === view || (v === 'buckets' && view === 'browse'));
+    });
+
+    if (view === 'browse' && parts[1]) {
+      var bucket = decodeURIComponent(parts[1]);
+      var prefix = parts.slice(2).map(decodeURIComponent).join('/');
+      return viewBrowse(bucket, prefix);
+    }
+    if (view === 'keys') return viewKeys();
+    if (view === 'users') return viewUsers();
+    if (view === 'usage') return viewUsage();
+    return viewBuckets();
+  }
+
+  function browseHash(bucket, prefix) {
+    var parts = [encodeURIComponent(bucket)];
+    if (prefix) prefix.replace(/\/$/, '').split('/').forEach(function (p) { parts.push(encodeURIComponent(p)); });
+    return '#/browse/' + parts.join('/') + (prefix ? '/' : '');
+  }
+
+  window.addEventListener('hashchange', route);
+
+  /* ------------------ 存储桶 ------------------ */
+
+  function viewBuckets() {
+    api('GET', '/bucket/list').then(function (buckets) {
+      buckets = buckets || [];
+      var nameInput = h('input', { placeholder: '存储桶名称', required: true });
+      var policySelect = policyOptions('private');
+      var createForm = h('form', {
+        class: 'toolbar', onsubmit: function (e) {
+          e.preventDefault();
+          api('POST', '/bucket/create', { json: { name: nameInput.value.trim(), accessPolicy: policySelect.value } })
+            .then(function () { toast('创建成功'); viewBuckets(); }).catch(fail);
+        }
+      }, nameInput, policySelect, h('button', { type: 'submit', class: 'primary', text: '创建存储桶' }));
+
+      var rows = buckets.map(function (b) {
+        var select = policyOptions(b.accessPolicy);
+        select.addEventListener('change', function () {
+          api('PUT', '/bucket/policy', { json: { bucket: b.name, accessPolicy: select.value } })
+            .then(function () { toast('访问策略已更新'); }).catch(function (err) { select.value = b.accessPolicy; fail(err); });
+        });
+        return h('tr', null,
+          h('td', null, h('a', { href: browseHash(b.name, ''), text: b.name })),
+          h('td', null, select),
+          h('td', { text: b.owner || '-' }),
+          h('td', { text: (b.webhooks || []).length + ' 个' }),
+          h('td', { class: 'actions' }, h('button', {
+            class: 'danger', text: '删除', onclick: function () {
+              if (!confirm('确定删除存储桶 ' + b.name + ' 吗?')) return;
+              api('DELETE', '/bucket/delete', { query: { bucket: b.name } })
+                .then(function () { toast('已删除'); viewBuckets(); }).catch(fail);
+            }
+          })));
+      });
+
+      render(h('div', null,
+        h('h2', { text: '存储桶' }),
+        createForm,
+        h('table', null,
+          h('thead', null, h('tr', null, h('th', { text: '名称' }), h('th', { text: '访问策略' }), h('th', { text: '所有者' }), h('th', { text: '事件通知' }), h('th'))),
+          h('tbody', null, rows.length ? rows : h('tr', null, h('td', { colspan: 5, class: 'empty', text: '暂无存储桶' }))))));
+    }).catch(fail);
+  }
+
+  function policyOptions(current) {
+    var select = h('select', null,
+      h('option', { value: 'private', text: '私有 (private)' }),
+      h('option', { value: 'public-read', text: '公共读 (public-read)' }));
+    // 保留通过接口设置的其他策略值
+    if (current && current !== 'private' && current !== 'public-read') {
+      select.appendChild(h('option', { value: current, text: current }));
+    }
+    select.value = current || 'private';
+    return select;
+  }
+
+  /* ------------------ 文件浏览 ------------------ */
+
+  function viewBrowse(bucket, prefix) {
+    Promise.all([
+      api('GET', '/file/list', { query: { bucket: bucket } }),
+      api('GET', '/bucket/info', { query: { bucket: bucket } })
+    ]).then(function (results) {
+      var files = results[0] || [];
+      var folders = {};
+      var items = [];
+      files.forEach(function (f) {
+        // 接口返回的文件名以存储桶名称开头
+        var key = f.indexOf(bucket + '/') === 0 ? f.slice(bucket.length + 1) : f;
+        if (key.indexOf(prefix) !== 0) return;
+        var rest = key.slice(prefix.length);
+        var slash = rest.indexOf('/');
+        if (slash >= 0) folders[rest.slice(0, slash)] = true;
+        else items.push(key);
+      });
+      items.sort();
+
+      var uploads = h('div', { class: 'uploads' });
+      var fileInput = h('input', { type: 'file', multiple: true, class: 'hidden' });
+      fileInput.addEventListener('change', function () {
+        uploadFiles(bucket, prefix, fileInput.files, uploads);
+        fileInput.value = '';
+      });
+      var dropzone = h('div', { class: 'dropzone' }, '拖拽文件到此处上传到当前目录,或 ',
+        h('button', { class: 'link', text: '选择文件', onclick: function () { fileInput.click(); } }), fileInput);
+      dropzone.addEventListener('dragover', function (e) { e.preventDefault(); dropzone.classList.add('over'); });
+      dropzone.addEventListener('dragleave', function () { dropzone.classList.remove('over'); });
+      dropzone.addEventListener('drop', function (e) {
+        e.preventDefault();
+        dropzone.classList.remove('over');
+        uploadFiles(bucket, prefix, e.dataTransfer.files, uploads);
+      });
+
+      var rows = [];
+      if (prefix) {
+        var parent = prefix.replace(/[^/]*\/$/, '');
+        rows.push(h('tr', null, h('td', { colspan: 3 }, h('a', { href: browseHash(bucket, parent), text: '.. 上级目录' }))));
+      }
+      Object.keys(folders).sort().forEach(function (name) {
+        rows.push(h('tr', null,
+          h('td', null, h('a', { href: browseHash(bucket, prefix + name + '/'), text: '📁 ' + name + '/' })),
+          h('td', { text: '目录' }), h('td')));
+      });
+      items.forEach(function (key) {
+        rows.push(h('tr', null,
+          h('td', null, h('a', { text: baseName(key), onclick: function () { preview(bucket, key); } })),
+          h('td', { text: extOf(key) || '文件' }),
+          h('td', { class: 'actions' },
+            h('button', { class: 'link', text: '预览', onclick: function () { preview(bucket, key); } }),
+            h('button', { class: 'link', text: '下载', onclick: function () { save(bucket, key); } }),
+            h('button', { class: 'link', text: '重命名', onclick: function () { rename(bucket, key, prefix); } }),
+            h('button', { class: 'link danger', text: '删除', onclick: function () { remove(bucket, key, prefix); } }))));
+      });
+
+      render(h('div', null,
+        h('h2', null, breadcrumb(bucket, prefix)),
+        h('p', { class: 'muted small', text: '访问策略: ' + results[1].accessPolicy }),
+        dropzone, uploads,
+        h('table', null,
+          h('thead', null, h('tr', null, h('th', { text: '名称' }), h('th', { text: '类型' }), h('th'))),
+          h('tbody', null, rows.length ? rows : h('tr', null, h('td', { colspan: 3, class: 'empty', text: '当前目录为空' }))))));
+    }).catch(fail);
+  }
+
+  function breadcrumb(bucket, prefix) {
+    var nodes = [h('a', { href: '#/buckets', text: '存储桶' }), ' / ', h('a', { href: browseHash(bucket, ''), text: bucket })];
+    var path = '';
+    prefix.replace(/\/$/, '').split('/').filter(Boolean).forEach(function (p) {
+      path += p + '/';
+      nodes.push(' / ', h('a', { href: browseHash(bucket, path), text: p }));
+    });
+    return h('span', { class: 'breadcrumb' }, nodes);
+  }
+
+  // uploadFiles 逐个上传文件并显示进度,全部完成后刷新列表
+  function uploadFiles(bucket, prefix, fileList, container) {
+    var files = Array.prototype.slice.call(fileList);
+    var next = function () {
+      var file = files.shift();
+      if (!file) return viewBrowse(bucket, prefix);
+      uploadOne(bucket, prefix, file, container).then(next, function (err) { fail(err); next(); });
+    };
+    next();
+  }
+
+  function uploadOne(bucket, prefix, file, container) {
+    var bar = h('progress', { max: 100, value: 0 });
+    var status = h('span', { class: 'status muted', text: '0%' });
+    container.appendChild(h('div', { class: 'upload' }, h('span', { class: 'name', text: file.name }), bar, status));
+
+    // 上传接口按 savePath + saveName + 原文件后缀 保存
+    var ext = extOf(file.name);
+    var form = new FormData();
+    form.append('bucket', bucket);
+    form.append('savePath', prefix);
+    form.append('saveName', ext ? file.name.slice(0, -(ext.length + 1)) : file.name);
+    form.append('file', file);
+
+    return new Promise(function (resolve, reject) {
+      var xhr = new XMLHttpRequest();
+      xhr.open('POST', '/file/upload');
+      var headers = authHeaders();
+      Object.keys(headers).forEach(function (k) { xhr.setRequestHeader(k, headers[k]); });
+      xhr.upload.onprogress = function (e) {
+        if (!e.lengthComputable) return;
+        var pct = Math.round(e.loaded * 100 / e.total);
+        bar.value = pct;
+        status.textContent = pct + '%';
+      };
+      xhr.onload = function () {
+        var result;
+        try { result = JSON.parse(xhr.responseText); } catch (e) { result = { success: false, message: xhr.statusText, code: xhr.status }; }
+        if (result.success) {
+          bar.value = 100;
+          status.textContent = '完成';
+          resolve(result.data);
+        } else {
+          status.textContent = '失败';
+          reject(new Error(file.name + ': ' + ((result.errors || []).join('; ') || result.message)));
+        }
+      };
+      xhr.onerror = function () {
+        status.textContent = '失败';
+        reject(new Error(file.name + ': 网络错误'));
+      };
+      xhr.send(form);
+    });
+  }
+
+  function preview(bucket, key) {
+    var ext = extOf(key);
+    var content = $('modal-content');
+    $('modal-title').textContent = key;
+    content.innerHTML = '';
+    content.appendChild(h('span', { class: 'muted', text: '加载中...' }));
+    $('modal').classList.remove('hidden');
+
+    var isText = TEXT_EXTS.indexOf(ext) >= 0;
+    var check = isText
+      ? api('GET', '/file/info', { query: { bucket: bucket, filename: key } }).then(function (info) {
+        if (info.fileSize > MAX_TEXT_PREVIEW) throw new Error('文件过大,请下载后查看');
+      })
+      : Promise.resolve();
+
+    check.then(function () { return download(bucket, key); }).then(function (blob) {
+      content.innerHTML = '';
+      if (IMAGE_EXTS.indexOf(ext) >= 0) {
+        content.appendChild(h('img', { src: objectURL(blob, ext === 'svg' ? 'image/svg+xml' : blob.type), alt: key }));
+      } else if (ext === 'pdf') {
+        content.appendChild(h('iframe', { src: objectURL(blob, 'application/pdf') }));
+      } else if (isText) {
+        return blob.text().then(function (text) { content.appendChild(h('pre', { text: text })); });
+      } else {
+        content.appendChild(h('div', null,
+          h('p', { class: 'muted', text: '该文件类型不支持预览(' + formatSize(blob.size) + ')' }),
+          h('button', { class: 'primary', text: '下载', onclick: function () { saveBlob(blob, baseName(key)); } })));
+      }
+    }).catch(function (err) {
+      content.innerHTML = '';
+      content.appendChild(h('p', { class: 'error', text: err.message }));
+    });
+  }
+
+  var objectURLs = [];
+  function objectURL(blob, type) {
+    var url = URL.createObjectURL(type ? blob.slice(0, blob.size, type) : blob);
+    objectURLs.push(url);
+    return url;
+  }
+
+  function closeModal() {
+    $('modal').classList.add('hidden');
+    $('modal-content').innerHTML = '';
+    objectURLs.forEach(function (url) { URL.revokeObjectURL(url); });
+    objectURLs = [];
+  }
+
+  $('modal-close').addEventListener('click', closeModal);
+  $('modal').addEventListener('click', function (e) { if (e.target === $('modal')) closeModal(); });
+  document.addEventListener('keydown', function (e) { if (e.key === 'Escape') closeModal(); });
+
+  function save(bucket, key) {
+    download(bucket, key).then(function (blob) { saveBlob(blob, baseName(key)); }).catch(fail);
+  }
+
+  function saveBlob(blob, name) {
+    var url = URL.createObjectURL(blob);
+    var a = h('a', { href: url, download: name });
+    document.body.appendChild(a);
+    a.click();
+    a.remove();
+    setTimeout(function () { URL.revokeObjectURL(url); }, 1000);
+  }
+
+  function rename(bucket, key, prefix) {
+    var to = prompt('新的文件名(可包含目录,如 a/b.txt)', key);
+    if (!to || to === key) return;
+    api('POST', '/file/rename', { json: { bucket: bucket, from: key, to: to.replace(/^\/+/, '') } })
+      .then(function () { toast('重命名成功'); viewBrowse(bucket, prefix); }).catch(fail);
+  }
+
+  function remove(bucket, key, prefix) {
+    if (!confirm('确定删除 ' + key + ' 吗?')) return;
+    api('DELETE', '/file/delete', { query: { bucket: bucket, filename: key } })
+      .then(function () { toast('已删除'); viewBrowse(bucket, prefix); }).catch(fail);
+  }
+
+  /* ------------------ 访问密钥 ------------------ */
+
+  function viewKeys(created) {
+    api('GET', '/access_key/list').then(function (keys) {
+      keys = keys || [];
+      var nameInput = h('input', { placeholder: '密钥名称', required: true });
+      var expireInput = h('input', { placeholder: '过期时间(可选)' });
+      var createForm = h('form', {
+        class: 'toolbar', onsubmit: function (e) {
+          e.preventDefault();
+          api('POST', '/access_key/create', { json: { name: nameInput.value.trim(), expireTime: expireInput.value.trim() } })
+            .then(function (info) { viewKeys(info); }).catch(fail);
+        }
+      }, nameInput, expireInput, h('button', { type: 'submit', class: 'primary', text: '创建密钥' }));
+
+      // 秘钥密码只在创建后显示一次
+      var secret = created && h('div', { class: 'secret' },
+        h('strong', { text: '请妥善保存秘钥密码,关闭后将不再显示' }), h('br'),
+        'Access Key: ', h('code', { text: created.accessKey }), h('br'),
+        'Secret Key: ', h('code', { text: created.secretKey }));
+
+      var rows = keys.map(function (k) {
+        var isCurrent = k.accessKey === session.accessKey;
+        return h('tr', null,
+          h('td', { text: k.name }),
+          h('td', null, h('code', { text: k.accessKey })),
+          h('td', { text: k.status === 1 ? '启用' : '已禁用' }),
+          h('td', { text: k.expireTime || '-' }),
+          h('td', { text: k.owner || '-' }),
+          h('td', { class: 'actions' }, h('button', {
+            class: 'danger', text: '删除', disabled: isCurrent, title: isCurrent ? '不能删除当前登录使用的密钥' : null,
+            onclick: function () {
+              if (!confirm('确定删除访问密钥 ' + k.name + ' 吗?')) return;
+              api('DELETE', '/access_key/delete', { query: { name: k.name } })
+                .then(function () { toast('已删除'); viewKeys(); }).catch(fail);
+            }
+          })));
+      });
+
+      render(h('div', null,
+        h('h2', { text: '访问密钥' }),
+        secret, createForm,
+        h('table', null,
+          h('thead', null, h('tr', null, h('th', { text: '名称' }), h('th', { text: 'Access Key' }), h('th', { text: '状态' }), h('th', { text: '过期时间' }), h('th', { text: '所属用户' }), h('th'))),
+          h('tbody', null, rows.length ? rows : h('tr', null, h('td', { colspan: 6, class: 'empty', text: '暂无访问密钥' }))))));
+    }).catch(fail);
+  }
+
+  /* ------------------ 用户 ------------------ */
+
+  var ROLES = [['admin', '管理员 (admin)'], ['bucket-owner', '存储桶所有者 (bucket-owner)'], ['read-only', '只读 (read-only)']];
+
+  function roleOptions(current) {
+    var select = h('select', null, ROLES.map(function (r) { return h('option', { value: r[0], text: r[1] }); }));
+    select.value = current || 'read-only';
+    return select;
+  }
+
+  function viewUsers() {
+    api('GET', '/user/list').then(function (users) {
+      users = users || [];
+      var nameInput = h('input', { placeholder: '用户名', required: true });
+      var passwordInput = h('input', { type: 'password', placeholder: '密码(至少 8 位)', required: true, autocomplete: 'new-password' });
+      var roleSelect = roleOptions('read-only');
+      var createForm = h('form', {
+        class: 'toolbar', onsubmit: function (e) {
+          e.preventDefault();
+          api('POST', '/user/create', { json: { username: nameInput.value.trim(), password: passwordInput.value, role: roleSelect.value } })
+            .then(function () { toast('创建成功'); viewUsers(); }).catch(fail);
+        }
+      }, nameInput, passwordInput, roleSelect, h('button', { type: 'submit', class: 'primary', text: '创建用户' }));
+
+      var rows = users.map(function (u) {
+        var isCurrent = u.username === session.username;
+        var select = roleOptions(u.role);
+        select.addEventListener('change', function () {
+          api('PUT', '/user/update', { json: { username: u.username, role: select.value } })
+            .then(function () { toast('角色已更新'); }).catch(function (err) { select.value = u.role; fail(err); });
+        });
+        return h('tr', null,
+          h('td', { text: u.username }),
+          h('td', { text: u.provider === 'oidc' ? '单点登录' + (u.groups && u.groups.length ? ' (' + u.groups.join(', ') + ')' : '') : '本地' }),
+          h('td', null, select),
+          h('td', { text: u.status === 1 ? '启用' : '已禁用' }),
+          h('td', { text: u.createdAt || '-' }),
+          h('td', { class: 'actions' },
+            h('button', {
+              text: '禁用', disabled: isCurrent || u.status !== 1, onclick: function () {
+                if (!confirm('禁用用户 ' + u.username + ' 将同时吊销其全部访问密钥,确定吗?')) return;
+                api('POST', '/user/revoke', { query: { username: u.username } })
+                  .then(function () { toast('已禁用'); viewUsers(); }).catch(fail);
+              }
+            }),
+            h('button', {
+              class: 'danger', text: '删除', disabled: isCurrent, onclick: function () {
+                if (!confirm('删除用户 ' + u.username + ' 将同时删除其全部访问密钥,确定吗?')) return;
+                api('DELETE', '/user/delete', { query: { username: u.username } })
+                  .then(function () { toast('已删除'); viewUsers(); }).catch(fail);
+              }
+            })));
+      });
+
+      render(h('div', null,
+        h('h2', { text: '用户' }),
+        createForm,
+        h('table', null,
+          h('thead', null, h('tr', null, h('th', { text: '用户名' }), h('th', { text: '来源' }), h('th', { text: '角色' }), h('th', { text: '状态' }), h('th', { text: '创建时间' }), h('th'))),
+          h('tbody', null, rows.length ? rows : h('tr', null, h('td', { colspan: 6, class: 'empty', text: '暂无用户' }))))));
+    }).catch(fail);
+  }
+
+  /* ------------------ 用量统计 ------------------ */
+
+  function viewUsage() {
+    Promise.all([api('GET', '/bucket/usage'), api('GET', '/bucket/list')]).then(function (results) {
+      var usage = results[0] || {};
+      var buckets = results[1] || [];
+      var total = 0;
+      var max = 0;
+      Object.keys(usage).forEach(function (b) {
+        total += usage[b];
+        max = Math.max(max, usage[b]);
+      });
+      var names = Object.keys(usage).sort(function (a, b) { return usage[b] - usage[a]; });
+
+      var rows = names.map(function (name) {
+        var pct = max ? Math.round(usage[name] * 100 / max) : 0;
+        var bar = h('div', { class: 'bar' }, h('div', { style: 'width:' + pct + '%' }));
+        return h('tr', null,
+          h('td', null, h('a', { href: browseHash(name, ''), text: name })),
+          h('td', { text: formatSize(usage[name]) }),
+          h('td', null, bar));
+      });
+
+      render(h('div', null,
+        h('h2', { text: '用量统计' }),
+        h('div', { class: 'stats' },
+          h('div', { class: 'card' }, h('div', { class: 'muted', text: '存储桶数' }), h('div', { class: 'value', text: String(buckets.length) })),
+          h('div', { class: 'card' }, h('div', { class: 'muted', text: '总占用空间' }), h('div', { class: 'value', text: formatSize(total) }))),
+        h('table', null,
+          h('thead', null, h('tr', null, h('th', { text: '存储桶' }), h('th', { text: '占用空间' }), h('th'))),
+          h('tbody', null, rows.length ? rows : h('tr', null, h('td', { colspan: 3, class: 'empty', text: '暂无数据' }))))));
+    }).catch(fail);
+  }
+
+  /* ------------------ 启动 ------------------ */
+
+  var oidcResult = takeOidcResult();
+  if (oidcResult && oidcResult.get('oidc_token')) {
+    var oidcAuth = { token: oidcResult.get('oidc_token') };
+    api('GET', '/auth/me', { auth: oidcAuth }).then(function (me) {
+      oidcAuth.username = me.username;
+      oidcAuth.role = me.role;
+      startSession(oidcAuth);
+    }).catch(function (err) {
+      showLogin();
+      $('login-error').textContent = err.message;
+    });
+  } else if (oidcResult) {
+    showLogin();
+    $('login-error').textContent = oidcResult.get('oidc_error');
+  } else if (session) showApp();
+  else showLogin();
+})();
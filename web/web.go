@@ -0,0 +1,29 @@
+/*
+ * @PackageName: web
+ * @FileName: web.go
+ * @Description: 内嵌的前端资源
+ * @Author: gabbymrh
+ * @Date: 2026-10-19 19:58:13
+ * @LastModifiedBy: gabbymrh
+ * @LastModifiedAt: 2026-10-19 19:58:13
+ */
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed console
+var consoleFS embed.FS
+
+// Console 返回管理控制台的静态资源(单页应用)
+func Console() fs.FS {
+	sub, err := fs.Sub(consoleFS, "console")
+	if err != nil {
+		// 目录在编译时嵌入,不会出错
+		panic(err)
+	}
+	return sub
+}